@@ -0,0 +1,261 @@
+package gl2d
+
+import (
+	"image"
+	"math"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	activeBackend Backend = NewOpenGLBackend()
+)
+
+// Backend denotes an implementation that renders the primitives of gl2d to a target. Backends are a closed set that mirrors the internal shape representation, so the interface cannot be implemented outside of gl2d. Use NewOpenGLBackend or NewSoftwareBackend to obtain a backend.
+type Backend interface {
+	init() error
+	terminate()
+	begin(width, height int)
+	end()
+	clear(color Color)
+	newTexture(rgba *image.RGBA, params glutil.TextureParameters) (*glutil.Texture, error)
+	newRenderTarget(width, height int, params glutil.TextureParameters) (*glutil.RenderTarget, error)
+	// supportsTarget returns true when the backend is able to render into the given render target.
+	supportsTarget(target *glutil.RenderTarget) bool
+	renderShape(s *shape)
+	flush()
+	drawCalls() int
+	setClipMask(depth int, mode clipMaskMode)
+	setBlendMode(mode BlendMode)
+	pushTarget(target *glutil.RenderTarget)
+	popTarget()
+}
+
+type clipMaskMode int
+
+const (
+	// clipMaskNone renders shapes only where the clip mask depth equals the active depth.
+	clipMaskNone clipMaskMode = iota
+	// clipMaskAdd increases the clip mask depth where shapes are drawn to the new depth.
+	clipMaskAdd
+	// clipMaskRemove decreases the clip mask depth where shapes are drawn from the given depth.
+	clipMaskRemove
+)
+
+type shapeKind int
+
+const (
+	shapeFillCircle shapeKind = iota
+	shapeDrawCircle
+	shapeDrawLine
+	shapeFillRectangle
+	shapeDrawRectangle
+	shapeImage
+	shapeString
+	shapeFillRoundedRectangle
+	shapeDrawRoundedRectangle
+	shapeFillEllipse
+	shapeDrawEllipse
+	shapeDrawArc
+	shapeFillPie
+	shapeBoxShadow
+	shapeFillPath
+	shapeSpreadMask
+	shapeBlurMask
+)
+
+// shape contains all parameters that are required to render a single primitive. The parameter names correspond to the uniforms of the shaders.
+//
+// All coordinates are given in local space and are mapped to screen space by transform.
+type shape struct {
+	kind  shapeKind
+	quad  Quad
+	color Color
+
+	center        mgl32.Vec2
+	radius        float32
+	halfLineWidth float32
+	lineOffspring mgl32.Vec2
+	lineDir       mgl32.Vec2
+	lineLength    float32
+	left, right   float32
+	top, bottom   float32
+	// cornerRadii are given in order top-left, top-right, bottom-right, bottom-left
+	cornerRadii          [4]float32
+	ellipseRadii         mgl32.Vec2
+	startAngle, endAngle float32
+	// shadowSigma denotes the standard deviation of the gaussian blur of box shadows in local units and of blurred masks in texels
+	shadowSigma float32
+	// maskStep denotes the distance of adjacent texels of spread or blurred masks in texture coordinates. Blur passes only step along one axis
+	maskStep mgl32.Vec2
+	// maskSpread denotes the radius in texels by which masks are dilated. Negative values erode masks
+	maskSpread float32
+	// pathSegments denote up to two boundaries of a filled path by x-coordinate at the top, slope dx/dy, top and bottom. The signed area left of a segment is weighted by its winding and added to pathBase
+	pathSegments [2][4]float32
+	pathWindings [2]float32
+	pathBase     float32
+
+	tex                      *glutil.Texture
+	uvTopLeft, uvBottomRight mgl32.Vec2
+	// sdfSpread denotes the distance in texture pixels covered by distance fields of strings. Zero for coverage images
+	sdfSpread    float32
+	outlineWidth float32
+	outlineColor Color
+	// fauxBold denotes the width in local units by which strings are widened to emulate bold fonts. The quad and texture coordinates already include the additional width
+	fauxBold float32
+
+	// paint replaces color for gradients
+	paint shapePaint
+
+	// the following values are computed by renderShape
+	transform    mgl32.Mat3
+	invTransform mgl32.Mat3
+	pixelScale   float32
+	polygon      []mgl32.Vec2
+	blendMode    BlendMode
+}
+
+func (s *shape) isTextured() bool {
+	return s.kind == shapeImage || s.kind == shapeString || s.kind == shapeSpreadMask || s.kind == shapeBlurMask
+}
+
+// sdfUnitsPerTexel returns the size of a texture pixel in local units, which converts distances of distance fields to local space.
+func (s *shape) sdfUnitsPerTexel() float32 {
+	texels := abs32(s.uvBottomRight[0]-s.uvTopLeft[0]) * float32(s.tex.Width)
+	if texels == 0 {
+		return 0
+	}
+	return (s.quad.Right - s.quad.Left) / texels
+}
+
+// fauxBoldUV returns the horizontal texture offset of the widened copy of a string and the horizontal texture coordinates of the glyph image.
+func (s *shape) fauxBoldUV() (offset, left, right float32) {
+	width := s.quad.Right - s.quad.Left
+	uvWidth := s.uvBottomRight[0] - s.uvTopLeft[0]
+	offset = s.fauxBold / width * uvWidth
+	return offset, s.uvTopLeft[0], s.uvBottomRight[0] - offset
+}
+
+// fauxBoldAlpha combines the alpha values of a glyph and its shifted copy. Distance fields are united by their maximum, while coverage is united like overlapping shapes, which avoids dips between the copies of thin strokes.
+func fauxBoldAlpha(original, shifted, sdfSpread float32) float32 {
+	if sdfSpread > 0 {
+		return max32(original, shifted)
+	}
+	return original + shifted - original*shifted
+}
+
+// localPos maps a screen location to the local coordinate system of the shape.
+func (s *shape) localPos(screenPos mgl32.Vec2) mgl32.Vec2 {
+	return transformPoint(s.invTransform, screenPos)
+}
+
+// uv returns the texture coordinates at the given local position.
+func (s *shape) uv(localPos mgl32.Vec2) mgl32.Vec2 {
+	q := s.quad
+	return mgl32.Vec2{
+		s.uvTopLeft[0] + (localPos[0]-q.Left)/(q.Right-q.Left)*(s.uvBottomRight[0]-s.uvTopLeft[0]),
+		s.uvTopLeft[1] + (localPos[1]-q.Top)/(q.Bottom-q.Top)*(s.uvBottomRight[1]-s.uvTopLeft[1]),
+	}
+}
+
+// arcParams returns the direction to the middle of an arc and half of its angular extent, which is limited to Pi.
+func (s *shape) arcParams() (mgl32.Vec2, float32) {
+	mid := (s.startAngle + s.endAngle) / 2
+	halfSweep := min32(abs32(s.endAngle-s.startAngle)/2, math.Pi)
+	return mgl32.Vec2{float32(math.Cos(float64(mid))), float32(math.Sin(float64(mid)))}, halfSweep
+}
+
+// rBlend returns the blending radius in local units, so that edges are always blended across the same screen distance.
+func (s *shape) rBlend() float32 {
+	return rBlend / s.pixelScale
+}
+
+func renderShape(s *shape) {
+	if currentClipMaskMode != clipMaskNone {
+		// mask coverage must not depend on the color of primitives
+		White.applyTo(s)
+		s.outlineColor = White
+	}
+
+	s.transform = transform
+	s.blendMode = blendMode
+	s.pixelScale = transformScale(transform)
+	if s.pixelScale == 0 {
+		// degenerated transformation, nothing visible
+		return
+	}
+	s.invTransform = transform.Inv()
+
+	if clipRect.IsEmpty() {
+		return
+	}
+
+	q := s.quad
+	corners := []mgl32.Vec2{
+		transformPoint(transform, mgl32.Vec2{q.Left, q.Top}),
+		transformPoint(transform, mgl32.Vec2{q.Right, q.Top}),
+		transformPoint(transform, mgl32.Vec2{q.Right, q.Bottom}),
+		transformPoint(transform, mgl32.Vec2{q.Left, q.Bottom}),
+	}
+
+	bounds := polygonBounds(corners)
+	if bounds.Right < clipRect.Left || bounds.Bottom < clipRect.Top || bounds.Left > clipRect.Right || bounds.Top > clipRect.Bottom {
+		// quad is not visible on screen, nothing to draw
+		return
+	}
+
+	// the clipped polygon is used for rasterization, while all shape parameters remain unchanged
+	s.polygon = clipPolygon(corners, clipRect)
+	if len(s.polygon) < 3 {
+		return
+	}
+	activeBackend.renderShape(s)
+}
+
+func polygonBounds(points []mgl32.Vec2) Quad {
+	q := Quad{Left: points[0][0], Right: points[0][0], Top: points[0][1], Bottom: points[0][1]}
+	for _, p := range points[1:] {
+		q.Left = min32(q.Left, p[0])
+		q.Right = max32(q.Right, p[0])
+		q.Top = min32(q.Top, p[1])
+		q.Bottom = max32(q.Bottom, p[1])
+	}
+	return q
+}
+
+// clipPolygon clips a convex polygon against an axis aligned rectangle using the Sutherland-Hodgman algorithm.
+func clipPolygon(points []mgl32.Vec2, clip Quad) []mgl32.Vec2 {
+	// each clip plane is defined by an axis, a limit and the side that is kept
+	planes := []struct {
+		axis int
+		val  float32
+		sign float32
+	}{
+		{0, clip.Left, 1}, {0, clip.Right, -1}, {1, clip.Top, 1}, {1, clip.Bottom, -1},
+	}
+
+	for _, plane := range planes {
+		if len(points) == 0 {
+			break
+		}
+		input := points
+		points = make([]mgl32.Vec2, 0, len(input)+1)
+		for i := range input {
+			cur := input[i]
+			prev := input[(i+len(input)-1)%len(input)]
+			curDist := plane.sign * (cur[plane.axis] - plane.val)
+			prevDist := plane.sign * (prev[plane.axis] - plane.val)
+			if curDist >= 0 {
+				if prevDist < 0 && curDist > 0 {
+					points = append(points, prev.Add(cur.Sub(prev).Mul(prevDist/(prevDist-curDist))))
+				}
+				points = append(points, cur)
+			} else if prevDist > 0 {
+				points = append(points, prev.Add(cur.Sub(prev).Mul(prevDist/(prevDist-curDist))))
+			}
+		}
+	}
+	return points
+}
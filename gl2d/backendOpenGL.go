@@ -0,0 +1,279 @@
+package gl2d
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// OpenGLBackend renders all primitives using OpenGL 2.1 shaders to the current framebuffer.
+type OpenGLBackend struct {
+	progs   map[shapeKind]*glProgram
+	batch   *batch
+	targets []glTargetState
+}
+
+// glTargetState holds the previous framebuffer while rendering to a render target.
+type glTargetState struct {
+	fbo      int32
+	viewport [4]int32
+}
+
+// NewOpenGLBackend returns a new backend that renders using OpenGL. OpenGL needs to be initialized before calling Init.
+func NewOpenGLBackend() *OpenGLBackend {
+	return &OpenGLBackend{}
+}
+
+func (b *OpenGLBackend) init() error {
+	useShadersDefault()
+
+	sources := []struct {
+		name     string
+		kind     shapeKind
+		vertex   string
+		fragment string
+	}{
+		{"fill circle", shapeFillCircle, defaultVertexShader, fillCircleFragmentShader},
+		{"draw circle", shapeDrawCircle, defaultVertexShader, drawCircleFragmentShader},
+		{"draw line", shapeDrawLine, defaultVertexShader, drawLineFragmentShader},
+		{"fill rectangle", shapeFillRectangle, defaultVertexShader, fillRectangleFragmentShader},
+		{"draw rectangle", shapeDrawRectangle, defaultVertexShader, drawRectangleFragmentShader},
+		{"draw image", shapeImage, defaultVertexShader, drawImageFragmentShader},
+		{"draw string", shapeString, defaultVertexShader, drawStringFragmentShader},
+		{"fill rounded rectangle", shapeFillRoundedRectangle, defaultVertexShader, fillRoundedRectangleFragmentShader},
+		{"draw rounded rectangle", shapeDrawRoundedRectangle, defaultVertexShader, drawRoundedRectangleFragmentShader},
+		{"fill ellipse", shapeFillEllipse, defaultVertexShader, fillEllipseFragmentShader},
+		{"draw ellipse", shapeDrawEllipse, defaultVertexShader, drawEllipseFragmentShader},
+		{"draw arc", shapeDrawArc, defaultVertexShader, drawArcFragmentShader},
+		{"fill pie", shapeFillPie, defaultVertexShader, fillPieFragmentShader},
+		{"box shadow", shapeBoxShadow, defaultVertexShader, boxShadowFragmentShader},
+		{"fill path", shapeFillPath, defaultVertexShader, fillPathFragmentShader},
+		{"spread mask", shapeSpreadMask, defaultVertexShader, spreadMaskFragmentShader},
+		{"blur mask", shapeBlurMask, defaultVertexShader, blurMaskFragmentShader},
+	}
+
+	b.progs = make(map[shapeKind]*glProgram)
+	for _, src := range sources {
+		prog, err := glutil.AssembleShaderFromSource(glutil.ShaderSource{
+			Vertex:   src.vertex,
+			Fragment: src.fragment,
+			// some drivers do not render anything when generic attribute 0 is disabled, so ensure that the vertex position always resides there
+			AttribLocations: map[string]uint32{batchAttribNames[0]: 0},
+		})
+		if err != nil {
+			b.terminate()
+			return fmt.Errorf("%s shader: %s", src.name, err.Error())
+		}
+		b.progs[src.kind] = newGLProgram(prog)
+	}
+
+	b.batch = newBatch()
+	return nil
+}
+
+func (b *OpenGLBackend) terminate() {
+	for _, prog := range b.progs {
+		gl.DeleteProgram(prog.prog)
+	}
+	b.progs = nil
+	if b.batch != nil {
+		b.batch.destroy()
+		b.batch = nil
+	}
+}
+
+func (b *OpenGLBackend) begin(width, height int) {
+	b.batch.drawCalls = 0
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Enable(gl.BLEND)
+	b.setBlendMode(BlendNormal)
+
+	// clip masks are stored in the stencil buffer
+	gl.ClearStencil(0)
+	gl.Clear(gl.STENCIL_BUFFER_BIT)
+	gl.Disable(gl.STENCIL_TEST)
+}
+
+func (b *OpenGLBackend) end() {
+	b.batch.flush()
+	gl.UseProgram(0)
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.STENCIL_TEST)
+	gl.Disable(gl.ALPHA_TEST)
+	gl.ColorMask(true, true, true, true)
+}
+
+func (b *OpenGLBackend) clear(color Color) {
+	b.batch.flush()
+	gl.ClearColor(color[0], color[1], color[2], color[3])
+	gl.Clear(gl.COLOR_BUFFER_BIT)
+}
+
+func (b *OpenGLBackend) newTexture(rgba *image.RGBA, params glutil.TextureParameters) (*glutil.Texture, error) {
+	return glutil.TextureFromRGBA(rgba, params)
+}
+
+func (b *OpenGLBackend) newRenderTarget(width, height int, params glutil.TextureParameters) (*glutil.RenderTarget, error) {
+	return glutil.NewRenderTarget(width, height, params)
+}
+
+// supportsTarget rejects render targets in main memory, because binding them would render to the default framebuffer.
+func (b *OpenGLBackend) supportsTarget(target *glutil.RenderTarget) bool {
+	return target.Framebuffer() != 0
+}
+
+func (b *OpenGLBackend) flush() {
+	b.batch.flush()
+}
+
+func (b *OpenGLBackend) drawCalls() int {
+	return b.batch.drawCalls
+}
+
+func (b *OpenGLBackend) setClipMask(depth int, mode clipMaskMode) {
+	b.batch.flush()
+
+	switch mode {
+	case clipMaskAdd, clipMaskRemove:
+		gl.Enable(gl.STENCIL_TEST)
+		if mode == clipMaskAdd {
+			gl.StencilFunc(gl.EQUAL, int32(depth-1), 0xff)
+			gl.StencilOp(gl.KEEP, gl.KEEP, gl.INCR)
+		} else {
+			gl.StencilFunc(gl.EQUAL, int32(depth), 0xff)
+			gl.StencilOp(gl.KEEP, gl.KEEP, gl.DECR)
+		}
+		// only modify the stencil buffer for fragments with at least 50% coverage
+		gl.ColorMask(false, false, false, false)
+		gl.Enable(gl.ALPHA_TEST)
+		gl.AlphaFunc(gl.GREATER, 0.5)
+
+	default:
+		gl.ColorMask(true, true, true, true)
+		gl.Disable(gl.ALPHA_TEST)
+		if depth > 0 {
+			gl.Enable(gl.STENCIL_TEST)
+			gl.StencilFunc(gl.EQUAL, int32(depth), 0xff)
+			gl.StencilOp(gl.KEEP, gl.KEEP, gl.KEEP)
+		} else {
+			gl.Disable(gl.STENCIL_TEST)
+		}
+	}
+}
+
+func (b *OpenGLBackend) pushTarget(target *glutil.RenderTarget) {
+	b.batch.flush()
+
+	var state glTargetState
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &state.fbo)
+	gl.GetIntegerv(gl.VIEWPORT, &state.viewport[0])
+	b.targets = append(b.targets, state)
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, target.Framebuffer())
+	gl.Viewport(0, 0, int32(target.Width()), int32(target.Height()))
+	// the render target has its own stencil buffer for clip masks
+	gl.ClearStencil(0)
+	gl.Clear(gl.STENCIL_BUFFER_BIT)
+	gl.Disable(gl.STENCIL_TEST)
+}
+
+func (b *OpenGLBackend) popTarget() {
+	b.batch.flush()
+
+	state := b.targets[len(b.targets)-1]
+	b.targets = b.targets[:len(b.targets)-1]
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(state.fbo))
+	gl.Viewport(state.viewport[0], state.viewport[1], state.viewport[2], state.viewport[3])
+}
+
+func (b *OpenGLBackend) setBlendMode(mode BlendMode) {
+	b.batch.flush()
+	b.batch.blendMode = mode
+
+	// the shaders convert their output for modes that require premultiplied colors
+	switch mode {
+	case BlendAdditive:
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE)
+	case BlendMultiply:
+		gl.BlendFuncSeparate(gl.DST_COLOR, gl.ZERO, gl.ZERO, gl.ONE)
+	case BlendScreen:
+		gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_COLOR)
+	case BlendPremultiplied:
+		gl.BlendFunc(gl.ONE, gl.ONE_MINUS_SRC_ALPHA)
+	case BlendReplace:
+		gl.BlendFunc(gl.ONE, gl.ZERO)
+	default:
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	}
+}
+
+func (b *OpenGLBackend) renderShape(s *shape) {
+	var tex, paintTex uint32
+	if s.isTextured() {
+		tex = s.tex.Tex
+	}
+	if s.paint.tex != nil {
+		paintTex = s.paint.tex.Tex
+	}
+	b.batch.prepare(b.progs[s.kind], tex, paintTex, 3*(len(s.polygon)-2))
+
+	var params0, params1, params2 [4]float32
+	switch s.kind {
+	case shapeFillCircle, shapeDrawCircle:
+		params0 = [4]float32{s.center[0], s.center[1], s.radius, s.halfLineWidth}
+
+	case shapeDrawArc, shapeFillPie:
+		params0 = [4]float32{s.center[0], s.center[1], s.radius, s.halfLineWidth}
+		midDir, halfSweep := s.arcParams()
+		params2 = [4]float32{midDir[0], midDir[1], halfSweep}
+
+	case shapeDrawLine:
+		params0 = [4]float32{s.lineOffspring[0], s.lineOffspring[1], s.lineDir[0], s.lineDir[1]}
+		params1 = [4]float32{s.lineLength, s.halfLineWidth}
+
+	case shapeFillRectangle, shapeDrawRectangle:
+		params0 = [4]float32{s.left, s.right, s.top, s.bottom}
+		params1 = [4]float32{0, s.halfLineWidth}
+
+	case shapeFillRoundedRectangle, shapeDrawRoundedRectangle:
+		params0 = [4]float32{(s.left + s.right) / 2, (s.top + s.bottom) / 2, (s.right - s.left) / 2, (s.bottom - s.top) / 2}
+		params1 = [4]float32{0, s.halfLineWidth}
+		params2 = s.cornerRadii
+
+	case shapeBoxShadow:
+		params0 = [4]float32{(s.left + s.right) / 2, (s.top + s.bottom) / 2, (s.right - s.left) / 2, (s.bottom - s.top) / 2}
+		params1 = [4]float32{s.shadowSigma}
+		params2 = s.cornerRadii
+
+	case shapeFillPath:
+		params0 = s.pathSegments[0]
+		params1 = [4]float32{s.pathBase, s.pathWindings[0], s.pathWindings[1]}
+		params2 = s.pathSegments[1]
+
+	case shapeString:
+		params0 = [4]float32{s.sdfSpread, s.sdfUnitsPerTexel(), s.outlineWidth}
+		params2 = s.outlineColor
+		if s.fauxBold > 0 {
+			offset, left, right := s.fauxBoldUV()
+			params0[3] = offset
+			params1 = [4]float32{left, right}
+		}
+
+	case shapeSpreadMask:
+		params0 = [4]float32{s.maskStep[0], s.maskStep[1], s.maskSpread}
+
+	case shapeBlurMask:
+		params0 = [4]float32{s.maskStep[0], s.maskStep[1], s.shadowSigma}
+
+	case shapeFillEllipse, shapeDrawEllipse:
+		params0 = [4]float32{s.center[0], s.center[1], s.ellipseRadii[0], s.ellipseRadii[1]}
+		params1 = [4]float32{0, s.halfLineWidth}
+	}
+	params1[3] = s.rBlend()
+
+	b.batch.addShape(s, params0, params1, params2)
+}
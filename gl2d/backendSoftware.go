@@ -0,0 +1,704 @@
+package gl2d
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SoftwareBackend renders all primitives on the CPU to an RGBA image and does not require OpenGL or a GPU. The same anti-aliasing math as in the OpenGL shaders is used, so results are comparable to the OpenGL backend.
+//
+// The target image stores raw color values like an OpenGL framebuffer, i.e. colors are not alpha-premultiplied. Textures need to be created with glutil.MemoryTextureFromRGBA to be visible.
+type SoftwareBackend struct {
+	target *image.RGBA
+	// batch mirrors the batching of the OpenGL backend to count draw calls
+	batch         softwareBatch
+	drawCallCount int
+
+	// stencil holds the clip mask depth of every pixel
+	stencil      []uint8
+	stencilDepth uint8
+	clipMaskMode clipMaskMode
+
+	targetStack []softwareTargetState
+}
+
+// softwareBatch describes the shapes the OpenGL backend would render with a single draw call.
+type softwareBatch struct {
+	kind          shapeKind
+	tex, paintTex *glutil.Texture
+	vertexCount   int
+}
+
+// softwareTargetState holds the previous render target while rendering to a nested render target.
+type softwareTargetState struct {
+	target       *image.RGBA
+	stencil      []uint8
+	stencilDepth uint8
+	clipMaskMode clipMaskMode
+}
+
+// NewSoftwareBackend returns a new backend that renders to the given image.
+func NewSoftwareBackend(target *image.RGBA) *SoftwareBackend {
+	return &SoftwareBackend{target: target}
+}
+
+// Target returns the image all primitives are rendered to.
+func (b *SoftwareBackend) Target() *image.RGBA {
+	return b.target
+}
+
+func (b *SoftwareBackend) init() error { return nil }
+func (b *SoftwareBackend) terminate()  {}
+func (b *SoftwareBackend) begin(_, _ int) {
+	b.batch = softwareBatch{}
+	b.drawCallCount = 0
+
+	pixelCount := b.target.Bounds().Dx() * b.target.Bounds().Dy()
+	if len(b.stencil) != pixelCount {
+		b.stencil = make([]uint8, pixelCount)
+	} else {
+		for i := range b.stencil {
+			b.stencil[i] = 0
+		}
+	}
+	b.stencilDepth = 0
+	b.clipMaskMode = clipMaskNone
+}
+
+func (b *SoftwareBackend) setClipMask(depth int, mode clipMaskMode) {
+	b.flush()
+	b.stencilDepth = uint8(depth)
+	b.clipMaskMode = mode
+}
+
+func (b *SoftwareBackend) pushTarget(target *glutil.RenderTarget) {
+	b.flush()
+	b.targetStack = append(b.targetStack, softwareTargetState{b.target, b.stencil, b.stencilDepth, b.clipMaskMode})
+
+	img := target.Texture.Image
+	b.target = img
+	b.stencil = make([]uint8, img.Bounds().Dx()*img.Bounds().Dy())
+	b.stencilDepth = 0
+	b.clipMaskMode = clipMaskNone
+}
+
+func (b *SoftwareBackend) popTarget() {
+	b.flush()
+	state := b.targetStack[len(b.targetStack)-1]
+	b.targetStack = b.targetStack[:len(b.targetStack)-1]
+	b.target, b.stencil, b.stencilDepth, b.clipMaskMode = state.target, state.stencil, state.stencilDepth, state.clipMaskMode
+}
+
+// setBlendMode only ends the current batch, because every shape contains its blend mode.
+func (b *SoftwareBackend) setBlendMode(_ BlendMode) { b.flush() }
+
+func (b *SoftwareBackend) end() { b.flush() }
+
+func (b *SoftwareBackend) clear(color Color) {
+	b.flush()
+	fillRGBA(b.target, color)
+}
+
+// flush ends the current batch. Shapes are rasterized immediately, so only the draw call is counted.
+func (b *SoftwareBackend) flush() {
+	if b.batch.vertexCount > 0 {
+		b.drawCallCount++
+	}
+	b.batch = softwareBatch{}
+}
+
+// drawCalls returns the number of draw calls the OpenGL backend would have issued for the same shapes.
+func (b *SoftwareBackend) drawCalls() int { return b.drawCallCount }
+
+func (b *SoftwareBackend) newTexture(rgba *image.RGBA, params glutil.TextureParameters) (*glutil.Texture, error) {
+	return glutil.MemoryTextureFromRGBA(rgba, params), nil
+}
+
+func (b *SoftwareBackend) newRenderTarget(width, height int, params glutil.TextureParameters) (*glutil.RenderTarget, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid render target size %dx%d", width, height)
+	}
+	return glutil.NewMemoryRenderTarget(width, height, params), nil
+}
+
+// supportsTarget rejects render targets in graphics memory, because their content is not accessible.
+func (b *SoftwareBackend) supportsTarget(target *glutil.RenderTarget) bool {
+	return target.Texture != nil && target.Texture.Image != nil
+}
+
+func (b *SoftwareBackend) renderShape(s *shape) {
+	if (s.isTextured() && (s.tex == nil || s.tex.Image == nil)) || (s.paint.tex != nil && s.paint.tex.Image == nil) {
+		// texture data is not available in main memory
+		return
+	}
+
+	var tex *glutil.Texture
+	if s.isTextured() {
+		tex = s.tex
+	}
+	vertexCount := 3 * (len(s.polygon) - 2)
+	if b.batch.kind != s.kind || b.batch.tex != tex || b.batch.paintTex != s.paint.tex || b.batch.vertexCount+vertexCount > batchMaxVertices {
+		b.flush()
+		b.batch = softwareBatch{kind: s.kind, tex: tex, paintTex: s.paint.tex}
+	}
+	b.batch.vertexCount += vertexCount
+
+	// a pixel is covered when its center lies inside the polygon
+	polygon := s.polygon
+	if polygonArea(polygon) < 0 {
+		// ensure clockwise orientation on screen in case the transformation mirrors the shape
+		polygon = make([]mgl32.Vec2, len(s.polygon))
+		for i := range s.polygon {
+			polygon[i] = s.polygon[len(s.polygon)-1-i]
+		}
+	}
+
+	q := polygonBounds(polygon)
+	bounds := b.target.Bounds()
+	minX := maxInt(bounds.Min.X, int(ceil32(q.Left-0.5)))
+	maxX := minInt(bounds.Max.X, int(ceil32(q.Right-0.5)))
+	minY := maxInt(bounds.Min.Y, int(ceil32(q.Top-0.5)))
+	maxY := minInt(bounds.Max.Y, int(ceil32(q.Bottom-0.5)))
+
+	for y := minY; y < maxY; y++ {
+		for x := minX; x < maxX; x++ {
+			screenPos := mgl32.Vec2{float32(x) + 0.5, float32(y) + 0.5}
+			if !polygonCoversPixel(polygon, screenPos) {
+				continue
+			}
+			b.renderFragment(s, x, y, screenPos)
+		}
+	}
+}
+
+// renderFragment computes the color of a single pixel and applies it to the target or the clip mask.
+func (b *SoftwareBackend) renderFragment(s *shape, x, y int, screenPos mgl32.Vec2) {
+	stencilIndex := (y-b.target.Rect.Min.Y)*b.target.Rect.Dx() + (x - b.target.Rect.Min.X)
+	switch b.clipMaskMode {
+	case clipMaskAdd:
+		if b.stencil[stencilIndex] == b.stencilDepth-1 {
+			if c, ok := s.fragment(s.localPos(screenPos)); ok && c[3] > 0.5 {
+				b.stencil[stencilIndex] = b.stencilDepth
+			}
+		}
+
+	case clipMaskRemove:
+		if b.stencil[stencilIndex] == b.stencilDepth {
+			if c, ok := s.fragment(s.localPos(screenPos)); ok && c[3] > 0.5 {
+				b.stencil[stencilIndex] = b.stencilDepth - 1
+			}
+		}
+
+	default:
+		if b.stencil[stencilIndex] == b.stencilDepth {
+			if c, ok := s.fragment(s.localPos(screenPos)); ok {
+				blendPixel(b.target, x, y, c, s.blendMode)
+			}
+		}
+	}
+}
+
+// polygonArea returns the signed area of a polygon, which is positive for clockwise orientation on screen.
+func polygonArea(polygon []mgl32.Vec2) float32 {
+	var area float32
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area / 2
+}
+
+// polygonCoversPixel tests whether a pixel center lies inside a clockwise oriented convex polygon. Pixel centers exactly on an edge are only covered for top and left edges to prevent double coverage of adjacent polygons.
+func polygonCoversPixel(polygon []mgl32.Vec2, p mgl32.Vec2) bool {
+	for i := range polygon {
+		a := polygon[i]
+		b := polygon[(i+1)%len(polygon)]
+		dx, dy := b[0]-a[0], b[1]-a[1]
+		if dx == 0 && dy == 0 {
+			continue
+		}
+		e := dx*(p[1]-a[1]) - dy*(p[0]-a[0])
+		if e < 0 {
+			return false
+		}
+		if e == 0 {
+			isTopEdge := dy == 0 && dx > 0
+			isLeftEdge := dy < 0
+			if !isTopEdge && !isLeftEdge {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fragment mirrors the fragment shaders and returns the color at the given location in local coordinates. The second return value is false when the fragment is discarded.
+func (s *shape) fragment(localPos mgl32.Vec2) (Color, bool) {
+	var d, halfLineWidth float32
+	switch s.kind {
+	case shapeFillCircle:
+		d = localPos.Sub(s.center).Len() - 0.5 - s.radius
+
+	case shapeDrawCircle:
+		d = abs32(s.radius - localPos.Sub(s.center).Len())
+		halfLineWidth = s.halfLineWidth
+
+	case shapeDrawLine:
+		dir := localPos.Sub(s.lineOffspring)
+		p := s.lineDir.Dot(dir)
+		if p >= 0 && p <= s.lineLength {
+			d = s.lineOffspring.Add(s.lineDir.Mul(p)).Sub(localPos).Len()
+		} else if p < 0 {
+			d = s.lineOffspring.Sub(localPos).Len()
+		} else {
+			d = s.lineOffspring.Add(s.lineDir.Mul(s.lineLength)).Sub(localPos).Len()
+		}
+		halfLineWidth = s.halfLineWidth
+
+	case shapeFillRectangle:
+		d = max32(max32(s.left-localPos[0], localPos[0]-s.right), max32(s.top-localPos[1], localPos[1]-s.bottom))
+
+	case shapeDrawRectangle:
+		d = abs32(max32(max32(s.left-localPos[0], localPos[0]-s.right), max32(s.top-localPos[1], localPos[1]-s.bottom)))
+		halfLineWidth = s.halfLineWidth
+
+	case shapeFillRoundedRectangle:
+		d = s.roundedRectangleDistance(localPos)
+
+	case shapeDrawRoundedRectangle:
+		d = abs32(s.roundedRectangleDistance(localPos))
+		halfLineWidth = s.halfLineWidth
+
+	case shapeFillEllipse:
+		d = s.ellipseDistance(localPos)
+
+	case shapeDrawEllipse:
+		d = abs32(s.ellipseDistance(localPos))
+		halfLineWidth = s.halfLineWidth
+
+	case shapeDrawArc:
+		midDir, halfSweep := s.arcParams()
+		q := arcLocalPos(localPos.Sub(s.center), midDir)
+		if float32(math.Atan2(float64(q[1]), float64(q[0]))) <= halfSweep {
+			d = abs32(q.Len() - s.radius)
+		} else {
+			d = q.Sub(pointOnCircle(mgl32.Vec2{}, s.radius, halfSweep)).Len()
+		}
+		halfLineWidth = s.halfLineWidth
+
+	case shapeFillPie:
+		midDir, halfSweep := s.arcParams()
+		q := arcLocalPos(localPos.Sub(s.center), midDir)
+		d = q.Len() - s.radius
+		if halfSweep < math.Pi {
+			edge := pointOnCircle(mgl32.Vec2{}, 1, halfSweep)
+			m := q.Sub(edge.Mul(clamp32(q.Dot(edge), 0, s.radius))).Len()
+			d = max32(d, m*sign32(edge[0]*q[1]-edge[1]*q[0]))
+		}
+
+	case shapeBoxShadow:
+		coverage := s.boxShadowCoverage(localPos)
+		if coverage < 0.002 {
+			return Color{}, false
+		}
+		color := s.paintColor(localPos)
+		return color.Alpha(min32(coverage, 1) * color[3]), true
+
+	case shapeFillPath:
+		coverage := pathCoverage(localPos, s.pathBase, s.pathSegments, s.pathWindings)
+		if coverage <= 0 {
+			return Color{}, false
+		}
+		color := s.paintColor(localPos)
+		return color.Alpha(coverage * color[3]), true
+
+	case shapeSpreadMask, shapeBlurMask:
+		var coverage float32
+		if s.kind == shapeSpreadMask {
+			coverage = s.spreadMaskCoverage(s.uv(localPos))
+		} else {
+			coverage = s.blurMaskCoverage(s.uv(localPos))
+		}
+		color := s.paintColor(localPos)
+		return color.Alpha(coverage * color[3]), true
+
+	case shapeImage, shapeString:
+		magnified := abs32(s.uvBottomRight[0]-s.uvTopLeft[0])*float32(s.tex.Width) <= s.pixelScale*(s.quad.Right-s.quad.Left)
+		uv := s.uv(localPos)
+		texColor := sampleTexture(s.tex, uv, magnified)
+		if s.fauxBold > 0 {
+			offset, left, right := s.fauxBoldUV()
+			if uv[0] > right {
+				texColor = Color{}
+			}
+			if shiftedUV := (mgl32.Vec2{uv[0] - offset, uv[1]}); shiftedUV[0] >= left {
+				shifted := sampleTexture(s.tex, shiftedUV, magnified)
+				alpha := fauxBoldAlpha(texColor[3], shifted[3], s.sdfSpread)
+				for i := range texColor {
+					texColor[i] = max32(texColor[i], shifted[i])
+				}
+				texColor[3] = alpha
+			}
+		}
+		if s.kind == shapeImage {
+			texColor = unpremultiply(texColor, s.blendMode)
+		}
+		if s.sdfSpread > 0 {
+			return s.distanceFieldColor(localPos, texColor[3])
+		}
+		return multiplyColors(s.paintColor(localPos), texColor), true
+	}
+
+	f := edgeCoverage(d, halfLineWidth, s.rBlend())
+	if f <= 0 {
+		return Color{}, false
+	}
+	color := s.paintColor(localPos)
+	return color.Alpha(f * color[3]), true
+}
+
+// spreadMaskCoverage mirrors the round dilation and erosion of spreadMaskFragmentShader.
+func (s *shape) spreadMaskCoverage(uv mgl32.Vec2) float32 {
+	radius := abs32(s.maskSpread) + 1
+	var coverage float32
+	for y := -maxShadowSpreadRadius; y <= maxShadowSpreadRadius; y++ {
+		for x := -maxShadowSpreadRadius; x <= maxShadowSpreadRadius; x++ {
+			weight := clamp32(radius-mgl32.Vec2{float32(x), float32(y)}.Len(), 0, 1)
+			if weight <= 0 {
+				continue
+			}
+			a := sampleTexture(s.tex, uv.Add(mgl32.Vec2{float32(x) * s.maskStep[0], float32(y) * s.maskStep[1]}), true)[3]
+			if s.maskSpread < 0 {
+				a = 1 - a
+			}
+			coverage = max32(coverage, a*weight)
+		}
+	}
+	if s.maskSpread < 0 {
+		coverage = 1 - coverage
+	}
+	return coverage
+}
+
+// blurMaskCoverage mirrors the gaussian blur along a single axis of blurMaskFragmentShader.
+func (s *shape) blurMaskCoverage(uv mgl32.Vec2) float32 {
+	radius := ceil32(3 * s.shadowSigma)
+	var coverage, weightSum float32
+	for i := -maxShadowBlurRadius; i <= maxShadowBlurRadius; i++ {
+		x := float32(i)
+		if abs32(x) > radius {
+			continue
+		}
+		weight := float32(math.Exp(float64(-(x * x) / (2 * s.shadowSigma * s.shadowSigma))))
+		coverage += weight * sampleTexture(s.tex, uv.Add(s.maskStep.Mul(x)), true)[3]
+		weightSum += weight
+	}
+	return coverage / weightSum
+}
+
+// distanceFieldColor mirrors the reconstruction of glyph edges and outlines from distance fields in drawStringFragmentShader.
+func (s *shape) distanceFieldColor(localPos mgl32.Vec2, value float32) (Color, bool) {
+	d := (value - 0.5) * 2 * s.sdfSpread * s.sdfUnitsPerTexel()
+	fill := edgeCoverage(-d, 0, s.rBlend())
+	outline := edgeCoverage(-d, s.outlineWidth, s.rBlend()) - fill
+	color := s.paintColor(localPos)
+	fillAlpha := color[3] * fill
+	outlineAlpha := s.outlineColor[3] * outline
+	alpha := fillAlpha + outlineAlpha
+	if alpha <= 0 {
+		return Color{}, false
+	}
+	var c Color
+	for i := 0; i < 3; i++ {
+		c[i] = (color[i]*fillAlpha + s.outlineColor[i]*outlineAlpha) / alpha
+	}
+	c[3] = alpha
+	return c, true
+}
+
+// paintColor mirrors paintColor of the shaders and returns the color of the paint at the given location in local coordinates.
+func (s *shape) paintColor(localPos mgl32.Vec2) Color {
+	return unpremultiply(s.evaluatePaint(localPos), s.blendMode)
+}
+
+func (s *shape) evaluatePaint(localPos mgl32.Vec2) Color {
+	if s.paint.kind == paintSolid {
+		return s.color
+	}
+	paintPos := transformPoint(s.paint.transform, localPos)
+	if s.paint.kind == paintPattern {
+		uv, ok := s.paint.patternUV(paintPos)
+		if !ok {
+			return Color{}
+		}
+		// number of texels per screen pixel decides about the filter
+		texelScale := transformScale(s.paint.transform) * float32(math.Sqrt(float64(s.paint.tex.Width*s.paint.tex.Height))) / s.pixelScale
+		return multiplyColors(s.color, sampleTexture(s.paint.tex, uv, texelScale <= 1))
+	}
+	t := s.paint.gradientOffset(paintPos)
+	return multiplyColors(s.color, sampleTexture(s.paint.tex, mgl32.Vec2{(t*(gradientRampSize-1) + 0.5) / gradientRampSize, 0.5}, true))
+}
+
+func multiplyColors(c1, c2 Color) Color {
+	return Color{c1[0] * c2[0], c1[1] * c2[1], c1[2] * c2[2], c1[3] * c2[3]}
+}
+
+func (s *shape) roundedRectangleDistance(localPos mgl32.Vec2) float32 {
+	center := mgl32.Vec2{(s.left + s.right) / 2, (s.top + s.bottom) / 2}
+	halfSize := mgl32.Vec2{(s.right - s.left) / 2, (s.bottom - s.top) / 2}
+	p := localPos.Sub(center)
+	var r float32
+	if p[0] < 0 {
+		if p[1] < 0 {
+			r = s.cornerRadii[0]
+		} else {
+			r = s.cornerRadii[3]
+		}
+	} else {
+		if p[1] < 0 {
+			r = s.cornerRadii[1]
+		} else {
+			r = s.cornerRadii[2]
+		}
+	}
+	q := mgl32.Vec2{abs32(p[0]) - halfSize[0] + r, abs32(p[1]) - halfSize[1] + r}
+	return mgl32.Vec2{max32(q[0], 0), max32(q[1], 0)}.Len() + min32(max32(q[0], q[1]), 0) - r
+}
+
+func (s *shape) ellipseDistance(localPos mgl32.Vec2) float32 {
+	p := localPos.Sub(s.center)
+	r := s.ellipseRadii
+	k0 := mgl32.Vec2{p[0] / r[0], p[1] / r[1]}.Len()
+	k1 := mgl32.Vec2{p[0] / (r[0] * r[0]), p[1] / (r[1] * r[1])}.Len()
+	if k1 > 0 {
+		return k0 * (k0 - 1) / k1
+	}
+	return -min32(r[0], r[1])
+}
+
+// arcLocalPos rotates p so that the arc is symmetric around the positive x-axis and mirrors it to the upper half.
+func arcLocalPos(p, midDir mgl32.Vec2) mgl32.Vec2 {
+	return mgl32.Vec2{p.Dot(midDir), abs32(midDir[0]*p[1] - midDir[1]*p[0])}
+}
+
+// boxShadowCoverage mirrors the box shadow shader and returns the blurred coverage of a rounded rectangle.
+func (s *shape) boxShadowCoverage(localPos mgl32.Vec2) float32 {
+	center := mgl32.Vec2{(s.left + s.right) / 2, (s.top + s.bottom) / 2}
+	halfSize := mgl32.Vec2{(s.right - s.left) / 2, (s.bottom - s.top) / 2}
+	sigma := s.shadowSigma
+	p := localPos.Sub(center)
+	var r float32
+	if p[0] < 0 {
+		if p[1] < 0 {
+			r = s.cornerRadii[0]
+		} else {
+			r = s.cornerRadii[3]
+		}
+	} else {
+		if p[1] < 0 {
+			r = s.cornerRadii[1]
+		} else {
+			r = s.cornerRadii[2]
+		}
+	}
+
+	if r <= 0 {
+		return boxIntegral(p[0], halfSize[0], sigma) * boxIntegral(p[1], halfSize[1], sigma)
+	}
+	low := p[1] - halfSize[1]
+	high := p[1] + halfSize[1]
+	start := clamp32(-3*sigma, low, high)
+	end := clamp32(3*sigma, low, high)
+	step := (end - start) / 4
+	y := start + 0.5*step
+	var coverage float32
+	for i := 0; i < 4; i++ {
+		delta := min32(halfSize[1]-r-abs32(p[1]-y), 0)
+		curved := halfSize[0] - r + float32(math.Sqrt(float64(max32(0, r*r-delta*delta))))
+		coverage += boxIntegral(p[0], curved, sigma) * gaussian32(y, sigma) * step
+		y += step
+	}
+	return coverage
+}
+
+// boxIntegral returns the integral of a gaussian with standard deviation sigma over the range x-halfSize to x+halfSize.
+func boxIntegral(x, halfSize, sigma float32) float32 {
+	return 0.5 * (erf32((x+halfSize)*0.70710678/sigma) - erf32((x-halfSize)*0.70710678/sigma))
+}
+
+// erf32 mirrors the error function approximation of the shaders.
+func erf32(x float32) float32 {
+	s := sign32(x)
+	a := abs32(x)
+	x = 1 + (0.278393+(0.230389+0.078108*(a*a))*a)*a
+	x *= x
+	return s - s/(x*x)
+}
+
+func gaussian32(x, sigma float32) float32 {
+	return float32(math.Exp(float64(-(x*x)/(2*sigma*sigma)))) / (2.50662827 * sigma)
+}
+
+func sign32(v float32) float32 {
+	if v > 0 {
+		return 1
+	}
+	if v < 0 {
+		return -1
+	}
+	return 0
+}
+
+// edgeCoverage returns the anti-aliased coverage of a ring with distance d to the center line. Filled shapes use a halfLineWidth of 0 and a signed distance d.
+func edgeCoverage(d, halfLineWidth, rBlend float32) float32 {
+	if d <= halfLineWidth-rBlend {
+		return 1
+	}
+	if d >= halfLineWidth+rBlend {
+		return 0
+	}
+	return (2*rBlend + halfLineWidth - rBlend - d) / (2 * rBlend)
+}
+
+// sampleTexture mirrors texture2D for textures residing in main memory.
+func sampleTexture(tex *glutil.Texture, uv mgl32.Vec2, magnified bool) Color {
+	filter := tex.Params.MinFilter
+	if magnified {
+		filter = tex.Params.MagFilter
+	}
+
+	if filter == glutil.TextureFilterNearest {
+		x := int(floor32(uv[0] * float32(tex.Width)))
+		y := int(floor32(uv[1] * float32(tex.Height)))
+		return texel(tex, x, y)
+	}
+
+	tx := uv[0]*float32(tex.Width) - 0.5
+	ty := uv[1]*float32(tex.Height) - 0.5
+	x0 := floor32(tx)
+	y0 := floor32(ty)
+	fx := tx - x0
+	fy := ty - y0
+	c00 := texel(tex, int(x0), int(y0))
+	c10 := texel(tex, int(x0)+1, int(y0))
+	c01 := texel(tex, int(x0), int(y0)+1)
+	c11 := texel(tex, int(x0)+1, int(y0)+1)
+
+	var c Color
+	for i := range c {
+		c[i] = (1-fy)*((1-fx)*c00[i]+fx*c10[i]) + fy*((1-fx)*c01[i]+fx*c11[i])
+	}
+	return c
+}
+
+func texel(tex *glutil.Texture, x, y int) Color {
+	var ok bool
+	if x, ok = wrapTexelCoord(x, tex.Width, tex.Params.WrapS); !ok {
+		return Color{}
+	}
+	if y, ok = wrapTexelCoord(y, tex.Height, tex.Params.WrapT); !ok {
+		return Color{}
+	}
+	img := tex.Image
+	offset := img.PixOffset(img.Rect.Min.X+x, img.Rect.Min.Y+y)
+	return Color{
+		float32(img.Pix[offset+0]) / 255,
+		float32(img.Pix[offset+1]) / 255,
+		float32(img.Pix[offset+2]) / 255,
+		float32(img.Pix[offset+3]) / 255,
+	}
+}
+
+// wrapTexelCoord maps a texel coordinate to the valid range according to the wrap mode. Returns false if the border color should be used instead.
+func wrapTexelCoord(i, n int, wrap glutil.TextureWrap) (int, bool) {
+	switch wrap {
+	case glutil.TextureWrapClampToEdge:
+		return minInt(maxInt(i, 0), n-1), true
+
+	case glutil.TextureWrapClampToBorder:
+		return i, i >= 0 && i < n
+
+	case glutil.TextureWrapMirroredRepeat:
+		m := i % (2 * n)
+		if m < 0 {
+			m += 2 * n
+		}
+		if m >= n {
+			m = 2*n - 1 - m
+		}
+		return m, true
+
+	default:
+		m := i % n
+		if m < 0 {
+			m += n
+		}
+		return m, true
+	}
+}
+
+// blendPixel mixes the color into the target image like the blend functions of the OpenGL backend.
+func blendPixel(img *image.RGBA, x, y int, c Color, mode BlendMode) {
+	offset := img.PixOffset(x, y)
+	a := c[3]
+	for i := 0; i < 4; i++ {
+		dst := float32(img.Pix[offset+i]) / 255
+		var result float32
+		switch mode {
+		case BlendAdditive:
+			result = c[i]*a + dst
+		case BlendMultiply:
+			if i < 3 {
+				result = dst * (1 - a + a*c[i])
+			} else {
+				result = dst
+			}
+		case BlendScreen:
+			if i < 3 {
+				result = c[i]*a + dst*(1-c[i]*a)
+			} else {
+				result = a + dst*(1-a)
+			}
+		case BlendPremultiplied:
+			if i < 3 {
+				result = c[i]*a + dst*(1-a)
+			} else {
+				result = a + dst*(1-a)
+			}
+		case BlendReplace:
+			result = c[i]
+		default:
+			result = c[i]*a + dst*(1-a)
+		}
+		img.Pix[offset+i] = colorComponentToUint8(result)
+	}
+}
+
+// unpremultiply mirrors unpremultiply of the shaders.
+func unpremultiply(c Color, mode BlendMode) Color {
+	if mode == BlendPremultiplied && c[3] > 0 {
+		return Color{c[0] / c[3], c[1] / c[3], c[2] / c[3], c[3]}
+	}
+	return c
+}
+
+func fillRGBA(img *image.RGBA, c Color) {
+	pixel := [4]uint8{colorComponentToUint8(c[0]), colorComponentToUint8(c[1]), colorComponentToUint8(c[2]), colorComponentToUint8(c[3])}
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			offset := img.PixOffset(x, y)
+			copy(img.Pix[offset:offset+4], pixel[:])
+		}
+	}
+}
+
+// colorComponentToUint8 converts a normalized color component to 8 bit. Halfway cases are rounded down to match common GPU implementations.
+func colorComponentToUint8(val float32) uint8 {
+	return uint8(math.Ceil(float64(min32(max32(val, 0), 1)*255) - 0.5))
+}
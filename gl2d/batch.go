@@ -0,0 +1,158 @@
+package gl2d
+
+import (
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// floats per vertex: position (2), local position (2), uv (2), color (4), params0 (4), params1 (4), params2 (4), paint0 (4), paint1 (4)
+	batchVertexSize   = 30
+	batchVertexStride = 4 * batchVertexSize
+	batchMaxVertices  = 6 * 4096
+)
+
+var (
+	batchAttribNames = []string{"vertexPos", "vertexLocalPos", "vertexUV", "vertexColor", "vertexParams0", "vertexParams1", "vertexParams2", "vertexPaint0", "vertexPaint1"}
+	batchAttribSizes = []int32{2, 2, 2, 4, 4, 4, 4, 4, 4}
+)
+
+// glProgram holds a shader program and the locations of all uniforms and attributes used for batched rendering.
+type glProgram struct {
+	prog             uint32
+	projectionMatrix int32
+	tex              int32
+	paintTex         int32
+	blendMode        int32
+	attribs          []int32
+}
+
+func newGLProgram(prog uint32) *glProgram {
+	p := &glProgram{
+		prog:             prog,
+		projectionMatrix: gl.GetUniformLocation(prog, gl.Str("projectionMatrix\x00")),
+		tex:              gl.GetUniformLocation(prog, gl.Str("tex\x00")),
+		paintTex:         gl.GetUniformLocation(prog, gl.Str("paintTex\x00")),
+		blendMode:        gl.GetUniformLocation(prog, gl.Str("blendMode\x00")),
+		attribs:          make([]int32, len(batchAttribNames)),
+	}
+	for i, name := range batchAttribNames {
+		// unused attributes are removed by the shader compiler and yield location -1
+		p.attribs[i] = gl.GetAttribLocation(prog, gl.Str(name+"\x00"))
+	}
+	return p
+}
+
+// batch accumulates the vertices of many shapes that share the same program and texture to render them in a single draw call.
+type batch struct {
+	vbo       uint32
+	vertices  []float32
+	prog      *glProgram
+	tex       uint32
+	paintTex  uint32
+	blendMode BlendMode
+	drawCalls int
+}
+
+func newBatch() *batch {
+	b := &batch{
+		vertices: make([]float32, 0, batchMaxVertices*batchVertexSize),
+	}
+	gl.GenBuffers(1, &b.vbo)
+	return b
+}
+
+func (b *batch) destroy() {
+	gl.DeleteBuffers(1, &b.vbo)
+}
+
+// prepare flushes the batch if the next shape requires another program or textures or the buffer cannot hold the given number of vertices.
+func (b *batch) prepare(prog *glProgram, tex, paintTex uint32, vertexCount int) {
+	if b.prog != prog || b.tex != tex || b.paintTex != paintTex || len(b.vertices)+vertexCount*batchVertexSize > cap(b.vertices) {
+		b.flush()
+		b.prog = prog
+		b.tex = tex
+		b.paintTex = paintTex
+	}
+}
+
+// addShape appends the clipped polygon of a shape as triangle fan. All vertices share the same color and shape parameters.
+func (b *batch) addShape(s *shape, params0, params1, params2 [4]float32) {
+	addVertex := func(pos mgl32.Vec2) {
+		localPos := s.localPos(pos)
+		var uv, paintPos mgl32.Vec2
+		if s.isTextured() {
+			uv = s.uv(localPos)
+		}
+		if s.paint.kind != paintSolid {
+			paintPos = transformPoint(s.paint.transform, localPos)
+		}
+		b.vertices = append(b.vertices, pos[0], pos[1], localPos[0], localPos[1], uv[0], uv[1])
+		b.vertices = append(b.vertices, s.color[:]...)
+		b.vertices = append(b.vertices, params0[:]...)
+		b.vertices = append(b.vertices, params1[:]...)
+		b.vertices = append(b.vertices, params2[:]...)
+		b.vertices = append(b.vertices, paintPos[0], paintPos[1], float32(s.paint.kind), 0)
+		b.vertices = append(b.vertices, s.paint.params[:]...)
+	}
+	for i := 2; i < len(s.polygon); i++ {
+		addVertex(s.polygon[0])
+		addVertex(s.polygon[i-1])
+		addVertex(s.polygon[i])
+	}
+}
+
+// flush renders all pending vertices.
+func (b *batch) flush() {
+	if len(b.vertices) == 0 || b.prog == nil {
+		b.vertices = b.vertices[:0]
+		return
+	}
+
+	gl.UseProgram(b.prog.prog)
+	gl.UniformMatrix4fv(b.prog.projectionMatrix, 1, false, &projectionMatrix[0])
+	if b.prog.blendMode >= 0 {
+		gl.Uniform1f(b.prog.blendMode, float32(b.blendMode))
+	}
+	if b.prog.tex >= 0 {
+		gl.Uniform1i(b.prog.tex, 0)
+		gl.BindTexture(gl.TEXTURE_2D, b.tex)
+	}
+	if b.prog.paintTex >= 0 {
+		gl.Uniform1i(b.prog.paintTex, 1)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, b.paintTex)
+		gl.ActiveTexture(gl.TEXTURE0)
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, b.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(b.vertices), gl.Ptr(b.vertices), gl.STREAM_DRAW)
+	offset := 0
+	for i, loc := range b.prog.attribs {
+		if loc >= 0 {
+			gl.EnableVertexAttribArray(uint32(loc))
+			gl.VertexAttribPointer(uint32(loc), batchAttribSizes[i], gl.FLOAT, false, batchVertexStride, gl.PtrOffset(4*offset))
+		}
+		offset += int(batchAttribSizes[i])
+	}
+
+	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(b.vertices)/batchVertexSize))
+	b.drawCalls++
+
+	for _, loc := range b.prog.attribs {
+		if loc >= 0 {
+			gl.DisableVertexAttribArray(uint32(loc))
+		}
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	if b.prog.tex >= 0 {
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+	}
+	if b.prog.paintTex >= 0 {
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		gl.ActiveTexture(gl.TEXTURE0)
+	}
+
+	b.vertices = b.vertices[:0]
+}
@@ -0,0 +1,58 @@
+package gl2d
+
+// BlendMode denotes how the color of primitives is combined with the existing canvas content.
+type BlendMode int
+
+const (
+	// BlendNormal draws translucent colors over the canvas. This is the default mode.
+	BlendNormal BlendMode = iota
+	// BlendAdditive adds colors to the canvas, e.g. for glow effects and lights.
+	BlendAdditive
+	// BlendMultiply multiplies the canvas with the colors, e.g. for shadows and light maps.
+	BlendMultiply
+	// BlendScreen brightens the canvas by inverting, multiplying and inverting again.
+	BlendScreen
+	// BlendPremultiplied draws colors and textures whose color components are already multiplied with their alpha value.
+	BlendPremultiplied
+	// BlendReplace overwrites the canvas including the alpha channel.
+	BlendReplace
+)
+
+var (
+	blendMode      BlendMode
+	blendModeStack []BlendMode
+)
+
+// SetBlendMode changes the blend mode of all subsequent primitives.
+func SetBlendMode(mode BlendMode) {
+	if mode == blendMode {
+		return
+	}
+	blendMode = mode
+	activeBackend.setBlendMode(mode)
+}
+
+// CurrentBlendMode returns the active blend mode.
+func CurrentBlendMode() BlendMode {
+	return blendMode
+}
+
+// PushBlendMode saves the current blend mode and activates the given one. Use PopBlendMode to restore the previous blend mode.
+func PushBlendMode(mode BlendMode) {
+	blendModeStack = append(blendModeStack, blendMode)
+	SetBlendMode(mode)
+}
+
+// PopBlendMode restores the blend mode saved by the last call to PushBlendMode.
+func PopBlendMode() {
+	if len(blendModeStack) == 0 {
+		panic("PopBlendMode called without matching PushBlendMode")
+	}
+	SetBlendMode(blendModeStack[len(blendModeStack)-1])
+	blendModeStack = blendModeStack[:len(blendModeStack)-1]
+}
+
+func resetBlendModeStack() {
+	blendMode = BlendNormal
+	blendModeStack = blendModeStack[:0]
+}
@@ -1,73 +1,140 @@
 package gl2d
 
 import (
-	"github.com/sbreitf1/go-gl-lib/glutil"
-
-	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-var (
-	fillCircleProg uint32
-	drawCircleProg uint32
-)
-
-func initCircles() error {
-	var err error
-	if fillCircleProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   defaultVertexShader,
-		Fragment: fillCircleFragmentShader,
-	}); err != nil {
-		return err
-	}
-
-	drawCircleProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   defaultVertexShader,
-		Fragment: drawCircleFragmentShader,
-	})
-	return err
+// FillCircle renders a filled circle with given radius.
+func FillCircle(center mgl32.Vec2, radius float32, color Color) {
+	FillCirclePaint(center, radius, color)
 }
 
-func terminateCircles() {
-	gl.DeleteProgram(fillCircleProg)
-	gl.DeleteProgram(drawCircleProg)
+// FillCirclePaint renders a filled circle with given radius using the given paint.
+func FillCirclePaint(center mgl32.Vec2, radius float32, paint Paint) {
+	// respect pixel offset
+	center = center.Add([2]float32{0.5, 0.5})
+
+	renderPaintedShape(paint, &shape{
+		kind:   shapeFillCircle,
+		center: center,
+		radius: radius,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(radius) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(radius) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(radius) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(radius) + ceil32(localRBlend()),
+		},
+	})
 }
 
-// FillCircle renders a filled circle with given radius.
-func FillCircle(center mgl32.Vec2, radius float32, color Color) {
+// DrawCircle renders an outlined circle. The radius denotes the midpoint of the outer ring and thus the outermost visible radius is radius+lineWidth/2.
+func DrawCircle(center mgl32.Vec2, radius float32, lineWidth float32, color Color) {
 	// respect pixel offset
 	center = center.Add([2]float32{0.5, 0.5})
 
-	setupCircleProgram(fillCircleProg, center, radius, color)
+	renderShape(&shape{
+		kind:          shapeDrawCircle,
+		color:         color,
+		center:        center,
+		radius:        radius,
+		halfLineWidth: lineWidth / 2.0,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(localRBlend()),
+		},
+	})
+}
+
+// FillEllipse renders a filled axis-aligned ellipse with given horizontal and vertical radius.
+func FillEllipse(center, radii mgl32.Vec2, color Color) {
+	FillEllipsePaint(center, radii, color)
+}
+
+// FillEllipsePaint renders a filled axis-aligned ellipse using the given paint.
+func FillEllipsePaint(center, radii mgl32.Vec2, paint Paint) {
+	// respect pixel offset and match the size of FillCircle
+	center = center.Add([2]float32{0.5, 0.5})
+	radii = radii.Add([2]float32{0.5, 0.5})
 
-	renderQuad(Quad{
-		Left:   floor32(center.X()) - ceil32(radius) - ceil32(rBlend),
-		Right:  ceil32(center.X()) + ceil32(radius) + ceil32(rBlend),
-		Top:    floor32(center.Y()) - ceil32(radius) - ceil32(rBlend),
-		Bottom: ceil32(center.Y()) + ceil32(radius) + ceil32(rBlend),
+	renderPaintedShape(paint, &shape{
+		kind:         shapeFillEllipse,
+		center:       center,
+		ellipseRadii: radii,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(radii.X()) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(radii.X()) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(radii.Y()) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(radii.Y()) + ceil32(localRBlend()),
+		},
 	})
 }
 
-// DrawCircle renders an outlined circle. The radius denotes the midpoint of the outer ring and thus the outermost visible radius is radius+lineWidth/2.
-func DrawCircle(center mgl32.Vec2, radius float32, lineWidth float32, color Color) {
+// DrawEllipse renders an outlined axis-aligned ellipse. Like DrawCircle, the radii denote the midpoint of the outline.
+func DrawEllipse(center, radii mgl32.Vec2, lineWidth float32, color Color) {
 	// respect pixel offset
 	center = center.Add([2]float32{0.5, 0.5})
 
-	setupCircleProgram(drawCircleProg, center, radius, color)
-	gl.Uniform1f(gl.GetUniformLocation(drawCircleProg, gl.Str("halfLineWidth\x00")), lineWidth/2.0)
+	renderShape(&shape{
+		kind:          shapeDrawEllipse,
+		color:         color,
+		center:        center,
+		ellipseRadii:  radii,
+		halfLineWidth: lineWidth / 2.0,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(lineWidth/2.0) - ceil32(radii.X()) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(lineWidth/2.0) + ceil32(radii.X()) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(lineWidth/2.0) - ceil32(radii.Y()) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(lineWidth/2.0) + ceil32(radii.Y()) + ceil32(localRBlend()),
+		},
+	})
+}
+
+// DrawArc renders a part of a circle outline with round ends. Angles are given in radians, where 0 points to the right and positive angles rotate clockwise on screen.
+func DrawArc(center mgl32.Vec2, radius, startAngle, endAngle, lineWidth float32, color Color) {
+	// respect pixel offset
+	center = center.Add([2]float32{0.5, 0.5})
 
-	renderQuad(Quad{
-		Left:   floor32(center.X()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(rBlend),
-		Right:  ceil32(center.X()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(rBlend),
-		Top:    floor32(center.Y()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(rBlend),
-		Bottom: ceil32(center.Y()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(rBlend),
+	renderShape(&shape{
+		kind:          shapeDrawArc,
+		color:         color,
+		center:        center,
+		radius:        radius,
+		startAngle:    startAngle,
+		endAngle:      endAngle,
+		halfLineWidth: lineWidth / 2.0,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(lineWidth/2.0) - ceil32(radius) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(lineWidth/2.0) + ceil32(radius) + ceil32(localRBlend()),
+		},
 	})
 }
 
-func setupCircleProgram(prog uint32, center mgl32.Vec2, radius float32, color Color) {
-	useProg(prog)
-	gl.Uniform2fv(gl.GetUniformLocation(prog, gl.Str("center\x00")), 1, &center[0])
-	gl.Uniform1f(gl.GetUniformLocation(prog, gl.Str("radius\x00")), radius)
-	gl.Uniform1f(gl.GetUniformLocation(prog, gl.Str("rBlend\x00")), rBlend)
-	gl.Uniform4fv(gl.GetUniformLocation(prog, gl.Str("color\x00")), 1, &color[0])
+// FillPie renders a filled circle sector between two angles. Angles are given like for DrawArc.
+func FillPie(center mgl32.Vec2, radius, startAngle, endAngle float32, color Color) {
+	FillPiePaint(center, radius, startAngle, endAngle, color)
+}
+
+// FillPiePaint renders a filled circle sector using the given paint.
+func FillPiePaint(center mgl32.Vec2, radius, startAngle, endAngle float32, paint Paint) {
+	// respect pixel offset and match the size of FillCircle
+	center = center.Add([2]float32{0.5, 0.5})
+	radius += 0.5
+
+	renderPaintedShape(paint, &shape{
+		kind:       shapeFillPie,
+		center:     center,
+		radius:     radius,
+		startAngle: startAngle,
+		endAngle:   endAngle,
+		quad: Quad{
+			Left:   floor32(center.X()) - ceil32(radius) - ceil32(localRBlend()),
+			Right:  ceil32(center.X()) + ceil32(radius) + ceil32(localRBlend()),
+			Top:    floor32(center.Y()) - ceil32(radius) - ceil32(localRBlend()),
+			Bottom: ceil32(center.Y()) + ceil32(radius) + ceil32(localRBlend()),
+		},
+	})
 }
@@ -3,7 +3,6 @@ package gl2d
 import (
 	"math"
 
-	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
@@ -11,14 +10,24 @@ var (
 	defaultVertexShader         string
 	fillCircleFragmentShader    string
 	drawCircleFragmentShader    string
-	drawImageVertexShader       string
 	drawImageFragmentShader     string
 	drawLineFragmentShader      string
 	fillRectangleFragmentShader string
 	drawRectangleFragmentShader string
-	drawStringVertexShader      string
 	drawStringFragmentShader    string
+
+	fillRoundedRectangleFragmentShader string
+	drawRoundedRectangleFragmentShader string
+	fillEllipseFragmentShader          string
+	drawEllipseFragmentShader          string
+	drawArcFragmentShader              string
+	fillPieFragmentShader              string
+	boxShadowFragmentShader            string
+	fillPathFragmentShader             string
+	spreadMaskFragmentShader           string
+	blurMaskFragmentShader             string
 )
+
 var (
 	// Red denotes opaque color red.
 	Red Color = [4]float32{1, 0, 0, 1}
@@ -75,10 +84,23 @@ func max32(val1, val2 float32) float32 {
 	}
 	return val2
 }
-
-func useProg(prog uint32) {
-	gl.UseProgram(prog)
-	gl.UniformMatrix4fv(gl.GetUniformLocation(prog, gl.Str("projectionMatrix\x00")), 1, false, &projectionMatrix[0])
+func abs32(val float32) float32 {
+	if val < 0 {
+		return -val
+	}
+	return val
+}
+func minInt(val1, val2 int) int {
+	if val1 < val2 {
+		return val1
+	}
+	return val2
+}
+func maxInt(val1, val2 int) int {
+	if val1 > val2 {
+		return val1
+	}
+	return val2
 }
 
 // Quad denotes an axis aligned rectangle.
@@ -106,6 +128,25 @@ func (q Quad) Contains(pos mgl32.Vec2) bool {
 	return pos[0] >= q.Left && pos[0] <= q.Right && pos[1] >= q.Top && pos[1] <= q.Bottom
 }
 
+// IsEmpty returns true, when the quad does not cover any area.
+func (q Quad) IsEmpty() bool {
+	return q.Right <= q.Left || q.Bottom <= q.Top
+}
+
+// IntersectQuads returns the area covered by both quads. The result is empty if the quads do not overlap.
+func IntersectQuads(q1, q2 Quad) Quad {
+	q := Quad{
+		Left:   max32(q1.Left, q2.Left),
+		Right:  min32(q1.Right, q2.Right),
+		Top:    max32(q1.Top, q2.Top),
+		Bottom: min32(q1.Bottom, q2.Bottom),
+	}
+	if q.IsEmpty() {
+		return Quad{Left: q.Left, Right: q.Left, Top: q.Top, Bottom: q.Top}
+	}
+	return q
+}
+
 // FullScreenQuad returns a quad that covers the current canvas completely.
 func FullScreenQuad() Quad {
 	return Quad{0, float32(canvasWidth) - 1, 0, float32(canvasHeight) - 1}
@@ -144,52 +185,3 @@ func ScaleCentered(q Quad, factor float32) Quad {
 		Bottom: q.Bottom + dh,
 	}
 }
-
-func renderQuad(q Quad) {
-	if q.Right < clipRect.Left || q.Bottom < clipRect.Top || q.Left > clipRect.Right || q.Top > clipRect.Bottom {
-		// quad is not visible on screen, nothing to draw
-		return
-	}
-
-	gl.Begin(gl.QUADS)
-	gl.Vertex3f(max32(clipRect.Left, q.Left), max32(clipRect.Top, q.Top), 0)
-	gl.Vertex3f(min32(clipRect.Right, q.Right), max32(clipRect.Top, q.Top), 0)
-	gl.Vertex3f(min32(clipRect.Right, q.Right), min32(clipRect.Bottom, q.Bottom), 0)
-	gl.Vertex3f(max32(clipRect.Left, q.Left), min32(clipRect.Bottom, q.Bottom), 0)
-	gl.End()
-}
-
-func renderTexturedQuad(q Quad, uvTopLeft, uvBottomRight mgl32.Vec2) {
-	if q.Right < clipRect.Left || q.Bottom < clipRect.Top || q.Left > clipRect.Right || q.Top > clipRect.Bottom {
-		// quad is not visible on screen, nothing to draw
-		return
-	}
-
-	uvLeft := uvTopLeft[0]
-	if q.Left < clipRect.Left {
-		uvLeft = uvTopLeft[0] + (clipRect.Left-q.Left)/(q.Right-q.Left)*(uvBottomRight[0]-uvTopLeft[0])
-	}
-	uvTop := uvTopLeft[1]
-	if q.Top < clipRect.Top {
-		uvTop = uvTopLeft[1] + (clipRect.Top-q.Top)/(q.Bottom-q.Top)*(uvBottomRight[1]-uvTopLeft[1])
-	}
-	uvRight := uvBottomRight[0]
-	if q.Right > clipRect.Right {
-		uvRight = uvTopLeft[0] + (clipRect.Right-q.Left)/(q.Right-q.Left)*(uvBottomRight[0]-uvTopLeft[0])
-	}
-	uvBottom := uvBottomRight[1]
-	if q.Bottom > clipRect.Bottom {
-		uvBottom = uvTopLeft[1] + (clipRect.Bottom-q.Top)/(q.Bottom-q.Top)*(uvBottomRight[1]-uvTopLeft[1])
-	}
-
-	gl.Begin(gl.QUADS)
-	gl.TexCoord2f(uvLeft, uvTop)
-	gl.Vertex3f(max32(clipRect.Left, q.Left), max32(clipRect.Top, q.Top), 0)
-	gl.TexCoord2f(uvRight, uvTop)
-	gl.Vertex3f(min32(clipRect.Right, q.Right), max32(clipRect.Top, q.Top), 0)
-	gl.TexCoord2f(uvRight, uvBottom)
-	gl.Vertex3f(min32(clipRect.Right, q.Right), min32(clipRect.Bottom, q.Bottom), 0)
-	gl.TexCoord2f(uvLeft, uvBottom)
-	gl.Vertex3f(max32(clipRect.Left, q.Left), min32(clipRect.Bottom, q.Bottom), 0)
-	gl.End()
-}
@@ -2,36 +2,40 @@ package gl2d
 
 import (
 	"fmt"
+	"image"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
 
-	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
 var (
 	initialized               bool
+	insideFrame               bool
 	canvasWidth, canvasHeight int
 	clipRect                  Quad
+	clipRectStack             []Quad
+	clipMaskStack             []clipMask
+	currentClipMaskMode       clipMaskMode
+	frameTextures             []*glutil.Texture
 
 	projectionMatrix mgl32.Mat4
 )
 
 // Init initializes all OpenGL buffers and should be called once after OpenGL is initialized.
 func Init() error {
-	useShadersDefault()
+	return InitWithBackend(NewOpenGLBackend())
+}
 
-	if err := initCircles(); err != nil {
-		return fmt.Errorf("init gl2d circles: %s", err.Error())
-	}
-	if err := initImages(); err != nil {
-		return fmt.Errorf("init gl2d images: %s", err.Error())
-	}
-	if err := initLines(); err != nil {
-		return fmt.Errorf("init gl2d lines: %s", err.Error())
-	}
-	if err := initRectangles(); err != nil {
-		return fmt.Errorf("init gl2d rectangles: %s", err.Error())
+// InitWithBackend initializes gl2d to render all primitives using the given backend, which is either obtained from NewOpenGLBackend or NewSoftwareBackend.
+func InitWithBackend(backend Backend) error {
+	if err := backend.init(); err != nil {
+		return fmt.Errorf("init gl2d backend: %s", err.Error())
 	}
+	activeBackend = backend
+
 	if err := initText(); err != nil {
+		activeBackend.terminate()
 		return fmt.Errorf("init gl2d text: %s", err.Error())
 	}
 
@@ -41,10 +45,9 @@ func Init() error {
 
 // Terminate releases all OpenGL buffers and should be called when the application is about to exit.
 func Terminate() {
-	terminateLines()
-	terminateCircles()
-	terminateRectangles()
 	terminateText()
+	activeBackend.terminate()
+	initialized = false
 }
 
 // Begin starts rendering a single frame.
@@ -53,26 +56,49 @@ func Begin(width, height int) {
 		panic("need to call gl2d.Init before gl2d.Begin")
 	}
 
+	activeBackend.begin(width, height)
+	startCanvas(width, height, mgl32.Ortho2D(0, float32(width), float32(height), 0))
+	insideFrame = true
+}
+
+// startCanvas resets all state for rendering to a canvas of the given size.
+func startCanvas(width, height int, projection mgl32.Mat4) {
 	canvasWidth = width
 	canvasHeight = height
+	projectionMatrix = projection
 
-	projectionMatrix = mgl32.Ortho2D(0, float32(width), float32(height), 0)
+	resetTransformStack()
+	resetBlendModeStack()
+	clipRectStack = clipRectStack[:0]
+	clipMaskStack = clipMaskStack[:0]
+	currentClipMaskMode = clipMaskNone
+	ResetClipRect()
+}
 
-	gl.Disable(gl.DEPTH_TEST)
-	gl.Enable(gl.BLEND)
-	gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+type clipMask struct {
+	drawMask  func()
+	transform mgl32.Mat3
+	clipRect  Quad
+}
 
-	ResetClipRect()
+// Clear fills the whole canvas with the given color.
+func Clear(color Color) {
+	activeBackend.clear(color)
 }
 
-// SetClipRect will skip rendering outside of the given clip rectangle.
+// SetClipRect will skip rendering outside of the given clip rectangle. The clip rectangle is always given in screen coordinates and is not affected by the current transformation.
 func SetClipRect(q Quad) {
 	clipRect = q
 }
 
 // ResetClipRect allows to render on the whole screen area.
 func ResetClipRect() {
-	clipRect = Quad{
+	clipRect = FullScreenClipRect()
+}
+
+// FullScreenClipRect returns the clip rectangle that covers the whole screen area.
+func FullScreenClipRect() Quad {
+	return Quad{
 		Left:   0,
 		Right:  float32(canvasWidth - 1),
 		Top:    0,
@@ -80,8 +106,115 @@ func ResetClipRect() {
 	}
 }
 
+// ClipRect returns the currently active clip rectangle in screen coordinates.
+func ClipRect() Quad {
+	return clipRect
+}
+
+// PushClipRect saves the current clip rectangle and restricts rendering to the intersection of the current clip rectangle and q. In contrast to SetClipRect, q is given in local coordinates and the bounding box of the transformed rectangle is used.
+func PushClipRect(q Quad) {
+	clipRectStack = append(clipRectStack, clipRect)
+
+	bounds := polygonBounds([]mgl32.Vec2{
+		transformPoint(transform, mgl32.Vec2{q.Left, q.Top}),
+		transformPoint(transform, mgl32.Vec2{q.Right, q.Top}),
+		transformPoint(transform, mgl32.Vec2{q.Right, q.Bottom}),
+		transformPoint(transform, mgl32.Vec2{q.Left, q.Bottom}),
+	})
+	clipRect = IntersectQuads(clipRect, bounds)
+}
+
+// PopClipRect restores the clip rectangle saved by the last call to PushClipRect.
+func PopClipRect() {
+	if len(clipRectStack) == 0 {
+		panic("PopClipRect called without matching PushClipRect")
+	}
+	clipRect = clipRectStack[len(clipRectStack)-1]
+	clipRectStack = clipRectStack[:len(clipRectStack)-1]
+}
+
+// PushClipMask restricts rendering to the area covered by all primitives drawn in drawMask. This allows clipping to arbitrary shapes like circles or rounded rectangles. Masks are intersected with the currently active clip mask and clip rectangle.
+//
+// Primitives drawn in drawMask are not visible and their color is ignored. Mask edges are not anti-aliased, a pixel belongs to the mask when it is covered by more than 50%. The OpenGL backend requires a stencil buffer.
+func PushClipMask(drawMask func()) {
+	if len(clipMaskStack) >= 255 {
+		panic("too many nested clip masks")
+	}
+	if currentClipMaskMode != clipMaskNone {
+		panic("PushClipMask cannot be called while drawing a clip mask")
+	}
+
+	mask := clipMask{drawMask, transform, clipRect}
+	clipMaskStack = append(clipMaskStack, mask)
+	renderClipMask(mask, len(clipMaskStack), clipMaskAdd)
+}
+
+// PopClipMask removes the clip mask added by the last call to PushClipMask.
+func PopClipMask() {
+	if len(clipMaskStack) == 0 {
+		panic("PopClipMask called without matching PushClipMask")
+	}
+	if currentClipMaskMode != clipMaskNone {
+		panic("PopClipMask cannot be called while drawing a clip mask")
+	}
+
+	mask := clipMaskStack[len(clipMaskStack)-1]
+	// replay the mask to revert its changes
+	renderClipMask(mask, len(clipMaskStack), clipMaskRemove)
+	clipMaskStack = clipMaskStack[:len(clipMaskStack)-1]
+	activeBackend.setClipMask(len(clipMaskStack), clipMaskNone)
+}
+
+func renderClipMask(mask clipMask, depth int, mode clipMaskMode) {
+	oldTransform, oldClipRect := transform, clipRect
+	transform, clipRect = mask.transform, mask.clipRect
+
+	currentClipMaskMode = mode
+	activeBackend.setClipMask(depth, mode)
+	mask.drawMask()
+	currentClipMaskMode = clipMaskNone
+	if mode == clipMaskAdd {
+		activeBackend.setClipMask(depth, clipMaskNone)
+	}
+
+	transform, clipRect = oldTransform, oldClipRect
+}
+
+// Flush renders all pending primitives. This is required before issuing custom OpenGL calls between Begin and End, because primitives are batched.
+func Flush() {
+	activeBackend.flush()
+}
+
+// DrawCalls returns the number of draw calls issued by the backend since the last call to Begin. This is intended for profiling.
+func DrawCalls() int {
+	return activeBackend.drawCalls()
+}
+
 // End ends the current 2D frame.
 func End() {
-	gl.UseProgram(0)
-	gl.Disable(gl.BLEND)
+	if len(targetStack) > 0 {
+		panic("End called without matching EndTarget")
+	}
+	activeBackend.end()
+	releaseFrameTextures()
+	releaseUnusedGlyphShadows()
+	insideFrame = false
+}
+
+// newFrameTexture creates a texture that is only valid until the end of the current frame.
+func newFrameTexture(rgba *image.RGBA, params glutil.TextureParameters) (*glutil.Texture, error) {
+	tex, err := activeBackend.newTexture(rgba, params)
+	if err != nil {
+		return nil, err
+	}
+	frameTextures = append(frameTextures, tex)
+	return tex, nil
+}
+
+func releaseFrameTextures() {
+	releaseGradientRamps()
+	for _, tex := range frameTextures {
+		tex.Destroy()
+	}
+	frameTextures = frameTextures[:0]
 }
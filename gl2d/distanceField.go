@@ -0,0 +1,118 @@
+package gl2d
+
+import (
+	"image"
+	"math"
+)
+
+// applyDistanceField replaces the alpha channel of a region by a signed distance field of the rune coverage. Alpha 0.5 denotes the edge and the alpha range [0, 1] covers spread pixels on both sides of the edge.
+func applyDistanceField(img *image.RGBA, region image.Rectangle, spread float32) {
+	width, height := region.Dx(), region.Dy()
+	if width <= 0 || height <= 0 || spread <= 0 {
+		return
+	}
+
+	coverage := make([]float32, width*height)
+	toInside := make([]float64, width*height)
+	toOutside := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			coverage[i] = float32(img.Pix[img.PixOffset(region.Min.X+x, region.Min.Y+y)+3]) / 255
+			if coverage[i] >= 0.5 {
+				toOutside[i] = math.Inf(1)
+			} else {
+				toInside[i] = math.Inf(1)
+			}
+		}
+	}
+	squaredDistanceTransform(toInside, width, height)
+	squaredDistanceTransform(toOutside, width, height)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var d float32
+			if coverage[i] > 0 && coverage[i] < 1 {
+				// anti-aliased pixels locate the edge more precisely than the pixel grid
+				d = coverage[i] - 0.5
+			} else if coverage[i] >= 0.5 {
+				d = float32(math.Sqrt(toOutside[i])) - 0.5
+			} else {
+				d = 0.5 - float32(math.Sqrt(toInside[i]))
+			}
+			a := colorComponentToUint8(0.5 + d/(2*spread))
+			offset := img.PixOffset(region.Min.X+x, region.Min.Y+y)
+			img.Pix[offset+0] = 255
+			img.Pix[offset+1] = 255
+			img.Pix[offset+2] = 255
+			img.Pix[offset+3] = a
+		}
+	}
+}
+
+// squaredDistanceTransform replaces each value by the minimum of f(q)+|p-q|² over all pixels q. Zero values denote the feature pixels and infinite values all others.
+func squaredDistanceTransform(f []float64, width, height int) {
+	n := maxInt(width, height)
+	line := make([]float64, n)
+	result := make([]float64, n)
+	v := make([]int, n)
+	z := make([]float64, n+1)
+
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			line[y] = f[y*width+x]
+		}
+		distanceTransform1D(line[:height], result[:height], v, z)
+		for y := 0; y < height; y++ {
+			f[y*width+x] = result[y]
+		}
+	}
+	for y := 0; y < height; y++ {
+		copy(line, f[y*width:(y+1)*width])
+		distanceTransform1D(line[:width], result[:width], v, z)
+		copy(f[y*width:(y+1)*width], result[:width])
+	}
+}
+
+// distanceTransform1D computes the lower envelope of parabolas as described by Felzenszwalb and Huttenlocher.
+func distanceTransform1D(f, d []float64, v []int, z []float64) {
+	n := len(f)
+	k := -1
+	for q := 0; q < n; q++ {
+		if math.IsInf(f[q], 1) {
+			continue
+		}
+		for k >= 0 {
+			s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+			if s > z[k] {
+				break
+			}
+			k--
+		}
+		k++
+		v[k] = q
+		if k == 0 {
+			z[k] = math.Inf(-1)
+		} else {
+			z[k] = ((f[q] + float64(q*q)) - (f[v[k-1]] + float64(v[k-1]*v[k-1]))) / float64(2*q-2*v[k-1])
+		}
+		z[k+1] = math.Inf(1)
+	}
+
+	if k < 0 {
+		// no feature pixel in this line
+		for q := range d {
+			d[q] = math.Inf(1)
+		}
+		return
+	}
+	k = 0
+	for q := 0; q < n; q++ {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		dq := float64(q - v[k])
+		d[q] = dq*dq + f[v[k]]
+	}
+}
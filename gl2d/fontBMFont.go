@@ -0,0 +1,268 @@
+package gl2d
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// bmFontTag denotes a line of the text format or an element of the XML format of AngelCode BMFont files.
+type bmFontTag struct {
+	name  string
+	attrs map[string]string
+}
+
+func (t bmFontTag) int(key string) (int, error) {
+	val, ok := t.attrs[key]
+	if !ok {
+		return 0, fmt.Errorf("%s is missing attribute %q", t.name, key)
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s attribute %q: %s", t.name, key, err.Error())
+	}
+	return i, nil
+}
+
+// LoadBMFont reads a font in the text or XML format of the AngelCode BMFont tool. The page images are loaded relative to the font file. Multiple pages are combined to a single image.
+func LoadBMFont(file string) (*BitmapFont, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(file)
+	return ReadBMFont(f, func(pageFile string) (image.Image, error) {
+		fPage, err := os.Open(filepath.Join(dir, pageFile))
+		if err != nil {
+			return nil, err
+		}
+		defer fPage.Close()
+		img, _, err := image.Decode(fPage)
+		return img, err
+	})
+}
+
+// ReadBMFont reads a font in the text or XML format of the AngelCode BMFont tool. loadPage is called for each page image referenced by the font. The binary format is not supported.
+func ReadBMFont(r io.Reader, loadPage func(file string) (image.Image, error)) (*BitmapFont, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []bmFontTag
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("BMF")):
+		return nil, fmt.Errorf("binary BMFont files are not supported")
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		tags, err = parseBMFontXML(trimmed)
+	default:
+		tags, err = parseBMFontText(trimmed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newBitmapFontFromBMFont(tags, loadPage)
+}
+
+func parseBMFontText(data []byte) ([]bmFontTag, error) {
+	var tags []bmFontTag
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		nameEnd := strings.IndexAny(line, " \t")
+		if nameEnd < 0 {
+			nameEnd = len(line)
+		}
+		tag := bmFontTag{name: line[:nameEnd], attrs: make(map[string]string)}
+		rest := line[nameEnd:]
+		for {
+			rest = strings.TrimLeft(rest, " \t")
+			if len(rest) == 0 {
+				break
+			}
+			eq := strings.IndexByte(rest, '=')
+			if eq < 0 {
+				return nil, fmt.Errorf("invalid attribute %q in %s", rest, tag.name)
+			}
+			key := rest[:eq]
+			rest = rest[eq+1:]
+			var val string
+			if strings.HasPrefix(rest, "\"") {
+				end := strings.IndexByte(rest[1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated value of %q in %s", key, tag.name)
+				}
+				val, rest = rest[1:end+1], rest[end+2:]
+			} else {
+				end := strings.IndexAny(rest, " \t")
+				if end < 0 {
+					end = len(rest)
+				}
+				val, rest = rest[:end], rest[end:]
+			}
+			tag.attrs[key] = val
+		}
+		tags = append(tags, tag)
+	}
+	return tags, scanner.Err()
+}
+
+func parseBMFontXML(data []byte) ([]bmFontTag, error) {
+	var tags []bmFontTag
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse BMFont XML: %s", err.Error())
+		}
+		if element, ok := token.(xml.StartElement); ok {
+			tag := bmFontTag{name: element.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range element.Attr {
+				tag.attrs[attr.Name.Local] = attr.Value
+			}
+			tags = append(tags, tag)
+		}
+	}
+}
+
+func newBitmapFontFromBMFont(tags []bmFontTag, loadPage func(file string) (image.Image, error)) (*BitmapFont, error) {
+	font := &BitmapFont{
+		runeRects:   make(map[rune]runeRect),
+		runeWidths:  make(map[rune]float32),
+		kernings:    make(map[rune]map[rune]float32),
+		runeOffsets: make(map[rune]mgl32.Vec2),
+	}
+
+	// pages are stacked vertically
+	pages := make(map[int]image.Image)
+	var pageIDs []int
+	for _, tag := range tags {
+		switch tag.name {
+		case "common":
+			lineHeight, err := tag.int("lineHeight")
+			if err != nil {
+				return nil, err
+			}
+			base, err := tag.int("base")
+			if err != nil {
+				return nil, err
+			}
+			// BMFont does not distinguish between descent and line gap
+			font.lineHeight, font.ascent, font.descent = float32(lineHeight), float32(base), float32(lineHeight-base)
+		case "page":
+			id, err := tag.int("id")
+			if err != nil {
+				return nil, err
+			}
+			img, err := loadPage(tag.attrs["file"])
+			if err != nil {
+				return nil, fmt.Errorf("load page %q: %s", tag.attrs["file"], err.Error())
+			}
+			pages[id] = img
+			pageIDs = append(pageIDs, id)
+		}
+	}
+	if font.lineHeight <= 0 {
+		return nil, fmt.Errorf("BMFont is missing the line height")
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("BMFont does not contain any page")
+	}
+
+	width, height := 0, 0
+	pageTops := make(map[int]int)
+	for _, id := range pageIDs {
+		size := pages[id].Bounds().Size()
+		pageTops[id] = height
+		width = maxInt(width, size.X)
+		height += size.Y
+	}
+	combined := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, id := range pageIDs {
+		img := pages[id]
+		draw.Draw(combined, img.Bounds().Sub(img.Bounds().Min).Add(image.Point{Y: pageTops[id]}), img, img.Bounds().Min, draw.Src)
+	}
+	font.image = fontImageFromImage(combined)
+
+	for _, tag := range tags {
+		switch tag.name {
+		case "char":
+			var vals [8]int
+			for i, key := range []string{"id", "x", "y", "width", "height", "xoffset", "yoffset", "xadvance"} {
+				val, err := tag.int(key)
+				if err != nil {
+					return nil, err
+				}
+				vals[i] = val
+			}
+			if vals[0] < 0 {
+				// some tools export the replacement glyph with id -1
+				continue
+			}
+			r := rune(vals[0])
+			font.runeWidths[r] = float32(vals[7])
+			if vals[3] <= 0 || vals[4] <= 0 {
+				continue
+			}
+			page := 0
+			if _, ok := tag.attrs["page"]; ok {
+				var err error
+				if page, err = tag.int("page"); err != nil {
+					return nil, err
+				}
+			}
+			top, ok := pageTops[page]
+			if !ok {
+				return nil, fmt.Errorf("char %d refers to unknown page %d", vals[0], page)
+			}
+			x, y := float32(vals[1]), float32(vals[2]+top)
+			font.runeRects[r] = runeRect{
+				topLeft:     mgl32.Vec2{x / float32(width), y / float32(height)},
+				bottomRight: mgl32.Vec2{(x + float32(vals[3])) / float32(width), (y + float32(vals[4])) / float32(height)},
+			}
+			font.runeOffsets[r] = mgl32.Vec2{float32(vals[5]), float32(vals[6])}
+
+		case "kerning":
+			first, err := tag.int("first")
+			if err != nil {
+				return nil, err
+			}
+			second, err := tag.int("second")
+			if err != nil {
+				return nil, err
+			}
+			amount, err := tag.int("amount")
+			if err != nil {
+				return nil, err
+			}
+			if font.kernings[rune(first)] == nil {
+				font.kernings[rune(first)] = make(map[rune]float32)
+			}
+			font.kernings[rune(first)][rune(second)] = float32(amount)
+		}
+	}
+	if len(font.kernings) == 0 {
+		font.kernings = nil
+	}
+	return font, nil
+}
@@ -0,0 +1,214 @@
+package gl2d
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// bundleImageFile and bundleMetaFile denote the entries of a font bundle.
+	bundleImageFile = "font.png"
+	bundleMetaFile  = "font.json"
+)
+
+// LoadBitmapFont reads a font that has been written by BitmapFont.Export.
+func LoadBitmapFont(imageFile, metaFile string) (*BitmapFont, error) {
+	fImage, err := os.Open(imageFile)
+	if err != nil {
+		return nil, err
+	}
+	defer fImage.Close()
+
+	metaData, err := ioutil.ReadFile(metaFile)
+	if err != nil {
+		return nil, err
+	}
+	return readBitmapFont(fImage, metaData)
+}
+
+// ExportBundle writes the font image and meta data to a single zip file that can be loaded with LoadBitmapFontBundle.
+func (f *BitmapFont) ExportBundle(file string) error {
+	fBundle, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer fBundle.Close()
+
+	if err := f.WriteBundle(fBundle); err != nil {
+		return err
+	}
+	return fBundle.Close()
+}
+
+// WriteBundle writes the font image and meta data as zip archive.
+func (f *BitmapFont) WriteBundle(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	imageWriter, err := archive.Create(bundleImageFile)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(imageWriter, f.image); err != nil {
+		return err
+	}
+
+	metaData, err := f.metaData()
+	if err != nil {
+		return err
+	}
+	metaWriter, err := archive.Create(bundleMetaFile)
+	if err != nil {
+		return err
+	}
+	if _, err := metaWriter.Write(metaData); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// LoadBitmapFontBundle reads a font bundle that has been written by BitmapFont.ExportBundle.
+func LoadBitmapFontBundle(file string) (*BitmapFont, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return ReadBitmapFontBundle(bytes.NewReader(data), int64(len(data)))
+}
+
+// ReadBitmapFontBundle reads a font bundle from a zip archive of the given size.
+func ReadBitmapFontBundle(r io.ReaderAt, size int64) (*BitmapFont, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open font bundle: %s", err.Error())
+	}
+
+	readEntry := func(name string) ([]byte, error) {
+		for _, entry := range archive.File {
+			if entry.Name == name {
+				f, err := entry.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer f.Close()
+				return ioutil.ReadAll(f)
+			}
+		}
+		return nil, fmt.Errorf("font bundle does not contain %q", name)
+	}
+
+	imageData, err := readEntry(bundleImageFile)
+	if err != nil {
+		return nil, err
+	}
+	metaData, err := readEntry(bundleMetaFile)
+	if err != nil {
+		return nil, err
+	}
+	return readBitmapFont(bytes.NewReader(imageData), metaData)
+}
+
+// NewFontFromBundle loads a font bundle and transfers the font image to graphics memory.
+func NewFontFromBundle(file string) (*Font, error) {
+	bitmapFont, err := LoadBitmapFontBundle(file)
+	if err != nil {
+		return nil, err
+	}
+	return NewFontFromBitmapFont(bitmapFont)
+}
+
+func readBitmapFont(imageReader io.Reader, metaData []byte) (*BitmapFont, error) {
+	img, _, err := image.Decode(imageReader)
+	if err != nil {
+		return nil, fmt.Errorf("decode font image: %s", err.Error())
+	}
+
+	var meta bitmapFontMetaData
+	if err := json.Unmarshal(metaData, &meta); err != nil {
+		return nil, fmt.Errorf("decode font meta data: %s", err.Error())
+	}
+	if meta.LineHeight <= 0 {
+		return nil, fmt.Errorf("font meta data is missing the line height")
+	}
+
+	font := &BitmapFont{
+		image:      fontImageFromImage(img),
+		runeRects:  make(map[rune]runeRect),
+		lineHeight: meta.LineHeight,
+		ascent:     meta.Ascent,
+		descent:    meta.Descent,
+		runeWidths: meta.RuneWidths,
+		kernings:   meta.Kernings,
+		sdfSpread:  meta.SDFSpread,
+	}
+	if font.runeWidths == nil {
+		font.runeWidths = make(map[rune]float32)
+	}
+	for r, rect := range meta.RuneRects {
+		font.runeRects[r] = runeRect{
+			topLeft:     mgl32.Vec2{rect[0], rect[1]},
+			bottomRight: mgl32.Vec2{rect[2], rect[3]},
+		}
+		if _, ok := font.runeWidths[r]; !ok {
+			return nil, fmt.Errorf("font meta data is missing the width of rune %q", r)
+		}
+	}
+	if len(meta.RuneBounds) > 0 {
+		font.runeBounds = make(map[rune]Quad)
+		for r, bounds := range meta.RuneBounds {
+			font.runeBounds[r] = Quad{Left: bounds[0], Top: bounds[1], Right: bounds[2], Bottom: bounds[3]}
+		}
+	}
+	if len(meta.RuneOffsets) > 0 {
+		font.runeOffsets = make(map[rune]mgl32.Vec2)
+		for r, offset := range meta.RuneOffsets {
+			font.runeOffsets[r] = offset
+		}
+	}
+	return font, nil
+}
+
+// fontImageFromImage converts an image to the font image representation, where only the alpha channel represents the runes. Images without transparency are interpreted as coverage in the color channels.
+func fontImageFromImage(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	opaque := true
+	for i := 3; i < len(dst.Pix); i += 4 {
+		if dst.Pix[i] < 255 {
+			opaque = false
+			break
+		}
+	}
+
+	// see generateBitmapFont for the reason of white color information
+	for y := 0; y < dst.Bounds().Dy(); y++ {
+		for x := 0; x < dst.Bounds().Dx(); x++ {
+			c := dst.RGBAAt(x, y)
+			a := c.A
+			if opaque {
+				a = c.R
+				if c.G > a {
+					a = c.G
+				}
+				if c.B > a {
+					a = c.B
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: a})
+		}
+	}
+	return dst
+}
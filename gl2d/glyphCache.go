@@ -0,0 +1,363 @@
+package gl2d
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// DefaultGlyphPageSize denotes the default maximum width and height of a glyph texture page in pixels.
+	DefaultGlyphPageSize = 1024
+	// DefaultGlyphMaxPages denotes the default number of glyph texture pages per font.
+	DefaultGlyphMaxPages = 4
+
+	initialGlyphPageSize = 256
+	// glyphPadding keeps transparent pixels between glyphs to prevent bleeding on interpolation
+	glyphPadding = 1
+	// sdfMinRasterSize denotes the minimum font size in pixels that is used to compute distance fields of TrueType and OpenType fonts
+	sdfMinRasterSize = 48
+)
+
+// glyph denotes the image of a rune and its location relative to the pen position at the top of a line.
+type glyph struct {
+	tex *glutil.Texture
+	// src denotes the glyph region inside the texture in pixels
+	src     Quad
+	offset  mgl32.Vec2
+	size    mgl32.Vec2
+	advance float32
+	// sdfSpread denotes the distance in texture pixels covered by the distance field. Zero for coverage images
+	sdfSpread float32
+}
+
+// uv returns the normalized texture coordinates of the glyph image.
+func (g glyph) uv() runeRect {
+	w, h := float32(g.tex.Width), float32(g.tex.Height)
+	return runeRect{
+		topLeft:     mgl32.Vec2{g.src.Left / w, g.src.Top / h},
+		bottomRight: mgl32.Vec2{g.src.Right / w, g.src.Bottom / h},
+	}
+}
+
+// glyphCache rasterizes runes of a font face on first use and keeps them in texture pages that are divided into equally sized cells.
+type glyphCache struct {
+	face        font.Face
+	closeFace   bool
+	ascent      float32
+	cellSize    int
+	maxPageSize int
+	maxPages    int
+	// unitScale converts pixels of the face to units of the font
+	unitScale float32
+	sdfSpread float32
+
+	pages    []*glyphPage
+	glyphs   map[rune]*cachedGlyph
+	advances map[rune]float32
+	// useCounter increases with every glyph lookup and determines the least recently used glyphs
+	useCounter uint64
+	// pinnedFrom protects all glyphs used since this counter value from eviction
+	pinnedFrom uint64
+}
+
+type glyphPage struct {
+	tex   *glutil.Texture
+	image *image.RGBA
+	size  int
+	cells []glyphCell
+	free  []int
+}
+
+type glyphCell struct {
+	x, y  int
+	glyph *cachedGlyph
+}
+
+type cachedGlyph struct {
+	r        rune
+	glyph    glyph
+	page     *glyphPage
+	cell     int
+	lastUsed uint64
+}
+
+func newGlyphCache(face font.Face, closeFace bool, pageSize, maxPages int) *glyphCache {
+	m := face.Metrics()
+	// most glyphs fit into the line height, the remainder covers accents and descenders exceeding the metrics
+	cellSize := int(ceil32(1.25*int26ToFloat32(m.Height))) + 2*glyphPadding
+	if pageSize <= 0 {
+		pageSize = DefaultGlyphPageSize
+	}
+	if maxPages <= 0 {
+		maxPages = DefaultGlyphMaxPages
+	}
+	return &glyphCache{
+		face:        face,
+		closeFace:   closeFace,
+		ascent:      int26ToFloat32(m.Ascent),
+		cellSize:    cellSize,
+		maxPageSize: maxInt(pageSize, cellSize),
+		maxPages:    maxPages,
+		unitScale:   1,
+		glyphs:      make(map[rune]*cachedGlyph),
+		advances:    make(map[rune]float32),
+	}
+}
+
+// enableSDF stores all glyphs as signed distance fields covering spread pixels of the face. unitScale denotes the font size in units per pixel of the face. Must be called before the first glyph is rasterized.
+func (c *glyphCache) enableSDF(spread, unitScale float32) {
+	c.sdfSpread = spread
+	c.unitScale = unitScale
+	c.cellSize += 2 * int(sdfPadding(spread))
+	c.maxPageSize = maxInt(c.maxPageSize, c.cellSize)
+}
+
+func (c *glyphCache) destroy() {
+	for _, p := range c.pages {
+		p.tex.Destroy()
+	}
+	c.pages = nil
+	c.glyphs = nil
+	if c.closeFace {
+		c.face.Close()
+	}
+}
+
+// pin protects all glyphs that are used from now on from eviction until the next call to pin. This ensures that all glyphs of a string remain valid until the string is rendered.
+func (c *glyphCache) pin() {
+	c.pinnedFrom = c.useCounter + 1
+}
+
+// advance returns the horizontal advance of a rune without rasterizing it.
+func (c *glyphCache) advance(r rune) (float32, bool) {
+	if a, ok := c.advances[r]; ok {
+		return a, true
+	}
+	a, ok := c.face.GlyphAdvance(r)
+	if !ok {
+		return 0, false
+	}
+	c.advances[r] = c.unitScale * int26ToFloat32(a)
+	return c.advances[r], true
+}
+
+// bounds returns the ink box of a rune relative to the pen position on the baseline without rasterizing it.
+func (c *glyphCache) bounds(r rune) (Quad, bool) {
+	return faceGlyphBounds(c.face, r, c.unitScale)
+}
+
+// faceGlyphBounds returns the ink box of a rune of a font face relative to the pen position on the baseline scaled by unitScale.
+func faceGlyphBounds(face font.Face, r rune, unitScale float32) (Quad, bool) {
+	bounds, _, ok := face.GlyphBounds(r)
+	if !ok {
+		return Quad{}, false
+	}
+	return Quad{
+		Left:   unitScale * int26ToFloat32(bounds.Min.X),
+		Top:    unitScale * int26ToFloat32(bounds.Min.Y),
+		Right:  unitScale * int26ToFloat32(bounds.Max.X),
+		Bottom: unitScale * int26ToFloat32(bounds.Max.Y),
+	}, true
+}
+
+func (c *glyphCache) kern(r1, r2 rune) float32 {
+	return c.unitScale * int26ToFloat32(c.face.Kern(r1, r2))
+}
+
+// glyph returns the image of a rune and rasterizes it if necessary.
+func (c *glyphCache) glyph(r rune) (glyph, bool) {
+	c.useCounter++
+	if g, ok := c.glyphs[r]; ok {
+		g.lastUsed = c.useCounter
+		return g.glyph, true
+	}
+
+	bounds, advance, ok := c.face.GlyphBounds(r)
+	if !ok {
+		return glyph{}, false
+	}
+	g := &cachedGlyph{r: r, cell: -1, lastUsed: c.useCounter}
+	g.glyph.advance = c.unitScale * int26ToFloat32(advance)
+	g.glyph.sdfSpread = c.sdfSpread
+
+	minX, minY := bounds.Min.X.Floor(), bounds.Min.Y.Floor()
+	maxX, maxY := bounds.Max.X.Ceil(), bounds.Max.Y.Ceil()
+	if c.sdfSpread > 0 && maxX > minX && maxY > minY {
+		// the distance field extends beyond the glyph outline
+		padding := int(sdfPadding(c.sdfSpread))
+		minX, minY = minX-padding, minY-padding
+		maxX, maxY = maxX+padding, maxY+padding
+	}
+	width := minInt(maxX-minX, c.cellSize-2*glyphPadding)
+	height := minInt(maxY-minY, c.cellSize-2*glyphPadding)
+	if width > 0 && height > 0 {
+		page, cell, ok := c.allocateCell()
+		if !ok {
+			logrus.Debugf("no space left in glyph cache for rune '%v'", r)
+			return glyph{}, false
+		}
+		g.page, g.cell = page, cell
+		page.cells[cell].glyph = g
+
+		x, y := page.cells[cell].x+glyphPadding, page.cells[cell].y+glyphPadding
+		c.rasterize(page, r, image.Rect(x, y, x+width, y+height), fixed.P(x-minX, y-minY))
+		g.glyph.tex = page.tex
+		g.glyph.src = Quad{Left: float32(x), Right: float32(x + width), Top: float32(y), Bottom: float32(y + height)}
+		g.glyph.offset = mgl32.Vec2{float32(minX), c.ascent + float32(minY)}.Mul(c.unitScale)
+		g.glyph.size = mgl32.Vec2{float32(width), float32(height)}.Mul(c.unitScale)
+	}
+
+	c.glyphs[r] = g
+	return g.glyph, true
+}
+
+// rasterize renders a rune with its origin at dot into the given region of a page and uploads the region.
+func (c *glyphCache) rasterize(page *glyphPage, r rune, region image.Rectangle, dot fixed.Point26_6) {
+	dst := page.image.SubImage(region).(*image.RGBA)
+	draw.Draw(dst, region, image.Transparent, image.Point{}, draw.Src)
+	d := &font.Drawer{
+		Dst:  dst,
+		Src:  image.NewUniform(color.RGBA{255, 255, 255, 255}),
+		Face: c.face,
+		Dot:  dot,
+	}
+	d.DrawString(string(r))
+
+	if c.sdfSpread > 0 {
+		applyDistanceField(page.image, region, c.sdfSpread)
+	}
+
+	// only the alpha channel represents the rune, see generateBitmapFont
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			i := page.image.PixOffset(x, y)
+			if page.image.Pix[i+3] > 0 {
+				page.image.Pix[i+0] = 255
+				page.image.Pix[i+1] = 255
+				page.image.Pix[i+2] = 255
+			}
+		}
+	}
+
+	if err := page.tex.UpdateRegion(page.image, region); err != nil {
+		logrus.Errorf("update glyph texture: %s", err.Error())
+	}
+}
+
+// allocateCell returns a free cell. Pages are grown or added when necessary and the least recently used glyph is evicted when all pages are full.
+func (c *glyphCache) allocateCell() (*glyphPage, int, bool) {
+	for _, p := range c.pages {
+		if len(p.free) > 0 {
+			return p, p.popFree(), true
+		}
+	}
+
+	// pending primitives might still refer to the current page textures
+	if activeBackend != nil {
+		activeBackend.flush()
+	}
+
+	for _, p := range c.pages {
+		if p.size < c.maxPageSize {
+			if err := c.growPage(p, minInt(2*p.size, c.maxPageSize)); err != nil {
+				logrus.Errorf("grow glyph page: %s", err.Error())
+				break
+			}
+			if len(p.free) > 0 {
+				return p, p.popFree(), true
+			}
+		}
+	}
+
+	if len(c.pages) < c.maxPages {
+		size := minInt(maxInt(initialGlyphPageSize, 2*c.cellSize), c.maxPageSize)
+		if len(c.pages) > 0 {
+			// further pages are only required for large alphabets
+			size = c.maxPageSize
+		}
+		p, err := c.newPage(size)
+		if err != nil {
+			logrus.Errorf("create glyph page: %s", err.Error())
+		} else {
+			c.pages = append(c.pages, p)
+			return p, p.popFree(), true
+		}
+	}
+
+	var lru *cachedGlyph
+	for _, p := range c.pages {
+		for _, cell := range p.cells {
+			if cell.glyph != nil && cell.glyph.lastUsed < c.pinnedFrom && (lru == nil || cell.glyph.lastUsed < lru.lastUsed) {
+				lru = cell.glyph
+			}
+		}
+	}
+	if lru == nil {
+		return nil, 0, false
+	}
+	delete(c.glyphs, lru.r)
+	lru.page.cells[lru.cell].glyph = nil
+	return lru.page, lru.cell, true
+}
+
+func (c *glyphCache) newPage(size int) (*glyphPage, error) {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	tex, err := activeBackend.newTexture(img, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+	if err != nil {
+		return nil, err
+	}
+	p := &glyphPage{tex: tex, image: img, size: size}
+	p.addCells(c.cellSize, 0)
+	return p, nil
+}
+
+// growPage enlarges the page while all existing glyphs keep their pixel location.
+func (c *glyphCache) growPage(p *glyphPage, size int) error {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, p.image.Bounds(), p.image, image.Point{}, draw.Src)
+	tex, err := activeBackend.newTexture(img, p.tex.Params)
+	if err != nil {
+		return err
+	}
+	// glyphs refer to the texture object, so it is replaced in place
+	p.tex.Destroy()
+	*p.tex = *tex
+	oldSize := p.size
+	p.image = img
+	p.size = size
+	p.addCells(c.cellSize, oldSize)
+	return nil
+}
+
+// addCells adds all cells of the page that are not located inside the top-left square of the given size.
+func (p *glyphPage) addCells(cellSize, oldSize int) {
+	oldCount := oldSize / cellSize
+	count := p.size / cellSize
+	for row := 0; row < count; row++ {
+		for col := 0; col < count; col++ {
+			if row < oldCount && col < oldCount {
+				continue
+			}
+			p.free = append(p.free, len(p.cells))
+			p.cells = append(p.cells, glyphCell{x: col * cellSize, y: row * cellSize})
+		}
+	}
+	// cells are used in reading order, so the free list is reversed
+	for i, j := 0, len(p.free)-1; i < j; i, j = i+1, j-1 {
+		p.free[i], p.free[j] = p.free[j], p.free[i]
+	}
+}
+
+func (p *glyphPage) popFree() int {
+	cell := p.free[len(p.free)-1]
+	p.free = p.free[:len(p.free)-1]
+	return cell
+}
@@ -1,28 +1,14 @@
 package gl2d
 
 import (
-	"github.com/sbreitf1/go-gl-lib/glutil"
-
-	"github.com/go-gl/gl/v2.1/gl"
-)
+	"image"
 
-var (
-	drawImageProg uint32
+	"github.com/sbreitf1/go-gl-lib/glutil"
 )
 
-func initImages() error {
-	var err error
-	if drawImageProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   drawImageVertexShader,
-		Fragment: drawImageFragmentShader,
-	}); err != nil {
-		return err
-	}
-	return nil
-}
-
-func terminateImages() {
-	gl.DeleteProgram(drawImageProg)
+// NewTexture creates a texture that can be rendered by the active backend. The texture needs to be released using Destroy.
+func NewTexture(rgba *image.RGBA, params glutil.TextureParameters) (*glutil.Texture, error) {
+	return activeBackend.newTexture(rgba, params)
 }
 
 // DrawImage draws the full texture to the given quad and stretches the image.
@@ -32,15 +18,7 @@ func DrawImage(tex *glutil.Texture, dst Quad) {
 
 // DrawColorizedImage draws the full texture to the given quad and stretches the image. Also allows to colorize the image.
 func DrawColorizedImage(tex *glutil.Texture, dst Quad, color Color) {
-	gl.UseProgram(drawImageProg)
-	gl.UniformMatrix4fv(gl.GetUniformLocation(drawImageProg, gl.Str("projectionMatrix\x00")), 1, false, &projectionMatrix[0])
-	gl.Uniform4fv(gl.GetUniformLocation(drawImageProg, gl.Str("color\x00")), 1, &color[0])
-
-	gl.BindTexture(gl.TEXTURE_2D, tex.Tex)
-
-	renderTexturedQuad(dst, [2]float32{0, 0}, [2]float32{1, 1})
-
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	DrawColorizedImageSrc(tex, dst, Quad{Left: 0, Right: 1, Top: 0, Bottom: 1}, color)
 }
 
 // DrawImageSrc draws a sub-rectangle of texture to the given quad and stretches the image.
@@ -50,15 +28,14 @@ func DrawImageSrc(tex *glutil.Texture, dst, srcUV Quad) {
 
 // DrawColorizedImageSrc draws a sub-rectangle of texture to the given quad and stretches the image. Also allows to colorize the image.
 func DrawColorizedImageSrc(tex *glutil.Texture, dst, srcUV Quad, color Color) {
-	gl.UseProgram(drawImageProg)
-	gl.UniformMatrix4fv(gl.GetUniformLocation(drawImageProg, gl.Str("projectionMatrix\x00")), 1, false, &projectionMatrix[0])
-	gl.Uniform4fv(gl.GetUniformLocation(drawImageProg, gl.Str("color\x00")), 1, &color[0])
-
-	gl.BindTexture(gl.TEXTURE_2D, tex.Tex)
-
-	renderTexturedQuad(dst, [2]float32{srcUV.Left, srcUV.Top}, [2]float32{srcUV.Right, srcUV.Bottom})
-
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	renderShape(&shape{
+		kind:          shapeImage,
+		quad:          dst,
+		color:         color,
+		tex:           tex,
+		uvTopLeft:     [2]float32{srcUV.Left, srcUV.Top},
+		uvBottomRight: [2]float32{srcUV.Right, srcUV.Bottom},
+	})
 }
 
 // DrawAnimation draws the currently visible from to the given quad and stretches the image.
@@ -69,6 +46,15 @@ func DrawAnimation(anim *glutil.Animation, dst Quad) {
 // DrawColorizedAnimation draws the currently visible from to the given quad and stretches the image. Also allows to colorize the image.
 func DrawColorizedAnimation(anim *glutil.Animation, dst Quad, color Color) {
 	srcTopLeftUV, srcBottomRightUV := anim.GetSrcUV()
+	// trimmed frames only cover a part of the destination
+	dstTopLeft, dstBottomRight := anim.GetDstRect()
+	w, h := dst.Right-dst.Left, dst.Bottom-dst.Top
+	dst = Quad{
+		Left:   dst.Left + dstTopLeft[0]*w,
+		Right:  dst.Left + dstBottomRight[0]*w,
+		Top:    dst.Top + dstTopLeft[1]*h,
+		Bottom: dst.Top + dstBottomRight[1]*h,
+	}
 	DrawColorizedImageSrc(anim.Image, dst, Quad{
 		Left:   srcTopLeftUV[0],
 		Right:  srcBottomRightUV[0],
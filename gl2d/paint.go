@@ -0,0 +1,290 @@
+package gl2d
+
+import (
+	"image"
+	"math"
+	"sort"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// gradientRampSize denotes the number of texels used to store the colors of a gradient.
+	gradientRampSize = 256
+)
+
+var (
+	// gradientRamps maps the hash of the gradient stops to all ramps with that hash.
+	gradientRamps = make(map[uint64][]gradientRampEntry)
+)
+
+type gradientRampEntry struct {
+	stops []GradientStop
+	tex   *glutil.Texture
+}
+
+// Paint describes the color of each pixel covered by a shape. Color, LinearGradient, RadialGradient, ConicGradient and Pattern implement Paint.
+type Paint interface {
+	applyTo(s *shape)
+}
+
+type paintKind int
+
+const (
+	paintSolid paintKind = iota
+	paintLinearGradient
+	paintRadialGradient
+	paintConicGradient
+	paintPattern
+)
+
+// shapePaint contains all parameters of a non-solid paint. The paint is evaluated at transform*localPos.
+type shapePaint struct {
+	kind      paintKind
+	transform mgl32.Mat3
+	params    [4]float32
+	tex       *glutil.Texture
+}
+
+// renderPaintedShape applies the paint to the shape before rendering it.
+func renderPaintedShape(paint Paint, s *shape) {
+	paint.applyTo(s)
+	renderShape(s)
+}
+
+func (c Color) applyTo(s *shape) {
+	s.color = c
+	s.paint = shapePaint{}
+}
+
+// GradientStop denotes the color of a gradient at a certain offset between 0 and 1.
+type GradientStop struct {
+	Offset float32
+	Color  Color
+}
+
+// LinearGradient changes colors along the line from Start to End. The color is constant perpendicular to the line and beyond both ends.
+type LinearGradient struct {
+	Start, End mgl32.Vec2
+	Stops      []GradientStop
+}
+
+func (g LinearGradient) applyTo(s *shape) {
+	applyGradient(s, paintLinearGradient, [4]float32{g.Start[0], g.Start[1], g.End[0], g.End[1]}, g.Stops)
+}
+
+// RadialGradient changes colors from Center to the circle with given Radius.
+type RadialGradient struct {
+	Center mgl32.Vec2
+	Radius float32
+	Stops  []GradientStop
+}
+
+func (g RadialGradient) applyTo(s *shape) {
+	applyGradient(s, paintRadialGradient, [4]float32{g.Center[0], g.Center[1], g.Radius}, g.Stops)
+}
+
+// ConicGradient changes colors clockwise around Center, beginning at StartAngle. Angles are given in radians, where 0 points to the right.
+type ConicGradient struct {
+	Center     mgl32.Vec2
+	StartAngle float32
+	Stops      []GradientStop
+}
+
+func (g ConicGradient) applyTo(s *shape) {
+	applyGradient(s, paintConicGradient, [4]float32{g.Center[0], g.Center[1], g.StartAngle}, g.Stops)
+}
+
+// Pattern fills shapes with a tiled texture. The pattern transformation is applied in order scale, rotation and offset. Without transformation, the top-left texel is located at the local origin and each texel covers one local unit.
+type Pattern struct {
+	Texture *glutil.Texture
+	// Offset moves the pattern origin.
+	Offset mgl32.Vec2
+	// Scale denotes the size of a texel in local units. A zero value is treated as 1.
+	Scale mgl32.Vec2
+	// Rotation rotates the pattern clockwise. The angle is given in radians.
+	Rotation float32
+	// Wrap denotes how the texture is continued outside of a single tile. Uses TextureWrapRepeat if not set.
+	Wrap glutil.TextureWrap
+}
+
+func (p Pattern) applyTo(s *shape) {
+	if p.Texture == nil || p.Texture.Width == 0 || p.Texture.Height == 0 {
+		Color{}.applyTo(s)
+		return
+	}
+
+	scale := p.Scale
+	if scale[0] == 0 {
+		scale[0] = 1
+	}
+	if scale[1] == 0 {
+		scale[1] = 1
+	}
+	patternTransform := mgl32.Translate2D(p.Offset[0], p.Offset[1]).Mul3(mgl32.HomogRotate2D(p.Rotation)).Mul3(mgl32.Scale2D(scale[0], scale[1]))
+
+	var wrap float32
+	switch p.Wrap {
+	case glutil.TextureWrapMirroredRepeat:
+		wrap = 1
+	case glutil.TextureWrapClampToEdge:
+		wrap = 2
+	case glutil.TextureWrapClampToBorder:
+		wrap = 3
+	}
+
+	s.color = White
+	s.paint = shapePaint{
+		kind: paintPattern,
+		// maps local coordinates to normalized texture coordinates
+		transform: mgl32.Scale2D(1/float32(p.Texture.Width), 1/float32(p.Texture.Height)).Mul3(patternTransform.Inv()),
+		params:    [4]float32{wrap, float32(p.Texture.Width), float32(p.Texture.Height)},
+		tex:       p.Texture,
+	}
+}
+
+func applyGradient(s *shape, kind paintKind, params [4]float32, stops []GradientStop) {
+	tex, err := gradientRamp(stops)
+	if err != nil {
+		// fall back to the first color when the texture is not available
+		if len(stops) > 0 {
+			stops[0].Color.applyTo(s)
+		}
+		return
+	}
+	s.color = White
+	s.paint = shapePaint{
+		kind:      kind,
+		transform: mgl32.Ident3(),
+		params:    params,
+		tex:       tex,
+	}
+}
+
+// gradientRamp returns a texture containing the interpolated colors of all stops. Textures are shared for identical stops until the end of the frame.
+func gradientRamp(stops []GradientStop) (*glutil.Texture, error) {
+	hash := hashGradientStops(stops)
+	for _, entry := range gradientRamps[hash] {
+		if equalGradientStops(entry.stops, stops) {
+			return entry.tex, nil
+		}
+	}
+
+	sorted := append([]GradientStop{}, stops...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Offset < sorted[j].Offset })
+
+	img := image.NewRGBA(image.Rect(0, 0, gradientRampSize, 1))
+	for x := 0; x < gradientRampSize; x++ {
+		c := gradientColor(sorted, float32(x)/(gradientRampSize-1))
+		for i := 0; i < 4; i++ {
+			img.Pix[4*x+i] = colorComponentToUint8(c[i])
+		}
+	}
+
+	tex, err := newFrameTexture(img, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+	if err != nil {
+		return nil, err
+	}
+	gradientRamps[hash] = append(gradientRamps[hash], gradientRampEntry{stops: append([]GradientStop{}, stops...), tex: tex})
+	return tex, nil
+}
+
+// hashGradientStops computes the FNV-1a hash of the offsets and colors of all stops without allocations.
+func hashGradientStops(stops []GradientStop) uint64 {
+	hash := uint64(14695981039346656037)
+	add := func(v float32) {
+		hash ^= uint64(math.Float32bits(v))
+		hash *= 1099511628211
+	}
+	for _, stop := range stops {
+		add(stop.Offset)
+		for _, c := range stop.Color {
+			add(c)
+		}
+	}
+	return hash
+}
+
+func equalGradientStops(a, b []GradientStop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// gradientColor interpolates the color at offset t from stops sorted by offset.
+func gradientColor(stops []GradientStop, t float32) Color {
+	if len(stops) == 0 {
+		return Color{}
+	}
+	if t <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for i := 1; i < len(stops); i++ {
+		if t <= stops[i].Offset {
+			prev, next := stops[i-1], stops[i]
+			f := (t - prev.Offset) / (next.Offset - prev.Offset)
+			var c Color
+			for j := range c {
+				c[j] = prev.Color[j] + f*(next.Color[j]-prev.Color[j])
+			}
+			return c
+		}
+	}
+	return stops[len(stops)-1].Color
+}
+
+func releaseGradientRamps() {
+	for key := range gradientRamps {
+		delete(gradientRamps, key)
+	}
+}
+
+// patternUV mirrors the wrapping of patterns in the shaders and returns the texture coordinates to sample.
+func (p *shapePaint) patternUV(paintPos mgl32.Vec2) (mgl32.Vec2, bool) {
+	uv := paintPos
+	for i := range uv {
+		switch p.params[0] {
+		case 0:
+			uv[i] -= floor32(uv[i])
+		case 1:
+			m := uv[i] - 2*floor32(uv[i]/2)
+			uv[i] = 1 - abs32(m-1)
+		case 2:
+			half := 0.5 / p.params[1+i]
+			uv[i] = clamp32(uv[i], half, 1-half)
+		default:
+			if uv[i] < 0 || uv[i] > 1 {
+				return uv, false
+			}
+		}
+	}
+	return uv, true
+}
+
+// gradientOffset mirrors the paint evaluation of the shaders and returns the normalized gradient position.
+func (p *shapePaint) gradientOffset(paintPos mgl32.Vec2) float32 {
+	var t float32
+	switch p.kind {
+	case paintLinearGradient:
+		start := mgl32.Vec2{p.params[0], p.params[1]}
+		d := mgl32.Vec2{p.params[2], p.params[3]}.Sub(start)
+		t = paintPos.Sub(start).Dot(d) / d.Dot(d)
+
+	case paintRadialGradient:
+		t = paintPos.Sub(mgl32.Vec2{p.params[0], p.params[1]}).Len() / p.params[2]
+
+	case paintConicGradient:
+		v := paintPos.Sub(mgl32.Vec2{p.params[0], p.params[1]})
+		t = (float32(math.Atan2(float64(v[1]), float64(v[0]))) - p.params[2]) / (2 * math.Pi)
+		t -= floor32(t)
+	}
+	return clamp32(t, 0, 1)
+}
@@ -0,0 +1,427 @@
+package gl2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// pathTolerance denotes the maximum distance in screen pixels between curves and their flattened representation.
+	pathTolerance = 0.2
+)
+
+type pathCommandKind int
+
+const (
+	pathMoveTo pathCommandKind = iota
+	pathLineTo
+	pathQuadTo
+	pathCubicTo
+	pathArc
+	pathClose
+)
+
+type pathCommand struct {
+	kind   pathCommandKind
+	points [3]mgl32.Vec2
+	// arc parameters
+	radius               float32
+	startAngle, endAngle float32
+}
+
+// Path describes an outline consisting of multiple sub-paths built from lines and curves. Use FillPath and StrokePath to render a path.
+type Path struct {
+	commands []pathCommand
+	current  mgl32.Vec2
+	start    mgl32.Vec2
+	hasPoint bool
+}
+
+// NewPath returns a new empty path.
+func NewPath() *Path {
+	return &Path{}
+}
+
+// Reset removes all sub-paths and returns to the state of a new path.
+func (p *Path) Reset() {
+	p.commands = p.commands[:0]
+	p.current = mgl32.Vec2{}
+	p.start = mgl32.Vec2{}
+	p.hasPoint = false
+}
+
+// IsEmpty returns true when the path does not contain any segments.
+func (p *Path) IsEmpty() bool {
+	return len(p.commands) == 0
+}
+
+// CurrentPoint returns the end point of the last segment.
+func (p *Path) CurrentPoint() mgl32.Vec2 {
+	return p.current
+}
+
+// MoveTo begins a new sub-path at the given point.
+func (p *Path) MoveTo(pt mgl32.Vec2) {
+	p.commands = append(p.commands, pathCommand{kind: pathMoveTo, points: [3]mgl32.Vec2{pt}})
+	p.current = pt
+	p.start = pt
+	p.hasPoint = true
+}
+
+// LineTo adds a straight line from the current point to pt.
+func (p *Path) LineTo(pt mgl32.Vec2) {
+	if !p.hasPoint {
+		p.MoveTo(pt)
+		return
+	}
+	p.commands = append(p.commands, pathCommand{kind: pathLineTo, points: [3]mgl32.Vec2{pt}})
+	p.current = pt
+}
+
+// QuadTo adds a quadratic Bezier curve from the current point to pt.
+func (p *Path) QuadTo(ctrl, pt mgl32.Vec2) {
+	if !p.hasPoint {
+		p.MoveTo(ctrl)
+	}
+	p.commands = append(p.commands, pathCommand{kind: pathQuadTo, points: [3]mgl32.Vec2{ctrl, pt}})
+	p.current = pt
+}
+
+// CubicTo adds a cubic Bezier curve from the current point to pt.
+func (p *Path) CubicTo(ctrl1, ctrl2, pt mgl32.Vec2) {
+	if !p.hasPoint {
+		p.MoveTo(ctrl1)
+	}
+	p.commands = append(p.commands, pathCommand{kind: pathCubicTo, points: [3]mgl32.Vec2{ctrl1, ctrl2, pt}})
+	p.current = pt
+}
+
+// Arc adds a circular arc around center. The angles are given in radians, where 0 points to the right and positive angles rotate clockwise on screen. The arc runs clockwise if endAngle > startAngle and counter-clockwise otherwise. A line connects the current point with the start of the arc.
+func (p *Path) Arc(center mgl32.Vec2, radius, startAngle, endAngle float32) {
+	start := pointOnCircle(center, radius, startAngle)
+	if !p.hasPoint {
+		p.MoveTo(start)
+	} else {
+		p.LineTo(start)
+	}
+	p.commands = append(p.commands, pathCommand{kind: pathArc, points: [3]mgl32.Vec2{center}, radius: radius, startAngle: startAngle, endAngle: endAngle})
+	p.current = pointOnCircle(center, radius, endAngle)
+}
+
+// ArcTo adds a circular arc with given radius that is tangent to the line from the current point to p1 and the line from p1 to p2. A line connects the current point with the start of the arc.
+func (p *Path) ArcTo(p1, p2 mgl32.Vec2, radius float32) {
+	if !p.hasPoint {
+		p.MoveTo(p1)
+	}
+	p0 := p.current
+
+	d0 := p0.Sub(p1)
+	d2 := p2.Sub(p1)
+	len0 := d0.Len()
+	len2 := d2.Len()
+	if radius <= 0 || len0 == 0 || len2 == 0 {
+		p.LineTo(p1)
+		return
+	}
+	d0 = d0.Mul(1 / len0)
+	d2 = d2.Mul(1 / len2)
+	cos := clamp32(d0.Dot(d2), -1, 1)
+	if abs32(cos) > 0.9999 {
+		// collinear points
+		p.LineTo(p1)
+		return
+	}
+
+	// distance from p1 to the tangent points
+	halfAngle := float32(math.Acos(float64(cos))) / 2
+	tangentDist := radius / float32(math.Tan(float64(halfAngle)))
+	t0 := p1.Add(d0.Mul(tangentDist))
+	t2 := p1.Add(d2.Mul(tangentDist))
+	bisector := d0.Add(d2).Normalize()
+	center := p1.Add(bisector.Mul(radius / float32(math.Sin(float64(halfAngle)))))
+
+	startAngle := angleOf(t0.Sub(center))
+	endAngle := angleOf(t2.Sub(center))
+	// choose the shorter direction around the circle
+	sweep := normalizeAngle(endAngle - startAngle)
+	p.Arc(center, radius, startAngle, startAngle+sweep)
+}
+
+// Close connects the current point with the start of the current sub-path and closes it.
+func (p *Path) Close() {
+	if !p.hasPoint {
+		return
+	}
+	p.commands = append(p.commands, pathCommand{kind: pathClose})
+	p.current = p.start
+}
+
+// Rectangle adds a closed rectangular sub-path.
+func (p *Path) Rectangle(topLeft, size mgl32.Vec2) {
+	p.MoveTo(topLeft)
+	p.LineTo(topLeft.Add(mgl32.Vec2{size[0], 0}))
+	p.LineTo(topLeft.Add(size))
+	p.LineTo(topLeft.Add(mgl32.Vec2{0, size[1]}))
+	p.Close()
+}
+
+// Circle adds a closed circular sub-path.
+func (p *Path) Circle(center mgl32.Vec2, radius float32) {
+	p.MoveTo(pointOnCircle(center, radius, 0))
+	p.Arc(center, radius, 0, 2*math.Pi)
+	p.Close()
+}
+
+// Polygon adds a closed sub-path connecting all given points.
+func (p *Path) Polygon(points []mgl32.Vec2) {
+	if len(points) == 0 {
+		return
+	}
+	p.MoveTo(points[0])
+	for _, pt := range points[1:] {
+		p.LineTo(pt)
+	}
+	p.Close()
+}
+
+// polyline denotes a flattened sub-path.
+type polyline struct {
+	points []mgl32.Vec2
+	closed bool
+}
+
+// flatten converts all curves to line segments. The tolerance is given in the same units as the path coordinates.
+func (p *Path) flatten(tolerance float32) []polyline {
+	var lines []polyline
+	var current *polyline
+	var pos mgl32.Vec2
+
+	ensureCurrent := func() {
+		if current == nil {
+			lines = append(lines, polyline{points: []mgl32.Vec2{pos}})
+			current = &lines[len(lines)-1]
+		}
+	}
+	addPoint := func(pt mgl32.Vec2) {
+		ensureCurrent()
+		last := current.points[len(current.points)-1]
+		if last != pt {
+			current.points = append(current.points, pt)
+		}
+		pos = pt
+	}
+
+	for _, cmd := range p.commands {
+		switch cmd.kind {
+		case pathMoveTo:
+			current = nil
+			pos = cmd.points[0]
+			ensureCurrent()
+
+		case pathLineTo:
+			addPoint(cmd.points[0])
+
+		case pathQuadTo:
+			p0, p1, p2 := pos, cmd.points[0], cmd.points[1]
+			dd := p0.Sub(p1.Mul(2)).Add(p2).Len()
+			n := int(ceil32(float32(math.Sqrt(float64(dd / (4 * tolerance))))))
+			for i := 1; i <= n; i++ {
+				t := float32(i) / float32(n)
+				mt := 1 - t
+				addPoint(p0.Mul(mt * mt).Add(p1.Mul(2 * mt * t)).Add(p2.Mul(t * t)))
+			}
+			addPoint(p2)
+
+		case pathCubicTo:
+			p0, p1, p2, p3 := pos, cmd.points[0], cmd.points[1], cmd.points[2]
+			dd := max32(p0.Sub(p1.Mul(2)).Add(p2).Len(), p1.Sub(p2.Mul(2)).Add(p3).Len())
+			n := int(ceil32(float32(math.Sqrt(float64(6 * dd / (8 * tolerance))))))
+			for i := 1; i <= n; i++ {
+				t := float32(i) / float32(n)
+				mt := 1 - t
+				addPoint(p0.Mul(mt * mt * mt).Add(p1.Mul(3 * mt * mt * t)).Add(p2.Mul(3 * mt * t * t)).Add(p3.Mul(t * t * t)))
+			}
+			addPoint(p3)
+
+		case pathArc:
+			for _, pt := range flattenArc(cmd.points[0], cmd.radius, cmd.startAngle, cmd.endAngle, tolerance) {
+				addPoint(pt)
+			}
+
+		case pathClose:
+			if current != nil {
+				current.closed = true
+				pos = current.points[0]
+				current = nil
+			}
+		}
+	}
+
+	// remove duplicated end points of closed sub-paths
+	for i := range lines {
+		pts := lines[i].points
+		if lines[i].closed && len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+			lines[i].points = pts[:len(pts)-1]
+		}
+	}
+	return lines
+}
+
+// flattenArc returns points along an arc including start and end point.
+func flattenArc(center mgl32.Vec2, radius, startAngle, endAngle, tolerance float32) []mgl32.Vec2 {
+	sweep := endAngle - startAngle
+	n := 1
+	if radius > tolerance {
+		maxStep := 2 * math.Acos(float64(1-tolerance/radius))
+		n = int(math.Ceil(math.Abs(float64(sweep)) / maxStep))
+	}
+	if n < 1 {
+		n = 1
+	}
+	points := make([]mgl32.Vec2, 0, n+1)
+	for i := 0; i <= n; i++ {
+		points = append(points, pointOnCircle(center, radius, startAngle+sweep*float32(i)/float32(n)))
+	}
+	return points
+}
+
+func pointOnCircle(center mgl32.Vec2, radius, angle float32) mgl32.Vec2 {
+	return center.Add(mgl32.Vec2{radius * float32(math.Cos(float64(angle))), radius * float32(math.Sin(float64(angle)))})
+}
+
+func angleOf(v mgl32.Vec2) float32 {
+	return float32(math.Atan2(float64(v[1]), float64(v[0])))
+}
+
+// normalizeAngle maps an angle to the range [-Pi, Pi].
+func normalizeAngle(a float32) float32 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a < -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func clamp32(val, min, max float32) float32 {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
+
+func clamp64(val, min, max float64) float64 {
+	if val < min {
+		return min
+	}
+	if val > max {
+		return max
+	}
+	return val
+}
+
+// FillRule denotes how to determine the inside of self-intersecting or nested paths.
+type FillRule int
+
+const (
+	// FillRuleNonZero fills all areas with a non-zero winding number.
+	FillRuleNonZero FillRule = iota
+	// FillRuleEvenOdd fills all areas that are enclosed by an odd number of edges.
+	FillRuleEvenOdd
+)
+
+func (r FillRule) isInside(winding int) bool {
+	if r == FillRuleEvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// LineJoin denotes the shape at corners of stroked paths.
+type LineJoin int
+
+const (
+	// LineJoinMiter extends the outer edges until they meet. Falls back to LineJoinBevel if the miter limit is exceeded.
+	LineJoinMiter LineJoin = iota
+	// LineJoinRound rounds corners.
+	LineJoinRound
+	// LineJoinBevel cuts off corners.
+	LineJoinBevel
+)
+
+// LineCap denotes the shape at the ends of open stroked paths.
+type LineCap int
+
+const (
+	// LineCapButt ends strokes exactly at the end points.
+	LineCapButt LineCap = iota
+	// LineCapRound adds a half circle to the end points.
+	LineCapRound
+	// LineCapSquare extends strokes by half the line width.
+	LineCapSquare
+)
+
+// DefaultMiterLimit is used when StrokeStyle.MiterLimit is not set.
+const DefaultMiterLimit = 10
+
+// StrokeStyle describes the appearance of stroked paths.
+type StrokeStyle struct {
+	Width float32
+	Join  LineJoin
+	Cap   LineCap
+	// MiterLimit denotes the maximum ratio of miter length to line width for LineJoinMiter.
+	MiterLimit float32
+	// Dashes contains alternating lengths of visible and invisible parts. Strokes are solid if empty.
+	Dashes     []float32
+	DashOffset float32
+}
+
+// FillPath fills the area enclosed by all sub-paths. Open sub-paths are closed implicitly.
+func FillPath(path *Path, rule FillRule, color Color) {
+	FillPathPaint(path, rule, color)
+}
+
+// FillPathPaint fills the area enclosed by all sub-paths using the given paint.
+func FillPathPaint(path *Path, rule FillRule, paint Paint) {
+	scale := transformScale(transform)
+	if scale == 0 {
+		return
+	}
+
+	lines := path.flatten(pathTolerance / scale)
+	polygons := make([][]mgl32.Vec2, 0, len(lines))
+	for _, line := range lines {
+		if len(line.points) >= 3 {
+			polygons = append(polygons, line.points)
+		}
+	}
+	renderPolygons(transformPolygons(transform, polygons), rule, paint)
+}
+
+// StrokePath renders the outline of all sub-paths. Like DrawLine, the stroke is centered on pixel centers.
+func StrokePath(path *Path, style StrokeStyle, color Color) {
+	StrokePathPaint(path, style, color)
+}
+
+// StrokePathPaint renders the outline of all sub-paths using the given paint.
+func StrokePathPaint(path *Path, style StrokeStyle, paint Paint) {
+	scale := transformScale(transform)
+	if scale == 0 {
+		return
+	}
+
+	lines := path.flatten(pathTolerance / scale)
+	// respect pixel offset
+	for _, line := range lines {
+		for i := range line.points {
+			line.points[i] = line.points[i].Add(mgl32.Vec2{0.5, 0.5})
+		}
+	}
+	polygons := strokePolylines(lines, style, pathTolerance/scale)
+	renderPolygons(transformPolygons(transform, polygons), FillRuleNonZero, paint)
+}
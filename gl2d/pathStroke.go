@@ -0,0 +1,199 @@
+package gl2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// strokePolylines converts the outline of all polylines to polygons with positive orientation. The union of all polygons, e.g. rendered using FillRuleNonZero, represents the stroke.
+func strokePolylines(lines []polyline, style StrokeStyle, tolerance float32) [][]mgl32.Vec2 {
+	halfWidth := style.Width / 2
+	if halfWidth <= 0 {
+		return nil
+	}
+	if len(style.Dashes) > 0 {
+		lines = dashPolylines(lines, style.Dashes, style.DashOffset)
+	}
+	miterLimit := style.MiterLimit
+	if miterLimit <= 0 {
+		miterLimit = DefaultMiterLimit
+	}
+
+	var polygons [][]mgl32.Vec2
+	addPolygon := func(polygon []mgl32.Vec2) {
+		if polygonArea(polygon) < 0 {
+			for i, j := 0, len(polygon)-1; i < j; i, j = i+1, j-1 {
+				polygon[i], polygon[j] = polygon[j], polygon[i]
+			}
+		}
+		polygons = append(polygons, polygon)
+	}
+	addDisc := func(center mgl32.Vec2) {
+		points := flattenArc(center, halfWidth, 0, 2*math.Pi, tolerance)
+		addPolygon(points[:len(points)-1])
+	}
+	addCap := func(p, dir mgl32.Vec2) {
+		switch style.Cap {
+		case LineCapRound:
+			addDisc(p)
+		case LineCapSquare:
+			n := mgl32.Vec2{-dir[1], dir[0]}.Mul(halfWidth)
+			ext := dir.Mul(halfWidth)
+			addPolygon([]mgl32.Vec2{p.Add(n), p.Add(n).Add(ext), p.Sub(n).Add(ext), p.Sub(n)})
+		}
+	}
+
+	for _, line := range lines {
+		// zero-length segments have no direction
+		points := make([]mgl32.Vec2, 0, len(line.points)+1)
+		for _, p := range line.points {
+			if len(points) == 0 || points[len(points)-1] != p {
+				points = append(points, p)
+			}
+		}
+		if line.closed && len(points) > 1 {
+			points = append(points, points[0])
+		}
+
+		if len(points) == 1 {
+			// a single point is only visible with round or square caps
+			addCap(points[0], mgl32.Vec2{1, 0})
+			addCap(points[0], mgl32.Vec2{-1, 0})
+			continue
+		}
+
+		dirs := make([]mgl32.Vec2, len(points)-1)
+		for i := range dirs {
+			a, b := points[i], points[i+1]
+			dirs[i] = b.Sub(a).Normalize()
+			n := mgl32.Vec2{-dirs[i][1], dirs[i][0]}.Mul(halfWidth)
+			addPolygon([]mgl32.Vec2{a.Add(n), b.Add(n), b.Sub(n), a.Sub(n)})
+		}
+
+		// joins between consecutive segments
+		for i := 1; i < len(points); i++ {
+			var d0, d1 mgl32.Vec2
+			if i < len(dirs) {
+				d0, d1 = dirs[i-1], dirs[i]
+			} else if line.closed {
+				d0, d1 = dirs[i-1], dirs[0]
+			} else {
+				break
+			}
+			if join := joinPolygon(points[i], d0, d1, halfWidth, style.Join, miterLimit, tolerance); join != nil {
+				addPolygon(join)
+			}
+		}
+
+		if !line.closed {
+			addCap(points[0], dirs[0].Mul(-1))
+			addCap(points[len(points)-1], dirs[len(dirs)-1])
+		}
+	}
+	return polygons
+}
+
+// joinPolygon returns the polygon that fills the gap on the outer side of two segments meeting at p. Returns nil if no gap exists.
+func joinPolygon(p, d0, d1 mgl32.Vec2, halfWidth float32, join LineJoin, miterLimit, tolerance float32) []mgl32.Vec2 {
+	cross := d0[0]*d1[1] - d0[1]*d1[0]
+	if abs32(cross) < 1e-6 && d0.Dot(d1) > 0 {
+		// straight continuation, no join required
+		return nil
+	}
+
+	// the outer side of the join lies opposite to the turning direction
+	n0 := mgl32.Vec2{-d0[1], d0[0]}.Mul(halfWidth)
+	n1 := mgl32.Vec2{-d1[1], d1[0]}.Mul(halfWidth)
+	if cross > 0 {
+		n0, n1 = n0.Mul(-1), n1.Mul(-1)
+	}
+	o0, o1 := p.Add(n0), p.Add(n1)
+
+	if join == LineJoinRound {
+		startAngle := angleOf(n0)
+		sweep := normalizeAngle(angleOf(n1) - startAngle)
+		return append([]mgl32.Vec2{p}, flattenArc(p, halfWidth, startAngle, startAngle+sweep, tolerance)...)
+	}
+
+	if join == LineJoinMiter {
+		bisector := n0.Add(n1)
+		if l := bisector.Len(); l > 1e-6 {
+			// ratio of miter length to line width as defined by SVG
+			ratio := 2 * halfWidth / l
+			if ratio <= miterLimit {
+				miter := p.Add(bisector.Mul(2 * halfWidth * halfWidth / (l * l)))
+				return []mgl32.Vec2{p, o0, miter, o1}
+			}
+		}
+	}
+	return []mgl32.Vec2{p, o0, o1}
+}
+
+// dashPolylines splits polylines into open pieces according to the dash pattern. The pattern restarts for every sub-path.
+func dashPolylines(lines []polyline, dashes []float32, offset float32) []polyline {
+	if len(dashes)%2 == 1 {
+		dashes = append(append([]float32{}, dashes...), dashes...)
+	}
+	var total float32
+	for _, d := range dashes {
+		if d < 0 {
+			return lines
+		}
+		total += d
+	}
+	if total <= 0 {
+		return lines
+	}
+
+	var result []polyline
+	for _, line := range lines {
+		points := line.points
+		if line.closed && len(points) > 1 {
+			points = append(append([]mgl32.Vec2{}, points...), points[0])
+		}
+
+		// find the dash index and remaining length at the start of the sub-path
+		pos := float32(math.Mod(float64(offset), float64(total)))
+		if pos < 0 {
+			pos += total
+		}
+		dashIndex := 0
+		for pos > dashes[dashIndex] || (pos > 0 && pos == dashes[dashIndex]) {
+			pos -= dashes[dashIndex]
+			dashIndex = (dashIndex + 1) % len(dashes)
+		}
+		remaining := dashes[dashIndex] - pos
+
+		var current []mgl32.Vec2
+		if dashIndex%2 == 0 {
+			current = []mgl32.Vec2{points[0]}
+		}
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			segLength := b.Sub(a).Len()
+			var t float32
+			for segLength-t > remaining {
+				t += remaining
+				p := a.Add(b.Sub(a).Mul(t / segLength))
+				if dashIndex%2 == 0 {
+					current = append(current, p)
+					result = append(result, polyline{points: current})
+					current = nil
+				} else {
+					current = []mgl32.Vec2{p}
+				}
+				dashIndex = (dashIndex + 1) % len(dashes)
+				remaining = dashes[dashIndex]
+			}
+			remaining -= segLength - t
+			if dashIndex%2 == 0 {
+				current = append(current, b)
+			}
+		}
+		if len(current) > 0 {
+			result = append(result, polyline{points: current})
+		}
+	}
+	return result
+}
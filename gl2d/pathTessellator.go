@@ -0,0 +1,448 @@
+package gl2d
+
+import (
+	"math"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// minSlabHeight denotes the minimum distance between consecutive scanlines of the sweep to guarantee progress for nearly parallel edges.
+	minSlabHeight = 1e-7
+	// maxCellSegments denotes the number of boundary segments a path cell can evaluate on the GPU. Pixels touched by more segments are computed on the CPU.
+	maxCellSegments = 2
+)
+
+// tessEdge denotes a non-horizontal polygon edge running downwards from (x0, y0) to (x1, y1).
+type tessEdge struct {
+	x0, y0, x1, y1 float64
+	// slope denotes dx/dy
+	slope   float64
+	winding int
+}
+
+func (e *tessEdge) xAt(y float64) float64 {
+	return e.x0 + (y-e.y0)*e.slope
+}
+
+// pathBoundary denotes a part of an edge that separates the filled area from the outside. The area lies right of the edge for winding -1 and left of it for winding 1.
+type pathBoundary struct {
+	edge        int
+	top, bottom float64
+	winding     float64
+}
+
+// rowBoundary denotes the part of a boundary inside a single pixel row.
+type rowBoundary struct {
+	boundary int
+	// pixels left of firstCol are fully covered by the boundary, pixels from firstCol up to lastCol are partially covered
+	firstCol, lastCol int
+	// coverage denotes the signed area the boundary adds to fully covered pixels
+	coverage float64
+}
+
+// pathCell denotes a rectangular range of pixels whose coverage is computed from a constant base and up to maxCellSegments boundaries.
+type pathCell struct {
+	left, right, top, bottom int
+	base                     float64
+	boundaries               [maxCellSegments]int
+}
+
+// pathTessellator decomposes filled polygons into cells of pixels that are rendered as batched shapes. A sweep line determines the boundary of the filled area, so that each cell can compute the exact coverage of its pixels.
+type pathTessellator struct {
+	edges      []tessEdge
+	boundaries []pathBoundary
+	active     []int
+	order      []int
+
+	rowBoundaries []rowBoundary
+	cols          []int
+	partial       []int
+	cells         []pathCell
+	prevCells     map[pathCell]int
+	curCells      map[pathCell]int
+}
+
+// tessellate computes the boundary of the area inside the polygons according to the fill rule.
+func (t *pathTessellator) tessellate(polygons [][]mgl32.Vec2, rule FillRule) {
+	t.edges = t.edges[:0]
+	t.boundaries = t.boundaries[:0]
+	t.active = t.active[:0]
+
+	var ys []float64
+	for _, polygon := range polygons {
+		for i := range polygon {
+			a := polygon[i]
+			b := polygon[(i+1)%len(polygon)]
+			if a[1] == b[1] {
+				// horizontal edges do not affect the winding number
+				continue
+			}
+			e := tessEdge{float64(a[0]), float64(a[1]), float64(b[0]), float64(b[1]), 0, 1}
+			if e.y0 > e.y1 {
+				e = tessEdge{e.x1, e.y1, e.x0, e.y0, 0, -1}
+			}
+			e.slope = (e.x1 - e.x0) / (e.y1 - e.y0)
+			t.edges = append(t.edges, e)
+			ys = append(ys, e.y0, e.y1)
+		}
+	}
+	if len(t.edges) == 0 {
+		return
+	}
+	sort.Slice(t.edges, func(i, j int) bool { return t.edges[i].y0 < t.edges[j].y0 })
+	sort.Float64s(ys)
+
+	nextEdge := 0
+	y := ys[0]
+	for _, nextY := range ys[1:] {
+		for y < nextY {
+			// update the active edges for the slab starting at y
+			n := 0
+			for _, i := range t.active {
+				if t.edges[i].y1 > y {
+					t.active[n] = i
+					n++
+				}
+			}
+			t.active = t.active[:n]
+			for nextEdge < len(t.edges) && t.edges[nextEdge].y0 <= y {
+				if t.edges[nextEdge].y1 > y {
+					t.active = append(t.active, nextEdge)
+				}
+				nextEdge++
+			}
+
+			bottom := t.splitAtCrossings(y, nextY)
+			t.addSlab(y, bottom, rule)
+			y = bottom
+		}
+	}
+	t.mergeBoundaries()
+}
+
+// splitAtCrossings sorts the active edges at the top of the slab and returns the bottom of the slab, which is moved upwards to the first crossing of two edges.
+func (t *pathTessellator) splitAtCrossings(top, bottom float64) float64 {
+	insertionSort(t.active, func(a, b int) bool {
+		ea, eb := &t.edges[a], &t.edges[b]
+		xa, xb := ea.xAt(top), eb.xAt(top)
+		if xa != xb {
+			return xa < xb
+		}
+		return ea.slope < eb.slope
+	})
+
+	// the first crossing always happens between edges that are adjacent at the top
+	for i := 1; i < len(t.active); i++ {
+		ea, eb := &t.edges[t.active[i-1]], &t.edges[t.active[i]]
+		if ea.slope <= eb.slope || ea.xAt(bottom) <= eb.xAt(bottom) {
+			continue
+		}
+		crossing := top + (eb.xAt(top)-ea.xAt(top))/(ea.slope-eb.slope)
+		if crossing > top+minSlabHeight && crossing < bottom-minSlabHeight {
+			bottom = crossing
+		}
+	}
+	return bottom
+}
+
+// addSlab adds the edges that bound the filled area between top and bottom to the boundary.
+func (t *pathTessellator) addSlab(top, bottom float64, rule FillRule) {
+	// edges do not cross inside the slab, so their order in the middle is valid for the whole slab
+	mid := (top + bottom) / 2
+	t.order = append(t.order[:0], t.active...)
+	insertionSort(t.order, func(a, b int) bool { return t.edges[a].xAt(mid) < t.edges[b].xAt(mid) })
+
+	winding := 0
+	for _, i := range t.order {
+		wasInside := rule.isInside(winding)
+		winding += t.edges[i].winding
+		if isInside := rule.isInside(winding); isInside && !wasInside {
+			t.boundaries = append(t.boundaries, pathBoundary{edge: i, top: top, bottom: bottom, winding: -1})
+		} else if !isInside && wasInside {
+			t.boundaries = append(t.boundaries, pathBoundary{edge: i, top: top, bottom: bottom, winding: 1})
+		}
+	}
+}
+
+// mergeBoundaries joins consecutive parts of the same edge, so that cells can be merged vertically.
+func (t *pathTessellator) mergeBoundaries() {
+	sort.Slice(t.boundaries, func(i, j int) bool {
+		bi, bj := &t.boundaries[i], &t.boundaries[j]
+		if bi.edge != bj.edge {
+			return bi.edge < bj.edge
+		}
+		if bi.winding != bj.winding {
+			return bi.winding < bj.winding
+		}
+		return bi.top < bj.top
+	})
+	n := 0
+	for i, b := range t.boundaries {
+		if i > 0 {
+			prev := &t.boundaries[n-1]
+			if prev.edge == b.edge && prev.winding == b.winding && prev.bottom == b.top {
+				prev.bottom = b.bottom
+				continue
+			}
+		}
+		t.boundaries[n] = b
+		n++
+	}
+	t.boundaries = t.boundaries[:n]
+}
+
+// computeCells divides all pixel rows between minRow and maxRow into cells. Within a row, cells are separated where the set of partially covered boundaries changes, and equal cells of consecutive rows are merged. Columns are limited to the range from minCol to maxCol.
+func (t *pathTessellator) computeCells(minRow, maxRow, minCol, maxCol int) {
+	t.cells = t.cells[:0]
+	if len(t.boundaries) == 0 {
+		return
+	}
+	if t.prevCells == nil {
+		t.prevCells = make(map[pathCell]int)
+		t.curCells = make(map[pathCell]int)
+	}
+	for key := range t.prevCells {
+		delete(t.prevCells, key)
+	}
+
+	sort.Slice(t.boundaries, func(i, j int) bool { return t.boundaries[i].top < t.boundaries[j].top })
+	minRow = maxInt(minRow, int(math.Floor(t.boundaries[0].top)))
+	t.active = t.active[:0]
+	next := 0
+	for row := minRow; row < maxRow; row++ {
+		rowTop, rowBottom := float64(row), float64(row+1)
+
+		n := 0
+		for _, i := range t.active {
+			if t.boundaries[i].bottom > rowTop {
+				t.active[n] = i
+				n++
+			}
+		}
+		t.active = t.active[:n]
+		for next < len(t.boundaries) && t.boundaries[next].top < rowBottom {
+			if t.boundaries[next].bottom > rowTop {
+				t.active = append(t.active, next)
+			}
+			next++
+		}
+		if len(t.active) == 0 {
+			if next == len(t.boundaries) {
+				break
+			}
+			// cells must not be merged across empty rows
+			for key := range t.prevCells {
+				delete(t.prevCells, key)
+			}
+			continue
+		}
+
+		t.rowBoundaries = t.rowBoundaries[:0]
+		t.cols = t.cols[:0]
+		for _, i := range t.active {
+			b := &t.boundaries[i]
+			y0, y1 := math.Max(b.top, rowTop), math.Min(b.bottom, rowBottom)
+			if y1 <= y0 {
+				continue
+			}
+			e := &t.edges[b.edge]
+			// columns outside of the range are not visible, but still affect the coverage of columns left of the boundary
+			x0 := clamp64(e.xAt(y0), float64(minCol), float64(maxCol))
+			x1 := clamp64(e.xAt(y1), float64(minCol), float64(maxCol))
+			rb := rowBoundary{
+				boundary: i,
+				firstCol: int(math.Floor(math.Min(x0, x1))),
+				lastCol:  int(math.Ceil(math.Max(x0, x1))) - 1,
+				coverage: b.winding * (y1 - y0),
+			}
+			t.rowBoundaries = append(t.rowBoundaries, rb)
+			t.cols = append(t.cols, rb.firstCol, rb.lastCol+1)
+		}
+		sort.Ints(t.cols)
+
+		for key := range t.curCells {
+			delete(t.curCells, key)
+		}
+		for i := 1; i < len(t.cols); i++ {
+			left, right := t.cols[i-1], t.cols[i]
+			if left == right {
+				continue
+			}
+			var base float64
+			partial := t.partial[:0]
+			for j := range t.rowBoundaries {
+				rb := &t.rowBoundaries[j]
+				if rb.firstCol >= right {
+					base += rb.coverage
+				} else if rb.lastCol >= left {
+					partial = append(partial, j)
+				}
+			}
+			t.partial = partial
+
+			if len(partial) <= maxCellSegments {
+				cell := pathCell{left: left, right: right, base: base, boundaries: [maxCellSegments]int{-1, -1}}
+				for k, j := range partial {
+					cell.boundaries[k] = t.rowBoundaries[j].boundary
+				}
+				if len(partial) == 0 && math.Abs(base) < 1e-6 {
+					continue
+				}
+				t.addCell(cell, row)
+				continue
+			}
+
+			// too many boundaries for a single cell, compute the coverage of each pixel instead
+			for col := left; col < right; col++ {
+				coverage := base
+				for _, j := range partial {
+					b := &t.boundaries[t.rowBoundaries[j].boundary]
+					coverage += b.winding * float64(t.boundaryIntegral(b, float32(row), float32(col)))
+				}
+				if math.Abs(coverage) < 1e-6 {
+					continue
+				}
+				t.addCell(pathCell{left: col, right: col + 1, base: coverage, boundaries: [maxCellSegments]int{-1, -1}}, row)
+			}
+		}
+		t.prevCells, t.curCells = t.curCells, t.prevCells
+	}
+}
+
+// addCell appends a cell for the given row or extends the equal cell of the previous row.
+func (t *pathTessellator) addCell(cell pathCell, row int) {
+	if i, ok := t.prevCells[cell]; ok {
+		t.cells[i].bottom = row + 1
+		t.curCells[cell] = i
+		return
+	}
+	// the key does not contain the rows, so it matches the cell of the next row
+	t.curCells[cell] = len(t.cells)
+	cell.top, cell.bottom = row, row+1
+	t.cells = append(t.cells, cell)
+}
+
+// boundaryIntegral returns the area of the pixel in the given row and column that lies left of the boundary.
+func (t *pathTessellator) boundaryIntegral(b *pathBoundary, row, col float32) float32 {
+	segment := t.segment(b)
+	y0, y1 := max32(row, segment[2]), min32(row+1, segment[3])
+	if y1 <= y0 {
+		return 0
+	}
+	return edgeIntegral(mgl32.Vec2{segment[0], segment[1]}, segment[2], col, y0, y1)
+}
+
+// segment returns the boundary as x-coordinate at the top, slope dx/dy, top and bottom.
+func (t *pathTessellator) segment(b *pathBoundary) [4]float32 {
+	e := &t.edges[b.edge]
+	return [4]float32{float32(e.xAt(b.top)), float32(e.slope), float32(b.top), float32(b.bottom)}
+}
+
+// insertionSort sorts indices in place. It is fast for the nearly sorted edge lists of consecutive slabs and does not allocate.
+func insertionSort(indices []int, less func(a, b int) bool) {
+	for i := 1; i < len(indices); i++ {
+		v := indices[i]
+		j := i
+		for j > 0 && less(v, indices[j-1]) {
+			indices[j] = indices[j-1]
+			j--
+		}
+		indices[j] = v
+	}
+}
+
+// renderPolygons fills polygons given in screen coordinates using the current clip rectangle and clip mask. The paint is given in local coordinates of the current transformation.
+//
+// The filled area is divided into cells of pixels that are rendered as batched shapes. Each cell computes the exact coverage of its pixels from the boundaries of the area, so no textures or additional draw calls are required.
+func renderPolygons(polygons [][]mgl32.Vec2, rule FillRule, paint Paint) {
+	if len(polygons) == 0 || clipRect.IsEmpty() {
+		return
+	}
+
+	var tessellator pathTessellator
+	tessellator.tessellate(polygons, rule)
+	// the clip rectangle includes its bottom pixel row
+	tessellator.computeCells(int(math.Floor(float64(clipRect.Top))), int(math.Ceil(float64(clipRect.Bottom)))+1,
+		int(math.Floor(float64(clipRect.Left)))-1, int(math.Ceil(float64(clipRect.Right)))+1)
+	if len(tessellator.cells) == 0 {
+		return
+	}
+
+	s := &shape{kind: shapeFillPath}
+	paint.applyTo(s)
+	if s.paint.kind != paintSolid {
+		// the paint needs to be evaluated in local coordinates of the path
+		s.paint.transform = s.paint.transform.Mul3(transform.Inv())
+	}
+
+	// the cells are already given in screen coordinates
+	oldTransform := transform
+	transform = mgl32.Ident3()
+	for _, cell := range tessellator.cells {
+		s.quad = Quad{Left: float32(cell.left), Right: float32(cell.right), Top: float32(cell.top), Bottom: float32(cell.bottom)}
+		s.pathBase = float32(cell.base)
+		for i, b := range cell.boundaries {
+			if b < 0 {
+				s.pathSegments[i] = [4]float32{}
+				s.pathWindings[i] = 0
+				continue
+			}
+			s.pathSegments[i] = tessellator.segment(&tessellator.boundaries[b])
+			s.pathWindings[i] = float32(tessellator.boundaries[b].winding)
+		}
+		renderShape(s)
+	}
+	transform = oldTransform
+}
+
+// pathCoverage returns the coverage of the pixel at the given center, which is the base coverage plus the signed areas left of the boundary segments.
+func pathCoverage(center mgl32.Vec2, base float32, segments [2][4]float32, windings [2]float32) float32 {
+	row := floor32(center[1])
+	col := floor32(center[0])
+	coverage := base
+	for i, segment := range segments {
+		if windings[i] == 0 {
+			continue
+		}
+		y0, y1 := max32(row, segment[2]), min32(row+1, segment[3])
+		if y1 > y0 {
+			coverage += windings[i] * edgeIntegral(mgl32.Vec2{segment[0], segment[1]}, segment[2], col, y0, y1)
+		}
+	}
+	return clamp32(coverage, 0, 1)
+}
+
+// edgeIntegral returns the area of a pixel row left of an edge between y0 and y1. The edge is given by its x-coordinate at edgeY and its slope dx/dy.
+func edgeIntegral(edge mgl32.Vec2, edgeY, pixelLeft, y0, y1 float32) float32 {
+	u0 := edge[0] + edge[1]*(y0-edgeY) - pixelLeft
+	u1 := edge[0] + edge[1]*(y1-edgeY) - pixelLeft
+	if abs32(u1-u0) < 0.001 {
+		return clamp32(0.5*(u0+u1), 0, 1) * (y1 - y0)
+	}
+	return (coverageIntegral(u1) - coverageIntegral(u0)) / edge[1]
+}
+
+// coverageIntegral is the antiderivative of the horizontal coverage of a pixel, which is the distance of an edge to the left pixel border clamped to [0, 1].
+func coverageIntegral(u float32) float32 {
+	if u < 0 {
+		return 0
+	}
+	if u <= 1 {
+		return 0.5 * u * u
+	}
+	return u - 0.5
+}
+
+// transformPolygons applies a transformation to all points.
+func transformPolygons(m mgl32.Mat3, polygons [][]mgl32.Vec2) [][]mgl32.Vec2 {
+	result := make([][]mgl32.Vec2, len(polygons))
+	for i, polygon := range polygons {
+		result[i] = make([]mgl32.Vec2, len(polygon))
+		for j, p := range polygon {
+			result[i][j] = transformPoint(m, p)
+		}
+	}
+	return result
+}
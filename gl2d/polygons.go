@@ -0,0 +1,52 @@
+package gl2d
+
+import (
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	// polylineStyle matches the round ends of DrawLine.
+	polylineStyle = StrokeStyle{Join: LineJoinRound, Cap: LineCapRound}
+)
+
+// DrawPolyline renders connected line segments as single shape. In contrast to multiple calls to DrawLine, joints are rounded and translucent colors do not overlap.
+func DrawPolyline(points []mgl32.Vec2, lineWidth float32, color Color) {
+	if len(points) == 0 {
+		return
+	}
+	path := NewPath()
+	path.MoveTo(points[0])
+	for _, p := range points[1:] {
+		path.LineTo(p)
+	}
+	style := polylineStyle
+	style.Width = lineWidth
+	StrokePath(path, style, color)
+}
+
+// FillPolygon renders the area enclosed by the given points. Self-intersecting polygons are filled using FillRuleNonZero.
+func FillPolygon(points []mgl32.Vec2, color Color) {
+	FillPolygonPaint(points, color)
+}
+
+// FillPolygonPaint renders the area enclosed by the given points using the given paint.
+func FillPolygonPaint(points []mgl32.Vec2, paint Paint) {
+	if len(points) < 3 {
+		return
+	}
+	path := NewPath()
+	path.Polygon(points)
+	FillPathPaint(path, FillRuleNonZero, paint)
+}
+
+// DrawPolygon renders the closed outline of a polygon.
+func DrawPolygon(points []mgl32.Vec2, lineWidth float32, color Color) {
+	if len(points) == 0 {
+		return
+	}
+	path := NewPath()
+	path.Polygon(points)
+	style := polylineStyle
+	style.Width = lineWidth
+	StrokePath(path, style, color)
+}
@@ -1,78 +1,107 @@
 package gl2d
 
 import (
-	"github.com/sbreitf1/go-gl-lib/glutil"
-
-	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
 
-var (
-	fillRectangleProg uint32
-	drawRectangleProg uint32
-)
+// FillRectangle renders a filled rectangle.
+func FillRectangle(topLeft, size mgl32.Vec2, color Color) {
+	FillRectanglePaint(topLeft, size, color)
+}
+
+// FillRectanglePaint renders a filled rectangle using the given paint.
+func FillRectanglePaint(topLeft, size mgl32.Vec2, paint Paint) {
+	s := newRectangleShape(shapeFillRectangle, topLeft, size, paint)
+	s.quad = Quad{
+		Left:   floor32(topLeft[0]) - ceil32(localRBlend()),
+		Right:  ceil32(topLeft[0]+size[0]) + ceil32(localRBlend()),
+		Top:    floor32(topLeft[1]) - ceil32(localRBlend()),
+		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(localRBlend()),
+	}
+	renderShape(s)
+}
+
+// DrawRectangle renders an outlined rectangle. The outline center exactly represents the rectangle defined by size, thus the visible outermost rectangle size is increased by lineWidth in both dimensions.
+func DrawRectangle(topLeft, size mgl32.Vec2, lineWidth float32, color Color) {
+	// respect pixel offset
+	topLeft = topLeft.Add([2]float32{0.5, 0.5})
+	size = size.Sub([2]float32{1, 1})
 
-func initRectangles() error {
-	var err error
-	if fillRectangleProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   defaultVertexShader,
-		Fragment: fillRectangleFragmentShader,
-	}); err != nil {
-		return err
+	s := newRectangleShape(shapeDrawRectangle, topLeft, size, color)
+	s.halfLineWidth = lineWidth / 2.0
+	s.quad = Quad{
+		Left:   floor32(topLeft[0]) - ceil32(lineWidth/2.0) - ceil32(localRBlend()),
+		Right:  ceil32(topLeft[0]+size[0]) + ceil32(lineWidth/2.0) + ceil32(localRBlend()),
+		Top:    floor32(topLeft[1]) - ceil32(lineWidth/2.0) - ceil32(localRBlend()),
+		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(lineWidth/2.0) + ceil32(localRBlend()),
 	}
+	renderShape(s)
+}
 
-	drawRectangleProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   defaultVertexShader,
-		Fragment: drawRectangleFragmentShader,
-	})
-	return err
+func newRectangleShape(kind shapeKind, topLeft, size mgl32.Vec2, paint Paint) *shape {
+	s := &shape{
+		kind:   kind,
+		left:   topLeft[0],
+		right:  topLeft[0] + size[0],
+		top:    topLeft[1],
+		bottom: topLeft[1] + size[1],
+	}
+	paint.applyTo(s)
+	return s
 }
 
-func terminateRectangles() {
-	gl.DeleteProgram(fillRectangleProg)
-	gl.DeleteProgram(drawRectangleProg)
+// CornerRadii denotes the radius of each corner of a rounded rectangle.
+type CornerRadii struct {
+	TopLeft, TopRight, BottomRight, BottomLeft float32
 }
 
-// FillRectangle renders a filled rectangle.
-func FillRectangle(topLeft, size mgl32.Vec2, color Color) {
-	setupRectangleProgram(fillRectangleProg, topLeft, size, color)
+// UniformCornerRadii returns CornerRadii with the same radius for all corners.
+func UniformCornerRadii(radius float32) CornerRadii {
+	return CornerRadii{radius, radius, radius, radius}
+}
 
-	renderQuad(Quad{
-		Left:   floor32(topLeft[0]) - ceil32(rBlend),
-		Right:  ceil32(topLeft[0]+size[0]) + ceil32(rBlend),
-		Top:    floor32(topLeft[1]) - ceil32(rBlend),
-		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(rBlend),
-	})
+// FillRoundedRectangle renders a filled rectangle with rounded corners. Radii are limited to half of the smaller rectangle dimension.
+func FillRoundedRectangle(topLeft, size mgl32.Vec2, radii CornerRadii, color Color) {
+	FillRoundedRectanglePaint(topLeft, size, radii, color)
 }
 
-// DrawRectangle renders an outlined rectangle. The outline center exactly represents the rectangle defined by size, thus the visible outermost rectangle size is increased by lineWidth in both dimensions.
-func DrawRectangle(topLeft, size mgl32.Vec2, lineWidth float32, color Color) {
+// FillRoundedRectanglePaint renders a filled rectangle with rounded corners using the given paint.
+func FillRoundedRectanglePaint(topLeft, size mgl32.Vec2, radii CornerRadii, paint Paint) {
+	s := newRectangleShape(shapeFillRoundedRectangle, topLeft, size, paint)
+	s.cornerRadii = clampCornerRadii(radii, size)
+	s.quad = Quad{
+		Left:   floor32(topLeft[0]) - ceil32(localRBlend()),
+		Right:  ceil32(topLeft[0]+size[0]) + ceil32(localRBlend()),
+		Top:    floor32(topLeft[1]) - ceil32(localRBlend()),
+		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(localRBlend()),
+	}
+	renderShape(s)
+}
+
+// DrawRoundedRectangle renders an outlined rectangle with rounded corners. Like DrawRectangle, the outline is centered on the rectangle defined by size.
+func DrawRoundedRectangle(topLeft, size mgl32.Vec2, radii CornerRadii, lineWidth float32, color Color) {
 	// respect pixel offset
 	topLeft = topLeft.Add([2]float32{0.5, 0.5})
 	size = size.Sub([2]float32{1, 1})
 
-	setupRectangleProgram(drawRectangleProg, topLeft, size, color)
-	gl.Uniform1f(gl.GetUniformLocation(drawRectangleProg, gl.Str("halfLineWidth\x00")), lineWidth/2.0)
-
-	renderQuad(Quad{
-		Left:   floor32(topLeft[0]) - ceil32(lineWidth/2.0) - ceil32(rBlend),
-		Right:  ceil32(topLeft[0]+size[0]) + ceil32(lineWidth/2.0) + ceil32(rBlend),
-		Top:    floor32(topLeft[1]) - ceil32(lineWidth/2.0) - ceil32(rBlend),
-		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(lineWidth/2.0) + ceil32(rBlend),
-	})
+	s := newRectangleShape(shapeDrawRoundedRectangle, topLeft, size, color)
+	s.cornerRadii = clampCornerRadii(radii, size)
+	s.halfLineWidth = lineWidth / 2.0
+	s.quad = Quad{
+		Left:   floor32(topLeft[0]) - ceil32(lineWidth/2.0) - ceil32(localRBlend()),
+		Right:  ceil32(topLeft[0]+size[0]) + ceil32(lineWidth/2.0) + ceil32(localRBlend()),
+		Top:    floor32(topLeft[1]) - ceil32(lineWidth/2.0) - ceil32(localRBlend()),
+		Bottom: ceil32(topLeft[1]+size[1]) + ceil32(lineWidth/2.0) + ceil32(localRBlend()),
+	}
+	renderShape(s)
 }
 
-func setupRectangleProgram(prog uint32, topLeft, size mgl32.Vec2, color Color) {
-	left := topLeft[0]
-	right := left + size[0]
-	top := topLeft[1]
-	bottom := top + size[1]
-
-	useProg(prog)
-	gl.Uniform1fv(gl.GetUniformLocation(prog, gl.Str("left\x00")), 1, &left)
-	gl.Uniform1fv(gl.GetUniformLocation(prog, gl.Str("right\x00")), 1, &right)
-	gl.Uniform1fv(gl.GetUniformLocation(prog, gl.Str("top\x00")), 1, &top)
-	gl.Uniform1fv(gl.GetUniformLocation(prog, gl.Str("bottom\x00")), 1, &bottom)
-	gl.Uniform1f(gl.GetUniformLocation(prog, gl.Str("rBlend\x00")), rBlend)
-	gl.Uniform4fv(gl.GetUniformLocation(prog, gl.Str("color\x00")), 1, &color[0])
+func clampCornerRadii(radii CornerRadii, size mgl32.Vec2) [4]float32 {
+	maxRadius := max32(min32(abs32(size[0]), abs32(size[1]))/2, 0)
+	return [4]float32{
+		clamp32(radii.TopLeft, 0, maxRadius),
+		clamp32(radii.TopRight, 0, maxRadius),
+		clamp32(radii.BottomRight, 0, maxRadius),
+		clamp32(radii.BottomLeft, 0, maxRadius),
+	}
 }
@@ -0,0 +1,226 @@
+package gl2d
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// objectReplacementRune represents inline images in the plain text of rich text layouts
+	objectReplacementRune = '\uFFFC'
+)
+
+// RichTextSpan denotes a run of text with a uniform style. Zero values inherit the style passed to DrawRichText.
+type RichTextSpan struct {
+	Text string
+	// Color replaces the text color if set.
+	Color  *Color
+	Bold   bool
+	Italic bool
+	// Font selects a font of RichTextResources.Fonts by name.
+	Font string
+	// Scale is multiplied with the scale of the text. Defaults to 1.
+	Scale float32
+	// Image selects an inline image of RichTextResources.Images by name that replaces the text of the span.
+	Image string
+}
+
+// RichTextResources contains the fonts and images that can be referenced by rich text.
+type RichTextResources struct {
+	Fonts map[string]*Font
+	// BoldFonts and ItalicFonts map fonts to their bold and italic variants. Bold text is emulated for fonts without bold variant, while italic text is rendered upright.
+	BoldFonts   map[*Font]*Font
+	ItalicFonts map[*Font]*Font
+	// Images are rendered as high as the ascent of the surrounding font and are aligned to the baseline.
+	Images map[string]*glutil.Texture
+}
+
+// ParseRichText splits markup into styled spans. Supported tags are [b]..[/b], [i]..[/i], [color=#rrggbb]..[/color] or with alpha as #rrggbbaa, [font=name]..[/font], [size=factor]..[/size] and [img=name]. Use [[ to insert a literal bracket.
+func ParseRichText(markup string) ([]RichTextSpan, error) {
+	type state struct {
+		tag   string
+		style RichTextSpan
+	}
+	stack := []state{{}}
+	var spans []RichTextSpan
+	var text strings.Builder
+	flush := func() {
+		if text.Len() > 0 {
+			span := stack[len(stack)-1].style
+			span.Text = text.String()
+			spans = append(spans, span)
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(markup); {
+		if markup[i] != '[' {
+			text.WriteByte(markup[i])
+			i++
+			continue
+		}
+		if strings.HasPrefix(markup[i:], "[[") {
+			text.WriteByte('[')
+			i += 2
+			continue
+		}
+		length := strings.IndexByte(markup[i:], ']')
+		if length < 0 {
+			return nil, fmt.Errorf("unterminated tag at offset %d", i)
+		}
+		tag := markup[i+1 : i+length]
+		offset := i
+		i += length + 1
+		flush()
+
+		if strings.HasPrefix(tag, "/") {
+			if len(stack) == 1 || stack[len(stack)-1].tag != tag[1:] {
+				return nil, fmt.Errorf("unexpected closing tag [%s] at offset %d", tag, offset)
+			}
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		name, value := tag, ""
+		hasValue := false
+		if j := strings.IndexByte(tag, '='); j >= 0 {
+			name, value, hasValue = tag[:j], tag[j+1:], true
+		}
+		if hasValue != (name == "color" || name == "font" || name == "size" || name == "img") {
+			return nil, fmt.Errorf("invalid tag [%s] at offset %d", tag, offset)
+		}
+
+		style := stack[len(stack)-1].style
+		switch name {
+		case "b":
+			style.Bold = true
+		case "i":
+			style.Italic = true
+		case "color":
+			c, err := parseHexColor(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid color at offset %d: %s", offset, err.Error())
+			}
+			style.Color = &c
+		case "font":
+			style.Font = value
+		case "size":
+			factor, err := strconv.ParseFloat(value, 32)
+			if err != nil || factor <= 0 {
+				return nil, fmt.Errorf("invalid size %q at offset %d", value, offset)
+			}
+			if style.Scale == 0 {
+				style.Scale = 1
+			}
+			style.Scale *= float32(factor)
+		case "img":
+			// images have no closing tag
+			style.Image = value
+			spans = append(spans, style)
+			continue
+		default:
+			return nil, fmt.Errorf("unknown tag [%s] at offset %d", tag, offset)
+		}
+		stack = append(stack, state{tag: name, style: style})
+	}
+	flush()
+
+	if len(stack) > 1 {
+		return nil, fmt.Errorf("unclosed tag [%s]", stack[len(stack)-1].tag)
+	}
+	return spans, nil
+}
+
+// parseHexColor parses colors in the form #rrggbb or #rrggbbaa.
+func parseHexColor(str string) (Color, error) {
+	if !strings.HasPrefix(str, "#") || (len(str) != 7 && len(str) != 9) {
+		return Color{}, fmt.Errorf("expected #rrggbb or #rrggbbaa instead of %q", str)
+	}
+	c := Color{0, 0, 0, 1}
+	for i := 0; 1+2*i < len(str); i++ {
+		v, err := strconv.ParseUint(str[1+2*i:3+2*i], 16, 8)
+		if err != nil {
+			return Color{}, fmt.Errorf("expected #rrggbb or #rrggbbaa instead of %q", str)
+		}
+		c[i] = float32(v) / 255
+	}
+	return c, nil
+}
+
+// LayoutRichText breaks styled spans into lines like LayoutText. font denotes the default font of all spans. The byte offsets of the layout refer to the concatenated text of all spans, where each image is represented by the rune U+FFFC.
+func LayoutRichText(spans []RichTextSpan, font *Font, res *RichTextResources, opts *DrawStringOptions) *TextLayout {
+	if res == nil {
+		res = &RichTextResources{}
+	}
+	l := newTextLayout(font, opts)
+	paragraphs := []paragraph{{}}
+	offset := 0
+	for _, span := range spans {
+		style := textStyle{font: font, scale: l.opts.Scale, color: span.Color}
+		if span.Scale > 0 {
+			style.scale *= span.Scale
+		}
+		if len(span.Font) > 0 {
+			if f, ok := res.Fonts[span.Font]; ok {
+				style.font = f
+			} else {
+				logrus.Warnf("unknown rich text font %q", span.Font)
+			}
+		}
+		if span.Italic {
+			if f, ok := res.ItalicFonts[style.font]; ok {
+				style.font = f
+			}
+		}
+		if span.Bold {
+			if f, ok := res.BoldFonts[style.font]; ok {
+				style.font = f
+			} else {
+				style.fauxBold = style.scale * max32(1, style.font.lineHeight/20)
+			}
+		}
+
+		text := span.Text
+		if len(span.Image) > 0 {
+			tex, ok := res.Images[span.Image]
+			if !ok {
+				logrus.Warnf("unknown rich text image %q", span.Image)
+				continue
+			}
+			style.image = tex
+			text = string(objectReplacementRune)
+		}
+
+		l.styles = append(l.styles, style)
+		paragraphs = appendParagraphs(paragraphs, text, offset, len(l.styles)-1)
+		offset += len(text)
+	}
+	paragraphs[len(paragraphs)-1].end = offset
+	l.layout(paragraphs)
+	return l
+}
+
+// DrawRichText renders a string with markup as described by ParseRichText. pos denotes the top-left corner of the layout box.
+func DrawRichText(markup string, pos mgl32.Vec2, font *Font, color Color, res *RichTextResources, opts *DrawStringOptions) error {
+	spans, err := ParseRichText(markup)
+	if err != nil {
+		return err
+	}
+	DrawTextLayout(LayoutRichText(spans, font, res, opts), pos, color)
+	return nil
+}
+
+// MeasureRichText returns the size of a string with markup rendered with a specific default font.
+func MeasureRichText(markup string, font *Font, res *RichTextResources, opts *DrawStringOptions) (mgl32.Vec2, error) {
+	spans, err := ParseRichText(markup)
+	if err != nil {
+		return mgl32.Vec2{}, err
+	}
+	return LayoutRichText(spans, font, res, opts).Size, nil
+}
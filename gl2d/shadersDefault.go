@@ -2,162 +2,644 @@ package gl2d
 
 func useShadersDefault() {
 
+	// paintFragmentShader is included by all fragment shaders and evaluates the color of a shape, i.e. a solid color, a gradient or a pattern.
+	// it also converts the final color as required by the active blend mode
+	paintFragmentShader := `
+uniform float blendMode;
+uniform sampler2D paintTex;
+varying vec2 localPos;
+varying vec4 shapeColor;
+varying vec4 paint0;
+varying vec4 paint1;
+
+vec4 evaluatePaint() {
+	vec2 paintPos = paint0.xy;
+	float kind = paint0.z;
+	if (kind < 0.5) {
+		return shapeColor;
+	}
+
+	if (kind > 3.5) {
+		// wrap modes are evaluated here to be independent of the texture parameters
+		vec2 uv = paintPos;
+		float wrap = paint1.x;
+		if (wrap < 0.5) {
+			uv = fract(uv);
+		} else if (wrap < 1.5) {
+			uv = 1.0-abs(mod(uv, 2.0)-1.0);
+		} else if (wrap < 2.5) {
+			vec2 halfTexel = 0.5/paint1.yz;
+			uv = clamp(uv, halfTexel, 1.0-halfTexel);
+		} else if (uv.x < 0.0 || uv.x > 1.0 || uv.y < 0.0 || uv.y > 1.0) {
+			return vec4(0.0);
+		}
+		return shapeColor*texture2D(paintTex, uv);
+	}
+
+	float t;
+	if (kind < 1.5) {
+		vec2 dir = paint1.zw-paint1.xy;
+		t = dot(paintPos-paint1.xy, dir)/dot(dir, dir);
+	} else if (kind < 2.5) {
+		t = distance(paintPos, paint1.xy)/paint1.z;
+	} else {
+		vec2 v = paintPos-paint1.xy;
+		t = fract((atan(v.y, v.x)-paint1.z)/6.28318531);
+	}
+	t = clamp(t, 0.0, 1.0);
+	return shapeColor*texture2D(paintTex, vec2((t*255.0+0.5)/256.0, 0.5));
+}
+
+vec4 unpremultiply(vec4 c) {
+	if (abs(blendMode-4.0) < 0.5 && c.a > 0.0) {
+		return vec4(c.rgb/c.a, c.a);
+	}
+	return c;
+}
+
+vec4 paintColor() {
+	return unpremultiply(evaluatePaint());
+}
+
+vec4 outputColor(vec4 c) {
+	if (abs(blendMode-2.0) < 0.5) {
+		// multiply
+		return vec4(mix(vec3(1.0), c.rgb, c.a), c.a);
+	}
+	if (abs(blendMode-3.0) < 0.5 || abs(blendMode-4.0) < 0.5) {
+		// screen and premultiplied
+		return vec4(c.rgb*c.a, c.a);
+	}
+	return c;
+}
+`
+
+	// all shape parameters are passed as vertex attributes to allow batching of many shapes in a single draw call.
+	// the vertex position is already transformed to screen space, while the shapes are evaluated in local space
 	defaultVertexShader = `#version 120
 
 uniform mat4 projectionMatrix;
-varying vec2 screenPos;
+attribute vec2 vertexPos;
+attribute vec2 vertexLocalPos;
+attribute vec2 vertexUV;
+attribute vec4 vertexColor;
+attribute vec4 vertexParams0;
+attribute vec4 vertexParams1;
+attribute vec4 vertexParams2;
+attribute vec4 vertexPaint0;
+attribute vec4 vertexPaint1;
+varying vec2 localPos;
+varying vec2 uv;
+varying vec4 shapeColor;
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+varying vec4 paint0;
+varying vec4 paint1;
 
 void main() {
-	gl_Position = projectionMatrix*gl_Vertex;
-	screenPos = gl_Vertex.xy;
+	gl_Position = projectionMatrix*vec4(vertexPos, 0, 1);
+	localPos = vertexLocalPos;
+	uv = vertexUV;
+	shapeColor = vertexColor;
+	params0 = vertexParams0;
+	params1 = vertexParams1;
+	params2 = vertexParams2;
+	paint0 = vertexPaint0;
+	paint1 = vertexPaint1;
 }`
 
 	fillCircleFragmentShader = `#version 120
-
-varying vec2 screenPos;
-uniform vec2 center;
-uniform float radius;
-uniform float rBlend = 0.5;
-uniform vec4 color;
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
 void main() {
-	float d = distance(screenPos.xy,center)-0.5;
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	float radius = params0.z;
+	float rBlend = params1.w;
+
+	float d = distance(localPos.xy,center)-0.5;
 	if (d >= radius+rBlend) {
 		discard;
 	}
 	if (d <= radius-rBlend) {
-		gl_FragColor = color;
+		gl_FragColor = outputColor(color);
 	} else {
 		float f = (2*rBlend+radius-rBlend-d)/(2*rBlend);
-		gl_FragColor = vec4(color.rgb, f*color.a);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
 	}
 }`
 	drawCircleFragmentShader = `#version 120
-
-varying vec2 screenPos;
-uniform vec2 center;
-uniform float radius;
-uniform float halfLineWidth;
-uniform float rBlend = 0.5;
-uniform vec4 color;
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
 void main() {
-	float d = abs(radius-distance(screenPos.xy,center));
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	float radius = params0.z;
+	float halfLineWidth = params0.w;
+	float rBlend = params1.w;
+
+	float d = abs(radius-distance(localPos.xy,center));
 	if (d <= halfLineWidth-rBlend) {
-		gl_FragColor = color;
+		gl_FragColor = outputColor(color);
 	} else if (d >= halfLineWidth+rBlend) {
 		discard;
 	} else {
 		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
-		gl_FragColor = vec4(color.rgb, f*color.a);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
 	}
 }`
 
-	drawImageVertexShader = `#version 120
-
-uniform mat4 projectionMatrix;
-
-void main() {
-	gl_Position = projectionMatrix*gl_Vertex;
-	gl_TexCoord[0] = gl_MultiTexCoord0;
-}`
 	drawImageFragmentShader = `#version 120
-
-varying vec2 screenPos;
+` + paintFragmentShader + `
 uniform sampler2D tex;
 varying vec2 uv;
-uniform vec4 color;
 
 void main() {
-	gl_FragColor = vec4(color*texture2D(tex, gl_TexCoord[0].st));
+	vec4 color = paintColor();
+
+	gl_FragColor = outputColor(color*unpremultiply(texture2D(tex, uv)));
 }`
 
 	drawLineFragmentShader = `#version 120
-
-varying vec2 screenPos;
-uniform vec2 lineOffspring;
-uniform vec2 lineDir;
-uniform float lineLength;
-uniform float halfLineWidth;
-uniform float rBlend = 0.5;
-uniform vec4 color;
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
 void main() {
-	vec2 dir = screenPos.xy-lineOffspring;
+	vec4 color = paintColor();
+
+	vec2 lineOffspring = params0.xy;
+	vec2 lineDir = params0.zw;
+	float lineLength = params1.x;
+	float halfLineWidth = params1.y;
+	float rBlend = params1.w;
+
+	vec2 dir = localPos.xy-lineOffspring;
 	float p = dot(lineDir,dir);
 	float d;
 	if (p >= 0 && p <= lineLength) {
-		d = distance(lineOffspring+p*lineDir, screenPos.xy);
+		d = distance(lineOffspring+p*lineDir, localPos.xy);
 	} else if (p < 0) {
-		d = distance(lineOffspring, screenPos.xy);
+		d = distance(lineOffspring, localPos.xy);
 	} else {
-		d = distance(lineOffspring+lineLength*lineDir, screenPos.xy);
+		d = distance(lineOffspring+lineLength*lineDir, localPos.xy);
 	}
 	if (d <= halfLineWidth-rBlend) {
-		gl_FragColor = color;
+		gl_FragColor = outputColor(color);
 	} else if (d >= halfLineWidth+rBlend) {
 		discard;
 	} else {
 		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
-		gl_FragColor = vec4(color.rgb, f*color.a);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
 	}
 }`
 
 	fillRectangleFragmentShader = `#version 120
-
-varying vec2 screenPos;
-uniform float left, right, top, bottom;
-uniform float rBlend = 0.5;
-uniform vec4 color;
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
 void main() {
-	float d = max(max(left-screenPos.x, screenPos.x-right), max(top-screenPos.y, screenPos.y-bottom));
+	vec4 color = paintColor();
+
+	float left = params0.x, right = params0.y, top = params0.z, bottom = params0.w;
+	float rBlend = params1.w;
+
+	float d = max(max(left-localPos.x, localPos.x-right), max(top-localPos.y, localPos.y-bottom));
 	if (d <= -rBlend) {
-		gl_FragColor = color;
+		gl_FragColor = outputColor(color);
 	} else if (d >= rBlend) {
 		discard;
 	} else {
 		float f = (2*rBlend-rBlend-d)/(2*rBlend);
-		gl_FragColor = vec4(color.rgb, f*color.a);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
 	}
 }`
 	drawRectangleFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+
+void main() {
+	vec4 color = paintColor();
+
+	float left = params0.x, right = params0.y, top = params0.z, bottom = params0.w;
+	float halfLineWidth = params1.y;
+	float rBlend = params1.w;
+
+	float d = abs(max(max(left-localPos.x, localPos.x-right), max(top-localPos.y, localPos.y-bottom)));
+	if (d <= halfLineWidth-rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= halfLineWidth+rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
 
-varying vec2 screenPos;
-uniform float left, right, top, bottom;
-uniform float halfLineWidth;
-uniform float rBlend = 0.5;
-uniform vec4 color;
+	fillRoundedRectangleFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
 
 void main() {
-	float d = abs(max(max(left-screenPos.x, screenPos.x-right), max(top-screenPos.y, screenPos.y-bottom)));
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	vec2 halfSize = params0.zw;
+	vec4 radii = params2;
+	float rBlend = params1.w;
+
+	vec2 p = localPos.xy-center;
+	float r = p.x < 0 ? (p.y < 0 ? radii.x : radii.w) : (p.y < 0 ? radii.y : radii.z);
+	vec2 q = abs(p)-halfSize+r;
+	float d = length(max(q, 0.0))+min(max(q.x, q.y), 0.0)-r;
+	if (d <= -rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
+	drawRoundedRectangleFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+
+void main() {
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	vec2 halfSize = params0.zw;
+	vec4 radii = params2;
+	float halfLineWidth = params1.y;
+	float rBlend = params1.w;
+
+	vec2 p = localPos.xy-center;
+	float r = p.x < 0 ? (p.y < 0 ? radii.x : radii.w) : (p.y < 0 ? radii.y : radii.z);
+	vec2 q = abs(p)-halfSize+r;
+	float d = abs(length(max(q, 0.0))+min(max(q.x, q.y), 0.0)-r);
 	if (d <= halfLineWidth-rBlend) {
-		gl_FragColor = color;
+		gl_FragColor = outputColor(color);
 	} else if (d >= halfLineWidth+rBlend) {
 		discard;
 	} else {
 		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
-		gl_FragColor = vec4(color.rgb, f*color.a);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
 	}
 }`
 
-	drawStringVertexShader = `#version 120
+	fillEllipseFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
-uniform mat4 projectionMatrix;
+void main() {
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	vec2 radii = params0.zw;
+	float rBlend = params1.w;
+
+	// first order approximation of the distance to the ellipse
+	vec2 p = localPos.xy-center;
+	float k0 = length(p/radii);
+	float k1 = length(p/(radii*radii));
+	float d = k1 > 0 ? k0*(k0-1)/k1 : -min(radii.x, radii.y);
+	if (d <= -rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
+	drawEllipseFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
 
 void main() {
-	gl_Position = projectionMatrix*gl_Vertex;
-	gl_TexCoord[0] = gl_MultiTexCoord0;
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	vec2 radii = params0.zw;
+	float halfLineWidth = params1.y;
+	float rBlend = params1.w;
+
+	// first order approximation of the distance to the ellipse
+	vec2 p = localPos.xy-center;
+	float k0 = length(p/radii);
+	float k1 = length(p/(radii*radii));
+	float d = abs(k1 > 0 ? k0*(k0-1)/k1 : -min(radii.x, radii.y));
+	if (d <= halfLineWidth-rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= halfLineWidth+rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
+
+	drawArcFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+
+void main() {
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	float radius = params0.z;
+	float halfLineWidth = params0.w;
+	vec2 midDir = params2.xy;
+	float halfSweep = params2.z;
+	float rBlend = params1.w;
+
+	// rotate the arc to be symmetric around the positive x-axis
+	vec2 p = localPos.xy-center;
+	vec2 q = vec2(dot(p, midDir), abs(midDir.x*p.y-midDir.y*p.x));
+	float d;
+	if (atan(q.y, q.x) <= halfSweep) {
+		d = abs(length(q)-radius);
+	} else {
+		d = distance(q, radius*vec2(cos(halfSweep), sin(halfSweep)));
+	}
+	if (d <= halfLineWidth-rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= halfLineWidth+rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend+halfLineWidth-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
+	fillPieFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+
+void main() {
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	float radius = params0.z;
+	vec2 midDir = params2.xy;
+	float halfSweep = params2.z;
+	float rBlend = params1.w;
+
+	// rotate the pie to be symmetric around the positive x-axis
+	vec2 p = localPos.xy-center;
+	vec2 q = vec2(dot(p, midDir), abs(midDir.x*p.y-midDir.y*p.x));
+	float d = length(q)-radius;
+	if (halfSweep < 3.14159265) {
+		vec2 edge = vec2(cos(halfSweep), sin(halfSweep));
+		float m = length(q-edge*clamp(dot(q, edge), 0.0, radius));
+		d = max(d, m*sign(edge.x*q.y-edge.y*q.x));
+	}
+	if (d <= -rBlend) {
+		gl_FragColor = outputColor(color);
+	} else if (d >= rBlend) {
+		discard;
+	} else {
+		float f = (2*rBlend-rBlend-d)/(2*rBlend);
+		gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+	}
+}`
+
+	// path trapezoids integrate the exact area of the pixel between their left and right edge
+	fillPathFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+
+float coverageIntegral(float u) {
+	if (u < 0.0) {
+		return 0.0;
+	}
+	if (u <= 1.0) {
+		return 0.5*u*u;
+	}
+	return u-0.5;
+}
+
+float edgeIntegral(vec2 edge, float edgeY, float pixelLeft, float y0, float y1) {
+	float u0 = edge.x+edge.y*(y0-edgeY)-pixelLeft;
+	float u1 = edge.x+edge.y*(y1-edgeY)-pixelLeft;
+	if (abs(u1-u0) < 0.001) {
+		return clamp(0.5*(u0+u1), 0.0, 1.0)*(y1-y0);
+	}
+	return (coverageIntegral(u1)-coverageIntegral(u0))/edge.y;
+}
+
+float segmentIntegral(vec4 segment, float row, float col) {
+	float y0 = max(row, segment.z);
+	float y1 = min(row+1.0, segment.w);
+	if (y1 <= y0) {
+		return 0.0;
+	}
+	return edgeIntegral(segment.xy, segment.z, col, y0, y1);
+}
+
+void main() {
+	vec4 color = paintColor();
+
+	float row = floor(localPos.y);
+	float col = floor(localPos.x);
+	float f = params1.x;
+	if (params1.y != 0.0) {
+		f += params1.y*segmentIntegral(params0, row, col);
+	}
+	if (params1.z != 0.0) {
+		f += params1.z*segmentIntegral(params2, row, col);
+	}
+	f = clamp(f, 0.0, 1.0);
+	if (f <= 0.0) {
+		discard;
+	}
+	gl_FragColor = outputColor(vec4(color.rgb, f*color.a));
+}`
+
+	// the blurred box is computed analytically, see https://madebyevan.com/shaders/fast-rounded-rectangle-shadows/
+	boxShadowFragmentShader = `#version 120
+` + paintFragmentShader + `
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
+
+float erf(float x) {
+	float s = sign(x);
+	float a = abs(x);
+	x = 1.0+(0.278393+(0.230389+0.078108*(a*a))*a)*a;
+	x *= x;
+	return s-s/(x*x);
+}
+
+float gaussian(float x, float sigma) {
+	return exp(-(x*x)/(2.0*sigma*sigma))/(2.50662827*sigma);
+}
+
+float boxIntegral(float x, float halfSize, float sigma) {
+	return 0.5*(erf((x+halfSize)*0.70710678/sigma)-erf((x-halfSize)*0.70710678/sigma));
+}
+
+float roundedBoxShadowX(float x, float y, float sigma, float r, vec2 halfSize) {
+	float delta = min(halfSize.y-r-abs(y), 0.0);
+	float curved = halfSize.x-r+sqrt(max(0.0, r*r-delta*delta));
+	return boxIntegral(x, curved, sigma);
+}
+
+void main() {
+	vec4 color = paintColor();
+
+	vec2 center = params0.xy;
+	vec2 halfSize = params0.zw;
+	vec4 radii = params2;
+	float sigma = params1.x;
+
+	vec2 p = localPos.xy-center;
+	float r = p.x < 0 ? (p.y < 0 ? radii.x : radii.w) : (p.y < 0 ? radii.y : radii.z);
+	float coverage;
+	if (r <= 0.0) {
+		coverage = boxIntegral(p.x, halfSize.x, sigma)*boxIntegral(p.y, halfSize.y, sigma);
+	} else {
+		// integrate the blurred rows along the vertical axis
+		float low = p.y-halfSize.y;
+		float high = p.y+halfSize.y;
+		float start = clamp(-3.0*sigma, low, high);
+		float end = clamp(3.0*sigma, low, high);
+		float step = (end-start)/4.0;
+		float y = start+0.5*step;
+		coverage = 0.0;
+		for (int i = 0; i < 4; i++) {
+			coverage += roundedBoxShadowX(p.x, p.y-y, sigma, r, halfSize)*gaussian(y, sigma)*step;
+			y += step;
+		}
+	}
+	if (coverage < 0.002) {
+		discard;
+	}
+	gl_FragColor = outputColor(vec4(color.rgb, min(coverage, 1.0)*color.a));
 }`
 	drawStringFragmentShader = `#version 120
+` + paintFragmentShader + `
+uniform sampler2D tex;
+varying vec2 uv;
+varying vec4 params0;
+varying vec4 params1;
+varying vec4 params2;
 
-varying vec2 screenPos;
+void main() {
+	vec4 color = paintColor();
+	vec4 texColor = texture2D(tex, uv);
+	float spread = params0.x;
+	float fauxBoldOffset = params0.w;
+	if (fauxBoldOffset > 0.0) {
+		// faux bold covers the union of the glyph and a shifted copy in a single pass
+		vec2 shiftedUV = vec2(uv.x-fauxBoldOffset, uv.y);
+		vec4 original = uv.x <= params1.y ? texColor : vec4(0.0);
+		vec4 shifted = shiftedUV.x >= params1.x ? texture2D(tex, shiftedUV) : vec4(0.0);
+		texColor = max(original, shifted);
+		if (spread <= 0.0) {
+			texColor.a = original.a+shifted.a-original.a*shifted.a;
+		}
+	}
+	if (spread <= 0.0) {
+		gl_FragColor = outputColor(color*texColor);
+		return;
+	}
+
+	float unitsPerTexel = params0.y;
+	float outlineWidth = params0.z;
+	vec4 outlineColor = params2;
+	float rBlend = params1.w;
+
+	// the distance field stores the distance to the glyph edge, positive inside
+	float d = (texColor.a-0.5)*2.0*spread*unitsPerTexel;
+	float fill = clamp((d+rBlend)/(2.0*rBlend), 0.0, 1.0);
+	float outline = clamp((d+outlineWidth+rBlend)/(2.0*rBlend), 0.0, 1.0)-fill;
+	float fillAlpha = color.a*fill;
+	float outlineAlpha = outlineColor.a*outline;
+	float alpha = fillAlpha+outlineAlpha;
+	if (alpha <= 0.0) {
+		discard;
+	}
+	gl_FragColor = outputColor(vec4((color.rgb*fillAlpha+outlineColor.rgb*outlineAlpha)/alpha, alpha));
+}`
+
+	// the maximum over a disc dilates masks with round corners. Texels are weighted by the distance of their far edge, so straight edges move by exactly the spread. Masks are eroded by dilating the inverted mask
+	spreadMaskFragmentShader = `#version 120
+` + paintFragmentShader + `
+uniform sampler2D tex;
+varying vec2 uv;
+varying vec4 params0;
+
+void main() {
+	vec4 color = paintColor();
+	vec2 texelStep = params0.xy;
+	float spread = params0.z;
+	float radius = abs(spread)+1.0;
+
+	float coverage = 0.0;
+	for (int y = -16; y <= 16; y++) {
+		for (int x = -16; x <= 16; x++) {
+			float weight = clamp(radius-length(vec2(x, y)), 0.0, 1.0);
+			if (weight > 0.0) {
+				float a = texture2D(tex, uv+vec2(x, y)*texelStep).a;
+				if (spread < 0.0) {
+					a = 1.0-a;
+				}
+				coverage = max(coverage, a*weight);
+			}
+		}
+	}
+	if (spread < 0.0) {
+		coverage = 1.0-coverage;
+	}
+	gl_FragColor = outputColor(vec4(color.rgb, coverage*color.a));
+}`
+
+	// a single pass blurs masks along one axis, so a gaussian blur requires a horizontal and a vertical pass
+	blurMaskFragmentShader = `#version 120
+` + paintFragmentShader + `
 uniform sampler2D tex;
 varying vec2 uv;
-uniform vec4 color;
+varying vec4 params0;
 
 void main() {
-	gl_FragColor = vec4(color*texture2D(tex, gl_TexCoord[0].st));
+	vec4 color = paintColor();
+	vec2 texelStep = params0.xy;
+	float sigma = params0.z;
+	float radius = ceil(3.0*sigma);
+
+	float coverage = 0.0;
+	float weightSum = 0.0;
+	for (int i = -64; i <= 64; i++) {
+		float x = float(i);
+		if (abs(x) <= radius) {
+			float weight = exp(-(x*x)/(2.0*sigma*sigma));
+			coverage += weight*texture2D(tex, uv+x*texelStep).a;
+			weightSum += weight;
+		}
+	}
+	gl_FragColor = outputColor(vec4(color.rgb, coverage/weightSum*color.a));
 }`
 
 }
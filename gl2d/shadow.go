@@ -0,0 +1,304 @@
+package gl2d
+
+import (
+	"math"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+const (
+	// maxShadowMaskSize limits the size of blurred text shadow masks in pixels.
+	maxShadowMaskSize = 4096
+	// maxShadowSpreadRadius limits the spread of text shadow masks in pixels and must match the loop limits of spreadMaskFragmentShader.
+	maxShadowSpreadRadius = 16
+	// maxShadowBlurRadius limits the blur radius of text shadow masks in pixels and must match the loop limits of blurMaskFragmentShader.
+	maxShadowBlurRadius = 64
+)
+
+var (
+	// glyphShadows maps the hash of glyph shadow masks to all masks with that hash. Masks that have not been used during a frame are destroyed at its end.
+	glyphShadows = make(map[uint64][]*glyphShadowMask)
+)
+
+// Shadow describes a soft shadow or glow drawn beneath a shape.
+type Shadow struct {
+	// Offset moves the shadow relative to the shape.
+	Offset mgl32.Vec2
+	// Blur denotes the blur radius. The shadow fades out over a distance of about Blur on each side of the shape edge.
+	Blur float32
+	// Spread enlarges the shape before it is blurred. Negative values shrink the shape.
+	Spread float32
+	Color  Color
+}
+
+// Glow returns a shadow without offset that lets a shape glow in the given color.
+func Glow(blur, spread float32, color Color) Shadow {
+	return Shadow{Blur: blur, Spread: spread, Color: color}
+}
+
+// sigma returns the standard deviation of the gaussian blur, which covers two standard deviations within the blur radius.
+func (s Shadow) sigma() float32 {
+	return s.Blur / 2
+}
+
+// DrawRectangleShadow renders the shadow of a rectangle. Draw the rectangle itself afterwards.
+func DrawRectangleShadow(topLeft, size mgl32.Vec2, shadow Shadow) {
+	DrawRoundedRectangleShadow(topLeft, size, CornerRadii{}, shadow)
+}
+
+// DrawRoundedRectangleShadow renders the shadow of a rectangle with rounded corners. The blur is computed analytically, so shadows of any size are rendered in a single pass.
+func DrawRoundedRectangleShadow(topLeft, size mgl32.Vec2, radii CornerRadii, shadow Shadow) {
+	topLeft = topLeft.Add(shadow.Offset).Sub(mgl32.Vec2{shadow.Spread, shadow.Spread})
+	size = size.Add(mgl32.Vec2{2 * shadow.Spread, 2 * shadow.Spread})
+	if size[0] <= 0 || size[1] <= 0 {
+		return
+	}
+	// corners grow with the spread like in CSS, but sharp corners remain sharp
+	for _, r := range []*float32{&radii.TopLeft, &radii.TopRight, &radii.BottomRight, &radii.BottomLeft} {
+		if *r > 0 {
+			*r = max32(*r+shadow.Spread, 0)
+		}
+	}
+
+	sigma := shadow.sigma()
+	if sigma < 0.25 {
+		// without blur, the shadow is a regular shape
+		FillRoundedRectanglePaint(topLeft, size, radii, shadow.Color)
+		return
+	}
+
+	s := newRectangleShape(shapeBoxShadow, topLeft, size, shadow.Color)
+	s.cornerRadii = clampCornerRadii(radii, size)
+	s.shadowSigma = sigma
+	margin := ceil32(3*sigma) + ceil32(localRBlend())
+	s.quad = Quad{
+		Left:   floor32(topLeft[0]) - margin,
+		Right:  ceil32(topLeft[0]+size[0]) + margin,
+		Top:    floor32(topLeft[1]) - margin,
+		Bottom: ceil32(topLeft[1]+size[1]) + margin,
+	}
+	renderShape(s)
+}
+
+// glyphShadowGlyph denotes a glyph of a shadow mask relative to the top-left corner of the mask.
+type glyphShadowGlyph struct {
+	font     *Font
+	r        rune
+	quad     Quad
+	fauxBold float32
+}
+
+// glyphShadowMask holds a blurred glyph mask that is reused while the same text is drawn with the same shadow in consecutive frames.
+type glyphShadowMask struct {
+	glyphs               []glyphShadowGlyph
+	scale, spread, sigma float32
+	target               *glutil.RenderTarget
+	// size denotes the size of the mask in local units
+	size mgl32.Vec2
+	used bool
+}
+
+func (m *glyphShadowMask) matches(glyphs []glyphShadowGlyph, scale, spread, sigma float32) bool {
+	if m.scale != scale || m.spread != spread || m.sigma != sigma || len(m.glyphs) != len(glyphs) {
+		return false
+	}
+	for i := range glyphs {
+		if m.glyphs[i] != glyphs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// drawGlyphShadow renders the shadow of laid out glyphs. The glyph coverage is rendered to a mask that is spread and blurred by the backend before rendering. Masks are cached, so unchanged text is only blurred once.
+func drawGlyphShadow(glyphs []glyphQuad, shadow Shadow) {
+	if len(glyphs) == 0 {
+		return
+	}
+	sigma := shadow.sigma()
+	if sigma < 0.25 && shadow.Spread == 0 {
+		// a sharp shadow is the offset text
+		for _, g := range glyphs {
+			renderPaintedShape(shadow.Color, g.shape(shadow.Offset))
+		}
+		return
+	}
+	bounds := Quad{Left: glyphs[0].quad.Left, Right: glyphs[0].quad.Right + glyphs[0].fauxBold, Top: glyphs[0].quad.Top, Bottom: glyphs[0].quad.Bottom}
+	for _, g := range glyphs[1:] {
+		bounds = Quad{Left: min32(bounds.Left, g.quad.Left), Right: max32(bounds.Right, g.quad.Right+g.fauxBold), Top: min32(bounds.Top, g.quad.Top), Bottom: max32(bounds.Bottom, g.quad.Bottom)}
+	}
+	margin := max32(shadow.Spread, 0) + 3*sigma + 1
+	bounds = Quad{Left: bounds.Left - margin, Right: bounds.Right + margin, Top: bounds.Top - margin, Bottom: bounds.Bottom + margin}
+
+	// the mask resolution follows the current transformation to keep shadows of zoomed text smooth
+	scale := transformScale(transform)
+	mask := glyphShadowMaskFor(glyphs, bounds, scale, shadow.Spread, sigma)
+	if mask == nil {
+		return
+	}
+
+	renderPaintedShape(shadow.Color, &shape{
+		kind: shapeString,
+		tex:  mask.target.Texture,
+		quad: Quad{
+			Left:   bounds.Left + shadow.Offset[0],
+			Right:  bounds.Left + shadow.Offset[0] + mask.size[0],
+			Top:    bounds.Top + shadow.Offset[1],
+			Bottom: bounds.Top + shadow.Offset[1] + mask.size[1],
+		},
+		uvTopLeft:     mgl32.Vec2{0, 0},
+		uvBottomRight: mgl32.Vec2{1, 1},
+	})
+}
+
+// glyphShadowMaskFor returns the cached mask of the glyphs inside bounds or creates a new one. Nil is returned for empty masks.
+func glyphShadowMaskFor(glyphs []glyphQuad, bounds Quad, scale, spread, sigma float32) *glyphShadowMask {
+	key := make([]glyphShadowGlyph, len(glyphs))
+	hash := uint64(14695981039346656037)
+	add := func(v uint32) {
+		hash ^= uint64(v)
+		hash *= 1099511628211
+	}
+	add(math.Float32bits(scale))
+	add(math.Float32bits(spread))
+	add(math.Float32bits(sigma))
+	// relative locations are quantized, so that rounding errors of moved text do not prevent reuse
+	quantize := func(v float32) float32 { return round32(v*64) / 64 }
+	for i, g := range glyphs {
+		key[i] = glyphShadowGlyph{font: g.font, r: g.r, quad: Quad{
+			Left:   quantize(g.quad.Left - bounds.Left),
+			Right:  quantize(g.quad.Right - bounds.Left),
+			Top:    quantize(g.quad.Top - bounds.Top),
+			Bottom: quantize(g.quad.Bottom - bounds.Top),
+		}, fauxBold: g.fauxBold}
+		add(uint32(g.r))
+		add(math.Float32bits(key[i].quad.Left))
+		add(math.Float32bits(key[i].quad.Top))
+	}
+
+	for _, mask := range glyphShadows[hash] {
+		if mask.matches(key, scale, spread, sigma) {
+			mask.used = true
+			return mask
+		}
+	}
+
+	if currentClipMaskMode != clipMaskNone {
+		// render targets are not available while drawing clip masks
+		return nil
+	}
+
+	// the filter passes cover a limited number of pixels, so large radii are applied to masks of lower resolution
+	maskScale := scale
+	if radius := abs32(spread) * maskScale; radius > maxShadowSpreadRadius {
+		maskScale *= maxShadowSpreadRadius / radius
+	}
+	if radius := 3 * sigma * maskScale; radius > maxShadowBlurRadius {
+		maskScale *= maxShadowBlurRadius / radius
+	}
+	width := int(ceil32((bounds.Right - bounds.Left) * maskScale))
+	height := int(ceil32((bounds.Bottom - bounds.Top) * maskScale))
+	if width <= 0 || height <= 0 {
+		return nil
+	}
+	if width > maxShadowMaskSize || height > maxShadowMaskSize {
+		maskScale *= float32(maxShadowMaskSize) / float32(maxInt(width, height))
+		width = minInt(int(ceil32((bounds.Right-bounds.Left)*maskScale)), maxShadowMaskSize)
+		height = minInt(int(ceil32((bounds.Bottom-bounds.Top)*maskScale)), maxShadowMaskSize)
+	}
+
+	target, err := renderGlyphShadowMask(glyphs, bounds, width, height, maskScale, spread, sigma)
+	if err != nil {
+		return nil
+	}
+
+	mask := &glyphShadowMask{
+		glyphs: key,
+		scale:  scale,
+		spread: spread,
+		sigma:  sigma,
+		target: target,
+		size:   mgl32.Vec2{float32(width) / maskScale, float32(height) / maskScale},
+		used:   true,
+	}
+	glyphShadows[hash] = append(glyphShadows[hash], mask)
+	return mask
+}
+
+// renderGlyphShadowMask renders the coverage of the glyphs to a render target of the given size. The mask is spread by a round dilation or erosion and blurred by a separable gaussian filter in subsequent passes.
+func renderGlyphShadowMask(glyphs []glyphQuad, bounds Quad, width, height int, maskScale, spread, sigma float32) (*glutil.RenderTarget, error) {
+	params := glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear)
+	mask, err := activeBackend.newRenderTarget(width, height, params)
+	if err != nil {
+		return nil, err
+	}
+	BeginTarget(mask)
+	// overlapping glyphs unite their coverage in the alpha channel
+	SetBlendMode(BlendPremultiplied)
+	SetTransform(mgl32.Scale2D(maskScale, maskScale).Mul3(mgl32.Translate2D(-bounds.Left, -bounds.Top)))
+	for _, g := range glyphs {
+		renderPaintedShape(White, g.shape(mgl32.Vec2{}))
+	}
+	EndTarget()
+
+	texelStep := mgl32.Vec2{1 / float32(width), 1 / float32(height)}
+	var passes []*shape
+	if spread != 0 {
+		passes = append(passes, &shape{kind: shapeSpreadMask, maskStep: texelStep, maskSpread: spread * maskScale})
+	}
+	if sigma > 0 {
+		passes = append(passes,
+			&shape{kind: shapeBlurMask, maskStep: mgl32.Vec2{texelStep[0], 0}, shadowSigma: sigma * maskScale},
+			&shape{kind: shapeBlurMask, maskStep: mgl32.Vec2{0, texelStep[1]}, shadowSigma: sigma * maskScale})
+	}
+	for _, pass := range passes {
+		filtered, err := activeBackend.newRenderTarget(width, height, params)
+		if err != nil {
+			mask.Destroy()
+			return nil, err
+		}
+		BeginTarget(filtered)
+		SetBlendMode(BlendReplace)
+		pass.tex = mask.Texture
+		pass.quad = Quad{Right: float32(width), Bottom: float32(height)}
+		pass.uvBottomRight = mgl32.Vec2{1, 1}
+		renderPaintedShape(White, pass)
+		EndTarget()
+		mask.Destroy()
+		mask = filtered
+	}
+	return mask, nil
+}
+
+// releaseUnusedGlyphShadows destroys all masks that have not been used during the current frame.
+func releaseUnusedGlyphShadows() {
+	for hash, masks := range glyphShadows {
+		n := 0
+		for _, mask := range masks {
+			if mask.used {
+				mask.used = false
+				masks[n] = mask
+				n++
+			} else {
+				mask.target.Destroy()
+			}
+		}
+		if n == 0 {
+			delete(glyphShadows, hash)
+		} else {
+			glyphShadows[hash] = masks[:n]
+		}
+	}
+}
+
+// releaseGlyphShadows destroys all cached masks.
+func releaseGlyphShadows() {
+	for hash, masks := range glyphShadows {
+		for _, mask := range masks {
+			mask.target.Destroy()
+		}
+		delete(glyphShadows, hash)
+	}
+}
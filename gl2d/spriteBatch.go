@@ -0,0 +1,218 @@
+package gl2d
+
+import (
+	"sort"
+
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// SpriteSortMode denotes the order in which the sprites of a batch are rendered.
+type SpriteSortMode int
+
+const (
+	// SpriteSortDepth renders sprites with lower depth first. Sprites of equal depth keep their order.
+	SpriteSortDepth SpriteSortMode = iota
+	// SpriteSortDepthTexture renders sprites with lower depth first and groups sprites of equal depth by texture, which reduces the number of draw calls but does not preserve their order.
+	SpriteSortDepthTexture
+	// SpriteSortDeferred renders sprites in the order they have been added.
+	SpriteSortDeferred
+)
+
+// Sprite denotes a textured quad of a sprite batch.
+type Sprite struct {
+	// Region denotes the atlas image of the sprite. Texture and SrcUV are used if Region is nil.
+	Region  *glutil.AtlasRegion
+	Texture *glutil.Texture
+	// SrcUV defaults to the full texture if empty.
+	SrcUV Quad
+	// Pos denotes the location of the origin.
+	Pos mgl32.Vec2
+	// Size defaults to the pixel size of the region or texture. Trimmed regions cover the corresponding part of the sprite.
+	Size mgl32.Vec2
+	// Origin denotes the pivot for positioning, rotation and scaling relative to the sprite size, e.g. {0.5, 0.5} for the center.
+	Origin mgl32.Vec2
+	// Rotation is given in radians and positive values rotate clockwise on screen.
+	Rotation float32
+	// Scale defaults to {1, 1}.
+	Scale mgl32.Vec2
+	// Tint is multiplied with the texture colors. Defaults to White.
+	Tint         Color
+	FlipX, FlipY bool
+	Depth        float32
+}
+
+// SpriteBatch collects sprites and renders them sorted by depth and texture, so that consecutive sprites of the same atlas page are drawn with a single draw call.
+type SpriteBatch struct {
+	SortMode SpriteSortMode
+	sprites  []Sprite
+	order    []int
+}
+
+// NewSpriteBatch returns an empty sprite batch.
+func NewSpriteBatch() *SpriteBatch {
+	return &SpriteBatch{}
+}
+
+// Begin removes all sprites of the previous frame.
+func (b *SpriteBatch) Begin() {
+	b.sprites = b.sprites[:0]
+}
+
+// Add appends a sprite that is rendered on End.
+func (b *SpriteBatch) Add(s Sprite) {
+	b.sprites = append(b.sprites, s)
+}
+
+// AddRegion appends an unrotated atlas image at pos.
+func (b *SpriteBatch) AddRegion(region *glutil.AtlasRegion, pos mgl32.Vec2, depth float32) {
+	b.Add(Sprite{Region: region, Pos: pos, Depth: depth})
+}
+
+// Len returns the number of sprites that have been added since Begin.
+func (b *SpriteBatch) Len() int {
+	return len(b.sprites)
+}
+
+// End renders all sprites with the current transformation, clipping and blend mode.
+func (b *SpriteBatch) End() {
+	b.order = b.order[:0]
+	for i := range b.sprites {
+		b.order = append(b.order, i)
+	}
+	switch b.SortMode {
+	case SpriteSortDepth:
+		sort.SliceStable(b.order, func(i, j int) bool {
+			return b.sprites[b.order[i]].Depth < b.sprites[b.order[j]].Depth
+		})
+	case SpriteSortDepthTexture:
+		sort.SliceStable(b.order, func(i, j int) bool {
+			si, sj := &b.sprites[b.order[i]], &b.sprites[b.order[j]]
+			if si.Depth != sj.Depth {
+				return si.Depth < sj.Depth
+			}
+			return textureID(si.texture()) < textureID(sj.texture())
+		})
+	}
+
+	PushTransform()
+	defer PopTransform()
+	base := transform
+	for _, i := range b.order {
+		s := &b.sprites[i]
+		tex := s.texture()
+		if tex == nil {
+			continue
+		}
+		transform = base.Mul3(s.transform())
+		renderShape(&shape{
+			kind:          shapeImage,
+			quad:          s.quad(),
+			color:         s.tint(),
+			tex:           tex,
+			uvTopLeft:     s.uvTopLeft(),
+			uvBottomRight: s.uvBottomRight(),
+		})
+	}
+}
+
+func (s *Sprite) texture() *glutil.Texture {
+	if s.Region != nil {
+		return s.Region.Texture()
+	}
+	return s.Texture
+}
+
+// textureID returns the OpenGL name of tex or 0 for sprites without texture, which are skipped on rendering.
+func textureID(tex *glutil.Texture) uint32 {
+	if tex == nil {
+		return 0
+	}
+	return tex.Tex
+}
+
+func (s *Sprite) size() mgl32.Vec2 {
+	if s.Size[0] != 0 || s.Size[1] != 0 {
+		return s.Size
+	}
+	if s.Region != nil {
+		return mgl32.Vec2{float32(s.Region.SourceSize.X), float32(s.Region.SourceSize.Y)}
+	}
+	uv := s.srcUV()
+	return mgl32.Vec2{abs32(uv.Right-uv.Left) * float32(s.Texture.Width), abs32(uv.Bottom-uv.Top) * float32(s.Texture.Height)}
+}
+
+// quad returns the textured part of the sprite, which is smaller than the sprite size for trimmed regions.
+func (s *Sprite) quad() Quad {
+	size := s.size()
+	if s.Region == nil || !s.Region.IsTrimmed() {
+		return Quad{Right: size[0], Bottom: size[1]}
+	}
+	topLeft, bottomRight := s.Region.TrimRect()
+	if s.FlipX {
+		topLeft[0], bottomRight[0] = 1-bottomRight[0], 1-topLeft[0]
+	}
+	if s.FlipY {
+		topLeft[1], bottomRight[1] = 1-bottomRight[1], 1-topLeft[1]
+	}
+	return Quad{Left: topLeft[0] * size[0], Top: topLeft[1] * size[1], Right: bottomRight[0] * size[0], Bottom: bottomRight[1] * size[1]}
+}
+
+func (s *Sprite) tint() Color {
+	if s.Tint == (Color{}) {
+		return White
+	}
+	return s.Tint
+}
+
+func (s *Sprite) srcUV() Quad {
+	if s.Region != nil {
+		topLeft, bottomRight := s.Region.UV()
+		return Quad{Left: topLeft[0], Top: topLeft[1], Right: bottomRight[0], Bottom: bottomRight[1]}
+	}
+	if s.SrcUV == (Quad{}) {
+		return Quad{Right: 1, Bottom: 1}
+	}
+	return s.SrcUV
+}
+
+// uvTopLeft and uvBottomRight return the texture coordinates of the sprite corners, which are swapped for flipped sprites.
+func (s *Sprite) uvTopLeft() mgl32.Vec2 {
+	uv := s.srcUV()
+	topLeft := mgl32.Vec2{uv.Left, uv.Top}
+	if s.FlipX {
+		topLeft[0] = uv.Right
+	}
+	if s.FlipY {
+		topLeft[1] = uv.Bottom
+	}
+	return topLeft
+}
+
+func (s *Sprite) uvBottomRight() mgl32.Vec2 {
+	uv := s.srcUV()
+	bottomRight := mgl32.Vec2{uv.Right, uv.Bottom}
+	if s.FlipX {
+		bottomRight[0] = uv.Left
+	}
+	if s.FlipY {
+		bottomRight[1] = uv.Top
+	}
+	return bottomRight
+}
+
+// transform returns the mapping from the sprite quad with top-left corner at the origin to local space.
+func (s *Sprite) transform() mgl32.Mat3 {
+	scale := s.Scale
+	if scale[0] == 0 && scale[1] == 0 {
+		scale = mgl32.Vec2{1, 1}
+	}
+	size := s.size()
+	m := mgl32.Translate2D(s.Pos[0], s.Pos[1])
+	if s.Rotation != 0 {
+		m = m.Mul3(mgl32.HomogRotate2D(s.Rotation))
+	}
+	m = m.Mul3(mgl32.Scale2D(scale[0], scale[1]))
+	return m.Mul3(mgl32.Translate2D(-s.Origin[0]*size[0], -s.Origin[1]*size[1]))
+}
@@ -0,0 +1,107 @@
+package gl2d
+
+import (
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// canvasState holds all state that is replaced while rendering to a render target.
+type canvasState struct {
+	insideFrame               bool
+	canvasWidth, canvasHeight int
+	projectionMatrix          mgl32.Mat4
+	transform                 mgl32.Mat3
+	transformStack            []mgl32.Mat3
+	clipRect                  Quad
+	clipRectStack             []Quad
+	clipMaskStack             []clipMask
+	blendMode                 BlendMode
+	blendModeStack            []BlendMode
+}
+
+var (
+	targetStack []canvasState
+)
+
+// NewRenderTarget returns a new render target of the given size that is suitable for the active backend.
+func NewRenderTarget(width, height int, params glutil.TextureParameters) (*glutil.RenderTarget, error) {
+	return activeBackend.newRenderTarget(width, height, params)
+}
+
+// BeginTarget redirects all subsequent rendering to the render target until EndTarget is called. The current transformation, clip rectangles, clip masks and blend mode are saved and reset for the render target.
+//
+// BeginTarget can be called between Begin and End to compose a frame from cached parts, as well as outside of a frame. Render targets can be nested. The content of the render target is kept, call Clear to reset it. Afterwards, the texture of the render target can be drawn upright using DrawImage.
+//
+// The render target must be obtained from NewRenderTarget, BeginTarget panics for render targets the active backend cannot render into.
+func BeginTarget(target *glutil.RenderTarget) {
+	if !initialized {
+		panic("need to call gl2d.Init before gl2d.BeginTarget")
+	}
+	if currentClipMaskMode != clipMaskNone {
+		panic("BeginTarget cannot be called while drawing a clip mask")
+	}
+	if !activeBackend.supportsTarget(target) {
+		panic("BeginTarget requires a render target created by gl2d.NewRenderTarget of the active backend")
+	}
+
+	targetStack = append(targetStack, canvasState{
+		insideFrame:      insideFrame,
+		canvasWidth:      canvasWidth,
+		canvasHeight:     canvasHeight,
+		projectionMatrix: projectionMatrix,
+		transform:        transform,
+		transformStack:   append([]mgl32.Mat3(nil), transformStack...),
+		clipRect:         clipRect,
+		clipRectStack:    append([]Quad(nil), clipRectStack...),
+		clipMaskStack:    append([]clipMask(nil), clipMaskStack...),
+		blendMode:        blendMode,
+		blendModeStack:   append([]BlendMode(nil), blendModeStack...),
+	})
+
+	activeBackend.pushTarget(target)
+	if insideFrame {
+		activeBackend.setBlendMode(BlendNormal)
+	} else {
+		activeBackend.begin(target.Width(), target.Height())
+	}
+	// the first row of a texture is located at the top of the image, but the bottom of a framebuffer
+	startCanvas(target.Width(), target.Height(), mgl32.Ortho2D(0, float32(target.Width()), 0, float32(target.Height())))
+	insideFrame = true
+}
+
+// EndTarget finishes rendering to the render target activated by the last call to BeginTarget and restores the previous state.
+func EndTarget() {
+	if len(targetStack) == 0 {
+		panic("EndTarget called without matching BeginTarget")
+	}
+	if currentClipMaskMode != clipMaskNone {
+		panic("EndTarget cannot be called while drawing a clip mask")
+	}
+
+	state := targetStack[len(targetStack)-1]
+	targetStack = targetStack[:len(targetStack)-1]
+
+	if !state.insideFrame {
+		activeBackend.end()
+	}
+	activeBackend.popTarget()
+
+	insideFrame = state.insideFrame
+	canvasWidth, canvasHeight = state.canvasWidth, state.canvasHeight
+	projectionMatrix = state.projectionMatrix
+	transform = state.transform
+	transformStack = append(transformStack[:0], state.transformStack...)
+	clipRect = state.clipRect
+	clipRectStack = append(clipRectStack[:0], state.clipRectStack...)
+	clipMaskStack = append(clipMaskStack[:0], state.clipMaskStack...)
+	blendMode = state.blendMode
+	blendModeStack = append(blendModeStack[:0], state.blendModeStack...)
+
+	if insideFrame {
+		activeBackend.setBlendMode(blendMode)
+		activeBackend.setClipMask(len(clipMaskStack), clipMaskNone)
+	} else {
+		releaseFrameTextures()
+	}
+}
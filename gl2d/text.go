@@ -10,15 +10,15 @@ import (
 	"io/ioutil"
 	"math"
 	"os"
-	"strings"
 
 	"github.com/sbreitf1/go-gl-lib/glutil"
 
-	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
 	"golang.org/x/image/math/fixed"
 )
 
@@ -27,8 +27,7 @@ const (
 )
 
 var (
-	defaultFont    *Font
-	drawStringProg uint32
+	defaultFont *Font
 )
 
 type runeRect struct {
@@ -40,15 +39,30 @@ type BitmapFont struct {
 	image      *image.RGBA
 	runeRects  map[rune]runeRect
 	lineHeight float32
+	ascent     float32
+	descent    float32
 	runeWidths map[rune]float32
 	kernings   map[rune]map[rune]float32
+	// sdfSpread denotes the distance in pixels covered by signed distance fields. Zero for fonts with coverage images
+	sdfSpread float32
+	// runeBounds denote the ink box of each rune relative to the pen position on the baseline
+	runeBounds map[rune]Quad
+	// runeOffsets denote the location of rune images relative to the pen position at the top of a line. Rune images without offset cover the advance and line height
+	runeOffsets map[rune]mgl32.Vec2
 }
 
 type bitmapFontMetaData struct {
 	RuneRects  map[rune][4]float32       `json:",omitempty"`
 	LineHeight float32                   `json:",omitempty"`
+	Ascent     float32                   `json:",omitempty"`
+	Descent    float32                   `json:",omitempty"`
 	RuneWidths map[rune]float32          `json:",omitempty"`
 	Kernings   map[rune]map[rune]float32 `json:",omitempty"`
+	SDFSpread  float32                   `json:",omitempty"`
+	// RuneOffsets are only present for imported fonts, whose rune images are cropped to the glyph
+	RuneOffsets map[rune][2]float32 `json:",omitempty"`
+	// RuneBounds denote the left, top, right and bottom edge of the ink box relative to the pen position on the baseline
+	RuneBounds map[rune][4]float32 `json:",omitempty"`
 }
 
 // Export writes the font image and meta data to two separate files.
@@ -63,11 +77,7 @@ func (f *BitmapFont) Export(imageFile, metaFile string) error {
 		return err
 	}
 
-	runeRects := make(map[rune][4]float32)
-	for r, rect := range f.runeRects {
-		runeRects[r] = [4]float32{rect.topLeft[0], rect.topLeft[1], rect.bottomRight[0], rect.bottomRight[1]}
-	}
-	data, err := json.Marshal(&bitmapFontMetaData{runeRects, f.lineHeight, f.runeWidths, f.kernings})
+	data, err := f.metaData()
 	if err != nil {
 		return err
 	}
@@ -75,23 +85,86 @@ func (f *BitmapFont) Export(imageFile, metaFile string) error {
 	return ioutil.WriteFile(metaFile, data, os.ModePerm)
 }
 
+// metaData returns the JSON encoded meta data of the font.
+func (f *BitmapFont) metaData() ([]byte, error) {
+	runeRects := make(map[rune][4]float32)
+	for r, rect := range f.runeRects {
+		runeRects[r] = [4]float32{rect.topLeft[0], rect.topLeft[1], rect.bottomRight[0], rect.bottomRight[1]}
+	}
+	var runeOffsets map[rune][2]float32
+	if len(f.runeOffsets) > 0 {
+		runeOffsets = make(map[rune][2]float32)
+		for r, offset := range f.runeOffsets {
+			runeOffsets[r] = offset
+		}
+	}
+	var runeBounds map[rune][4]float32
+	if len(f.runeBounds) > 0 {
+		runeBounds = make(map[rune][4]float32)
+		for r, bounds := range f.runeBounds {
+			runeBounds[r] = [4]float32{bounds.Left, bounds.Top, bounds.Right, bounds.Bottom}
+		}
+	}
+	return json.Marshal(&bitmapFontMetaData{
+		RuneRects:   runeRects,
+		LineHeight:  f.lineHeight,
+		Ascent:      f.ascent,
+		Descent:     f.descent,
+		RuneWidths:  f.runeWidths,
+		Kernings:    f.kernings,
+		SDFSpread:   f.sdfSpread,
+		RuneOffsets: runeOffsets,
+		RuneBounds:  runeBounds,
+	})
+}
+
 // Font denotes an OpenGL font represented by pre-rendered rune images.
 type Font struct {
 	texture    *glutil.Texture
+	image      *image.RGBA
 	runeRects  map[rune]runeRect
 	lineHeight float32
+	// ascent denotes the distance from the top of a line to the baseline and descent the distance from the baseline to the lowest descenders
+	ascent     float32
+	descent    float32
 	runeWidths map[rune]float32
 	kernings   map[rune]map[rune]float32
+	// cache rasterizes runes that are not pre-rendered to texture. Nil for fonts without font face
+	cache       *glyphCache
+	sdfSpread   float32
+	runeOffsets map[rune]mgl32.Vec2
+	runeBounds  map[rune]Quad
+	// outlines of fonts loaded from files reveal missing glyphs, which font faces replace by the notdef glyph
+	outlines *sfnt.Font
+	buffer   sfnt.Buffer
 }
 
-// Destroy released all ressources of this OpenGL font.
-func (f *Font) Destroy() {
-	gl.DeleteTextures(1, &f.texture.Tex)
+// FontOptions configures fonts that are loaded from TrueType or OpenType data.
+type FontOptions struct {
+	// Size denotes the font size in points. Defaults to 12.
+	Size float64
+	// DPI denotes the screen resolution. Defaults to 72, so that one point equals one pixel.
+	DPI float64
+	// Hinting denotes how glyph outlines are aligned to the pixel grid.
+	Hinting font.Hinting
+	// PageSize denotes the maximum width and height of a glyph texture page in pixels. Defaults to DefaultGlyphPageSize.
+	PageSize int
+	// MaxPages denotes the number of glyph texture pages. Rarely used glyphs are evicted when all pages are full. Defaults to DefaultGlyphMaxPages.
+	MaxPages int
+	// SDF stores glyphs as signed distance fields that stay sharp at any scale and support outlines.
+	SDF bool
+	// SDFSpread denotes the distance in pixels of the unscaled font that is covered by the distance field on both sides of the glyph edges and limits the outline width. Defaults to a sixth of the font size.
+	SDFSpread float32
 }
 
-func (f *Font) splitLines(str string) []string {
-	//TODO remove or replace unknown chars (keep control chars \n and \t)
-	return strings.Split(strings.ReplaceAll(strings.ReplaceAll(str, "\r\n", "\n"), "\r", "\n"), "\n")
+// Destroy released all ressources of this OpenGL font.
+func (f *Font) Destroy() {
+	if f.texture != nil {
+		f.texture.Destroy()
+	}
+	if f.cache != nil {
+		f.cache.destroy()
+	}
 }
 
 // LineHeight denotes the vertical distance between two baselines
@@ -99,17 +172,144 @@ func (f *Font) LineHeight() float32 {
 	return f.lineHeight
 }
 
+// Ascent returns the distance from the top of a line to the baseline. Falls back to the line height for fonts without metrics.
+func (f *Font) Ascent() float32 {
+	if f.ascent > 0 {
+		return f.ascent
+	}
+	return f.lineHeight
+}
+
+// Descent returns the distance from the baseline to the lowest descenders. Falls back to the space between baseline and line height for fonts without metrics.
+func (f *Font) Descent() float32 {
+	if f.descent > 0 {
+		return f.descent
+	}
+	return max32(0, f.lineHeight-f.Ascent())
+}
+
+// GlyphMetrics denotes the measures of a single rune.
+type GlyphMetrics struct {
+	// Advance denotes the horizontal distance from the pen position to the pen position of the next rune.
+	Advance float32
+	// Bounds denotes the ink box relative to the pen position on the baseline. Top is negative for glyphs above the baseline.
+	Bounds Quad
+}
+
+// LeftBearing returns the distance from the pen position to the left edge of the ink box.
+func (m GlyphMetrics) LeftBearing() float32 {
+	return m.Bounds.Left
+}
+
+// RightBearing returns the distance from the right edge of the ink box to the pen position of the next rune.
+func (m GlyphMetrics) RightBearing() float32 {
+	return m.Advance - m.Bounds.Right
+}
+
+// GlyphMetrics returns the advance and ink box of a rune. Returns false if the rune is not supported.
+func (f *Font) GlyphMetrics(r rune) (GlyphMetrics, bool) {
+	advance, ok := f.advance(r)
+	if !ok {
+		return GlyphMetrics{}, false
+	}
+	m := GlyphMetrics{Advance: advance}
+	if bounds, ok := f.runeBounds[r]; ok {
+		m.Bounds = bounds
+	} else if offset, ok := f.runeOffsets[r]; ok {
+		rect := f.runeRects[r]
+		size := f.image.Bounds().Size()
+		m.Bounds = Quad{
+			Left:   offset[0],
+			Top:    offset[1] - f.Ascent(),
+			Right:  offset[0] + (rect.bottomRight[0]-rect.topLeft[0])*float32(size.X),
+			Bottom: offset[1] - f.Ascent() + (rect.bottomRight[1]-rect.topLeft[1])*float32(size.Y),
+		}
+	} else if _, ok := f.runeWidths[r]; ok {
+		// pre-rendered runes without bounds cover their cell
+		m.Bounds = Quad{Right: advance, Top: -f.Ascent(), Bottom: f.lineHeight - f.Ascent()}
+	} else if f.cache != nil {
+		m.Bounds, _ = f.cache.bounds(r)
+	}
+	return m, true
+}
+
 // RuneSize returns the size of the given rune.
 func (f *Font) RuneSize(r rune) (float32, float32) {
-	w, ok := f.runeWidths[r]
+	w, ok := f.advance(r)
 	if !ok {
 		return 0, 0
 	}
 	return w, f.lineHeight
 }
 
+// IsSDF returns true when the glyphs are stored as signed distance fields.
+func (f *Font) IsSDF() bool {
+	return f.sdfSpread > 0
+}
+
+// HasRune returns true when the font is able to render the given rune.
+func (f *Font) HasRune(r rune) bool {
+	if f.outlines != nil {
+		if _, ok := f.runeWidths[r]; ok {
+			return true
+		}
+		i, err := f.outlines.GlyphIndex(&f.buffer, r)
+		return err == nil && i != 0
+	}
+	_, ok := f.advance(r)
+	return ok
+}
+
+func (f *Font) advance(r rune) (float32, bool) {
+	if w, ok := f.runeWidths[r]; ok {
+		return w, true
+	}
+	if f.cache != nil {
+		return f.cache.advance(r)
+	}
+	return 0, false
+}
+
+// glyph returns the image of a rune. Runes that are not pre-rendered are rasterized on first use.
+func (f *Font) glyph(r rune) (glyph, bool) {
+	if rect, ok := f.runeRects[r]; ok {
+		w, h := f.RuneSize(r)
+		size := f.image.Bounds().Size()
+		g := glyph{
+			tex: f.texture,
+			src: Quad{
+				Left:   rect.topLeft[0] * float32(size.X),
+				Right:  rect.bottomRight[0] * float32(size.X),
+				Top:    rect.topLeft[1] * float32(size.Y),
+				Bottom: rect.bottomRight[1] * float32(size.Y),
+			},
+			size:      mgl32.Vec2{w, h},
+			advance:   w,
+			sdfSpread: f.sdfSpread,
+		}
+		if offset, ok := f.runeOffsets[r]; ok {
+			// imported rune images are cropped to the glyph and map one pixel to one unit
+			g.offset = offset
+			g.size = mgl32.Vec2{g.src.Right - g.src.Left, g.src.Bottom - g.src.Top}
+		} else if f.sdfSpread > 0 {
+			// distance fields are padded on all sides and map one pixel to one unit
+			padding := sdfPadding(f.sdfSpread)
+			g.offset = mgl32.Vec2{-padding, -padding}
+			g.size = mgl32.Vec2{g.src.Right - g.src.Left, g.src.Bottom - g.src.Top}
+		}
+		return g, true
+	}
+	if f.cache != nil {
+		return f.cache.glyph(r)
+	}
+	return glyph{}, false
+}
+
 // Kern returns the spacing depending on the previous rune.
 func (f *Font) Kern(r1, r2 rune) float32 {
+	if f.cache != nil {
+		return f.cache.kern(r1, r2)
+	}
 	if f.kernings == nil {
 		return 0
 	}
@@ -130,13 +330,6 @@ func DefaultFont() *Font {
 
 func initText() error {
 	var err error
-	if drawStringProg, err = glutil.AssembleShaderFromSource(glutil.ShaderSource{
-		Vertex:   drawStringVertexShader,
-		Fragment: drawStringFragmentShader,
-	}); err != nil {
-		return err
-	}
-
 	if defaultFont, err = NewFontFromFace(basicfont.Face7x13); err != nil {
 		return err
 	}
@@ -144,7 +337,7 @@ func initText() error {
 }
 
 func terminateText() {
-	gl.DeleteProgram(drawStringProg)
+	releaseGlyphShadows()
 	defaultFont.Destroy()
 	defaultFont = nil
 }
@@ -159,9 +352,23 @@ func float32ToInt26(f float32) fixed.Int26_6 {
 
 // NewBitmapFontFromFace renders font face to a new BitmapFont.
 func NewBitmapFontFromFace(face font.Face) (*BitmapFont, error) {
+	return generateBitmapFont(faceBitmapFontSource(face, 0))
+}
+
+// NewSDFBitmapFontFromFace renders font face to a new BitmapFont that stores signed distance fields covering spread pixels on both sides of the glyph edges.
+func NewSDFBitmapFontFromFace(face font.Face, spread float32) (*BitmapFont, error) {
+	if spread <= 0 {
+		return nil, fmt.Errorf("spread must be positive")
+	}
+	return generateBitmapFont(faceBitmapFontSource(face, spread))
+}
+
+func faceBitmapFontSource(face font.Face, sdfSpread float32) bitmapFontSource {
 	m := face.Metrics()
-	return generateBitmapFont(bitmapFontSource{
+	return bitmapFontSource{
 		LineHeight: int26ToFloat32(m.Height),
+		Ascent:     int26ToFloat32(m.Ascent),
+		Descent:    int26ToFloat32(m.Descent),
 		RuneWidth: func(r rune) (float32, bool) {
 			if w, ok := face.GlyphAdvance(r); ok {
 				return int26ToFloat32(w), true
@@ -180,14 +387,29 @@ func NewBitmapFontFromFace(face font.Face) (*BitmapFont, error) {
 		Kern: func(r1, r2 rune) float32 {
 			return int26ToFloat32(face.Kern(r1, r2))
 		},
-	})
+		RuneBounds: func(r rune) (Quad, bool) {
+			return faceGlyphBounds(face, r, 1)
+		},
+		SDFSpread: sdfSpread,
+	}
 }
 
 type bitmapFontSource struct {
 	LineHeight float32
+	Ascent     float32
+	Descent    float32
 	RuneWidth  func(r rune) (float32, bool)
 	RenderRune func(r rune, dst draw.Image, x, y float32)
 	Kern       func(r1, r2 rune) float32
+	// RuneBounds returns the ink box of a rune relative to the pen position on the baseline
+	RuneBounds func(r rune) (Quad, bool)
+	// SDFSpread enables signed distance fields if positive
+	SDFSpread float32
+}
+
+// sdfPadding returns the number of pixels that are added on each side of a glyph to contain the outer part of the distance field.
+func sdfPadding(spread float32) float32 {
+	return ceil32(spread)
 }
 
 // NewBitmapFontFromFace renders font face to a new BitmapFont.
@@ -196,6 +418,10 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 
 	maxRuneHeight := src.LineHeight
 	maxRuneWidth := float32(0)
+	padding := 0
+	if src.SDFSpread > 0 {
+		padding = int(sdfPadding(src.SDFSpread))
+	}
 
 	// measure runes
 	for _, r := range defaultAlphabet {
@@ -205,14 +431,21 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 			}
 
 			//TODO draw rune directly to texture image in next loop
-			img := image.NewRGBA(image.Rect(0, 0, int(ceil32(width)), int(ceil32(maxRuneHeight))))
-			src.RenderRune(r, img, 0, 0)
+			img := image.NewRGBA(image.Rect(0, 0, int(ceil32(width))+2*padding, int(ceil32(maxRuneHeight))+2*padding))
+			src.RenderRune(r, img, float32(padding), float32(padding))
+			if src.SDFSpread > 0 {
+				applyDistanceField(img, img.Bounds(), src.SDFSpread)
+			}
 			runeImages[r] = img
 		} else {
 			logrus.Warnf("rune %v not supported by selected font", r)
 		}
 	}
 
+	// cells are measured including the padding of distance fields
+	maxRuneWidth += float32(2 * padding)
+	maxRuneHeight += float32(2 * padding)
+
 	// first guess of runes per line
 	runesPerLine := int(math.Ceil(math.Sqrt(float64(len([]rune(defaultAlphabet))))))
 	minTexWidth := 1 + (int(ceil32(maxRuneWidth+1)) * runesPerLine)
@@ -231,6 +464,8 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 		runeRects:  make(map[rune]runeRect),
 		runeWidths: make(map[rune]float32),
 		kernings:   make(map[rune]map[rune]float32),
+		sdfSpread:  src.SDFSpread,
+		runeBounds: make(map[rune]Quad),
 	}
 	// assemble texture image that holds all rune images at different locations
 	line := 0
@@ -256,6 +491,9 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 			} else {
 				font.runeWidths[r] = 0
 			}
+			if bounds, ok := src.RuneBounds(r); ok {
+				font.runeBounds[r] = bounds
+			}
 			// save rune pair specific kernings
 			kernMap := map[rune]float32(nil)
 			for _, rNext := range defaultAlphabet {
@@ -282,7 +520,9 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 		}
 	}
 
-	font.lineHeight = maxRuneHeight
+	font.lineHeight = src.LineHeight
+	font.ascent = src.Ascent
+	font.descent = src.Descent
 	if len(font.kernings) == 0 {
 		font.kernings = nil
 	}
@@ -304,7 +544,7 @@ func generateBitmapFont(src bitmapFontSource) (*BitmapFont, error) {
 // NewFontFromBitmapFont transfers the bitmap font image data to graphics memory.
 func NewFontFromBitmapFont(font *BitmapFont) (*Font, error) {
 	// transfer texture image to video memory
-	tex, err := glutil.TextureFromRGBA(font.image, glutil.TextureParameters{
+	tex, err := activeBackend.newTexture(font.image, glutil.TextureParameters{
 		WrapS:     glutil.TextureWrapRepeat,
 		WrapT:     glutil.TextureWrapRepeat,
 		MinFilter: glutil.TextureFilterLinear,
@@ -315,22 +555,132 @@ func NewFontFromBitmapFont(font *BitmapFont) (*Font, error) {
 	}
 
 	return &Font{
-		texture:    tex,
-		runeRects:  font.runeRects,
-		lineHeight: font.lineHeight,
-		runeWidths: font.runeWidths,
-		kernings:   font.kernings,
+		texture:     tex,
+		image:       font.image,
+		runeRects:   font.runeRects,
+		lineHeight:  font.lineHeight,
+		ascent:      font.ascent,
+		descent:     font.descent,
+		runeWidths:  font.runeWidths,
+		kernings:    font.kernings,
+		sdfSpread:   font.sdfSpread,
+		runeOffsets: font.runeOffsets,
+		runeBounds:  font.runeBounds,
 	}, nil
 }
 
-// NewFontFromFace prepares an OpenGL font from the given font face.
+// NewFontFromFace prepares an OpenGL font from the given font face. Runes of the default alphabet are pre-rendered, all other runes are rasterized on first use.
 func NewFontFromFace(face font.Face) (*Font, error) {
 	bitmapFont, err := NewBitmapFontFromFace(face)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewFontFromBitmapFont(bitmapFont)
+	f, err := NewFontFromBitmapFont(bitmapFont)
+	if err != nil {
+		return nil, err
+	}
+	f.cache = newGlyphCache(face, false, 0, 0)
+	return f, nil
+}
+
+// NewSDFFontFromFace prepares an OpenGL font from the given font face that stores glyphs as signed distance fields covering spread pixels on both sides of the glyph edges. Runes of the default alphabet are pre-rendered, all other runes are rasterized on first use.
+func NewSDFFontFromFace(face font.Face, spread float32) (*Font, error) {
+	bitmapFont, err := NewSDFBitmapFontFromFace(face, spread)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := NewFontFromBitmapFont(bitmapFont)
+	if err != nil {
+		return nil, err
+	}
+	f.cache = newGlyphCache(face, false, 0, 0)
+	f.cache.enableSDF(spread, 1)
+	return f, nil
+}
+
+// NewCachedFontFromFace prepares an OpenGL font that rasterizes all runes of the font face on first use. Only PageSize, MaxPages, SDF and SDFSpread of opts are respected. The default SDFSpread is derived from the line height.
+func NewCachedFontFromFace(face font.Face, opts *FontOptions) *Font {
+	var actualOpts FontOptions
+	if opts != nil {
+		actualOpts = *opts
+	}
+	if actualOpts.SDF && actualOpts.SDFSpread <= 0 {
+		actualOpts.SDFSpread = int26ToFloat32(face.Metrics().Height) / 6
+	}
+	return newCachedFont(face, false, 1, &actualOpts)
+}
+
+// newCachedFont prepares a font whose glyphs are rasterized by the given face. unitScale denotes the font size in pixels per pixel of the face, which allows to rasterize distance fields at higher resolution.
+func newCachedFont(face font.Face, closeFace bool, unitScale float32, opts *FontOptions) *Font {
+	f := &Font{
+		lineHeight: unitScale * int26ToFloat32(face.Metrics().Height),
+		ascent:     unitScale * int26ToFloat32(face.Metrics().Ascent),
+		descent:    unitScale * int26ToFloat32(face.Metrics().Descent),
+		cache:      newGlyphCache(face, closeFace, opts.PageSize, opts.MaxPages),
+	}
+	if opts.SDF {
+		f.sdfSpread = opts.SDFSpread
+		f.cache.enableSDF(opts.SDFSpread/unitScale, unitScale)
+	}
+	return f
+}
+
+// NewFontFromData loads a TrueType or OpenType font from memory. Runes are rasterized on first use.
+func NewFontFromData(data []byte, opts *FontOptions) (*Font, error) {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse font: %s", err.Error())
+	}
+
+	var actualOpts FontOptions
+	if opts != nil {
+		actualOpts = *opts
+	}
+	if actualOpts.Size == 0 {
+		actualOpts.Size = 12
+	}
+	if actualOpts.DPI == 0 {
+		actualOpts.DPI = 72
+	}
+
+	faceOpts := &opentype.FaceOptions{
+		Size:    actualOpts.Size,
+		DPI:     actualOpts.DPI,
+		Hinting: actualOpts.Hinting,
+	}
+	unitScale := float32(1)
+	if actualOpts.SDF {
+		pixelSize := float32(actualOpts.Size * actualOpts.DPI / 72)
+		if actualOpts.SDFSpread <= 0 {
+			actualOpts.SDFSpread = pixelSize / 6
+		}
+		// small fonts are rasterized at a higher resolution to preserve the glyph shapes in the distance field
+		if pixelSize < sdfMinRasterSize {
+			unitScale = pixelSize / sdfMinRasterSize
+			faceOpts.Size /= float64(unitScale)
+			// hinting aligns outlines to the grid of the larger face
+			faceOpts.Hinting = font.HintingNone
+		}
+	}
+
+	face, err := opentype.NewFace(f, faceOpts)
+	if err != nil {
+		return nil, fmt.Errorf("create font face: %s", err.Error())
+	}
+	cachedFont := newCachedFont(face, true, unitScale, &actualOpts)
+	cachedFont.outlines = f
+	return cachedFont, nil
+}
+
+// NewFontFromFile loads a TrueType (.ttf) or OpenType (.otf) font file. Runes are rasterized on first use.
+func NewFontFromFile(file string, opts *FontOptions) (*Font, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read font file: %s", err.Error())
+	}
+	return NewFontFromData(data, opts)
 }
 
 func roundUpToPowerOfTwo(val int) int {
@@ -355,10 +705,34 @@ func roundUpToPowerOfTwo(val int) int {
 
 // DrawStringOptions can be used to overwrite string rendering behaviour.
 type DrawStringOptions struct {
-	//TODO center lines
 	TabSpaces int
 	Scale     float32
 	RoundPos  bool
+	// Paint replaces the color of DrawString if set.
+	Paint Paint
+	// Shadows are drawn beneath the text in the given order, e.g. to add a drop shadow or glow.
+	Shadows []Shadow
+	// OutlineWidth denotes the width of an outline around the glyphs in local units. Only supported by SDF fonts and limited by their spread.
+	OutlineWidth float32
+	OutlineColor Color
+	// MaxWidth denotes the width of the layout box. Lines are aligned inside the box and wrapped or truncated when they exceed it. Zero disables the limit.
+	MaxWidth float32
+	// MaxHeight denotes the height of the layout box for vertical alignment. Lines that do not fit are omitted. Zero disables the limit.
+	MaxHeight float32
+	// Wrap breaks lines exceeding MaxWidth between words, or between runes when a word does not fit into a line.
+	Wrap          bool
+	Align         TextAlign
+	VerticalAlign VerticalAlign
+	// LineSpacing scales the distance between two baselines. Defaults to 1.
+	LineSpacing float32
+	// MaxLines limits the number of lines. Zero disables the limit.
+	MaxLines int
+	// BaselineOrigin places the baseline of the first line at the position passed to DrawString instead of the top of the layout box. This aligns texts of different fonts and sizes.
+	BaselineOrigin bool
+	// Ellipsis is appended to truncated text, e.g. "...". Without ellipsis, lines exceeding MaxWidth are not truncated.
+	Ellipsis string
+	// Direction denotes the base direction of each paragraph for bidirectional text. Defaults to the direction of the first strong character.
+	Direction TextDirection
 }
 
 func getActualDrawStringOptions(opts *DrawStringOptions) DrawStringOptions {
@@ -372,97 +746,77 @@ func getActualDrawStringOptions(opts *DrawStringOptions) DrawStringOptions {
 	if actualOpts.Scale == 0 {
 		actualOpts.Scale = 1
 	}
+	if actualOpts.LineSpacing == 0 {
+		actualOpts.LineSpacing = 1
+	}
 	return actualOpts
 }
 
-// DrawString renders the given string. Multiple lines are aligned left.
-func DrawString(str string, pos mgl32.Vec2, font *Font, color Color, opts *DrawStringOptions) {
-	actualOpts := getActualDrawStringOptions(opts)
+// glyphQuad denotes the location of a single rune of a laid out string.
+type glyphQuad struct {
+	glyph glyph
+	// font and r identify the glyph image independent of its location in the glyph cache
+	font *Font
+	r    rune
+	quad Quad
+	// color replaces the paint of the text if set
+	color *Color
+	// fauxBold denotes the width in local units by which the glyph is widened to the right
+	fauxBold float32
+}
 
-	if actualOpts.RoundPos {
-		pos[0] = round32(pos[0])
-		pos[1] = round32(pos[1])
+func (g glyphQuad) shape(offset mgl32.Vec2) *shape {
+	uv := g.glyph.uv()
+	// the quad and texture coordinates are extended beyond the glyph image to cover the widened glyph
+	widening := g.fauxBold / (g.quad.Right - g.quad.Left)
+	return &shape{
+		kind: shapeString,
+		tex:  g.glyph.tex,
+		quad: Quad{
+			Left:   g.quad.Left + offset[0],
+			Right:  g.quad.Right + g.fauxBold + offset[0],
+			Top:    g.quad.Top + offset[1],
+			Bottom: g.quad.Bottom + offset[1],
+		},
+		uvTopLeft:     uv.topLeft,
+		uvBottomRight: mgl32.Vec2{uv.bottomRight[0] + widening*(uv.bottomRight[0]-uv.topLeft[0]), uv.bottomRight[1]},
+		sdfSpread:     g.glyph.sdfSpread,
+		fauxBold:      g.fauxBold,
 	}
+}
 
-	spaceWidth, _ := font.RuneSize(' ')
-	tabWidth := (spaceWidth + 1) * float32(actualOpts.TabSpaces)
-	//TODO fallback if space does not exist
-
-	gl.UseProgram(drawStringProg)
-	gl.UniformMatrix4fv(gl.GetUniformLocation(drawStringProg, gl.Str("projectionMatrix\x00")), 1, false, &projectionMatrix[0])
-	gl.Uniform4fv(gl.GetUniformLocation(drawStringProg, gl.Str("color\x00")), 1, &color[0])
-
-	gl.BindTexture(gl.TEXTURE_2D, font.texture.Tex)
-
-	lineHeight := font.lineHeight
-
-	// each line needs to be processed separately
-	lines := font.splitLines(str)
+// DrawString renders the given string. pos denotes the top-left corner of the layout box or the start of the first baseline if BaselineOrigin is set.
+func DrawString(str string, pos mgl32.Vec2, font *Font, color Color, opts *DrawStringOptions) {
+	DrawTextLayout(LayoutText(str, font, opts), pos, color)
+}
 
-	for i := range lines {
-		y := pos[1] + actualOpts.Scale*float32(i)*lineHeight
-		x := pos[0]
-		previousRune := rune(0)
-		for _, r := range lines[i] {
-			if r == '\t' {
-				// jump to next tab anchor instead of drawing
-				x = float32(math.Floor(float64(x-pos[0])/float64(tabWidth)) + float64(tabWidth))
-				previousRune = rune(0)
+// DrawTextLayout renders a text layout that has been computed with LayoutText. pos denotes the top-left corner of the layout box.
+func DrawTextLayout(layout *TextLayout, pos mgl32.Vec2, color Color) {
+	paint := layout.opts.Paint
+	if paint == nil {
+		paint = color
+	}
 
-			} else {
-				if rect, ok := font.runeRects[r]; ok {
-					if previousRune != rune(0) {
-						x += float32(font.Kern(previousRune, r))
-					}
-					w, h := font.RuneSize(r)
-					renderTexturedQuad(Quad{
-						Left:   x,
-						Right:  x + actualOpts.Scale*float32(w),
-						Top:    y,
-						Bottom: y + actualOpts.Scale*float32(h),
-					}, rect.topLeft, rect.bottomRight)
-					x += actualOpts.Scale * float32(w)
-				}
-			}
+	glyphs, images := layout.quads(pos)
+	for _, shadow := range layout.opts.Shadows {
+		drawGlyphShadow(glyphs, shadow)
+	}
+	for _, g := range glyphs {
+		glyphPaint := paint
+		if g.color != nil {
+			glyphPaint = *g.color
 		}
+		s := g.shape(mgl32.Vec2{})
+		s.outlineWidth = layout.opts.OutlineWidth
+		s.outlineColor = layout.opts.OutlineColor
+		renderPaintedShape(glyphPaint, s)
+	}
+	for _, img := range images {
+		DrawImage(img.tex, img.quad)
 	}
-
-	gl.BindTexture(gl.TEXTURE_2D, 0)
 }
 
 // MeasureString return the size of the given string rendered with a specific font.
 func MeasureString(str string, font *Font, opts *DrawStringOptions) mgl32.Vec2 {
-	actualOpts := getActualDrawStringOptions(opts)
-
-	spaceWidth, _ := font.RuneSize(' ')
-	tabWidth := (spaceWidth + 1) * float32(actualOpts.TabSpaces)
-	//TODO fallback if space does not exist
-
-	lines := font.splitLines(str)
-	maxWidth := float32(0)
-	for i := range lines {
-		x := float32(0)
-		previousRune := rune(0)
-		for _, r := range lines[i] {
-			if r == '\t' {
-				// jump to next tab anchor instead of drawing
-				x = float32(math.Floor(float64(x)/float64(tabWidth)) + float64(tabWidth))
-				previousRune = rune(0)
-
-			} else {
-				if _, ok := font.runeRects[r]; ok {
-					if previousRune != rune(0) {
-						x += float32(font.Kern(previousRune, r))
-					}
-					w, _ := font.RuneSize(r)
-					x += actualOpts.Scale * float32(w)
-				}
-			}
-		}
-		if x > maxWidth {
-			maxWidth = x
-		}
-	}
-
-	return [2]float32{maxWidth, actualOpts.Scale * float32(len(lines)) * font.lineHeight}
+	return LayoutText(str, font, opts).Size
 }
@@ -0,0 +1,263 @@
+package gl2d
+
+import (
+	"unicode"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// TextDirection denotes the base direction of paragraphs.
+type TextDirection int
+
+const (
+	// TextDirectionAuto derives the direction of each paragraph from its first strong character.
+	TextDirectionAuto TextDirection = iota
+	// TextDirectionLTR lays out paragraphs from left to right.
+	TextDirectionLTR
+	// TextDirectionRTL lays out paragraphs from right to left.
+	TextDirectionRTL
+)
+
+var (
+	// mirroredRunes contains the most common paired punctuation that is mirrored in right-to-left runs
+	mirroredRunes = map[rune]rune{
+		'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<',
+		'«': '»', '»': '«', '‹': '›', '›': '‹',
+	}
+)
+
+// bidiLevels resolves the embedding level of each rune of a paragraph with the Unicode Bidirectional Algorithm (UAX #9) and returns the levels and the paragraph level. Explicit embeddings, overrides and isolates are not supported and treated as neutral characters.
+func bidiLevels(runes []rune, direction TextDirection) ([]uint8, uint8) {
+	classes := make([]bidi.Class, len(runes))
+	for i, r := range runes {
+		p, _ := bidi.LookupRune(r)
+		classes[i] = p.Class()
+		switch classes[i] {
+		case bidi.LRE, bidi.RLE, bidi.LRO, bidi.RLO, bidi.PDF, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI, bidi.BN, bidi.Control:
+			classes[i] = bidi.ON
+		}
+	}
+
+	// P2, P3: the first strong character determines the paragraph level
+	base := uint8(0)
+	switch direction {
+	case TextDirectionRTL:
+		base = 1
+	case TextDirectionAuto:
+		for _, c := range classes {
+			if c == bidi.L {
+				break
+			}
+			if c == bidi.R || c == bidi.AL {
+				base = 1
+				break
+			}
+		}
+	}
+	sos := bidi.L
+	if base == 1 {
+		sos = bidi.R
+	}
+
+	// W1: non-spacing marks take the type of the previous character
+	previous := sos
+	for i, c := range classes {
+		if c == bidi.NSM {
+			classes[i] = previous
+		}
+		previous = classes[i]
+	}
+	// W2, W3: European numbers after Arabic letters become Arabic numbers
+	lastStrong := sos
+	for i, c := range classes {
+		switch c {
+		case bidi.L, bidi.R, bidi.AL:
+			lastStrong = c
+		case bidi.EN:
+			if lastStrong == bidi.AL {
+				classes[i] = bidi.AN
+			}
+		}
+	}
+	for i, c := range classes {
+		if c == bidi.AL {
+			classes[i] = bidi.R
+		}
+	}
+	// W4: single separators between numbers of the same type
+	for i := 1; i+1 < len(classes); i++ {
+		prev, next := classes[i-1], classes[i+1]
+		if classes[i] == bidi.ES && prev == bidi.EN && next == bidi.EN {
+			classes[i] = bidi.EN
+		} else if classes[i] == bidi.CS && prev == next && (prev == bidi.EN || prev == bidi.AN) {
+			classes[i] = prev
+		}
+	}
+	// W5: terminators adjacent to European numbers
+	for i := 0; i < len(classes); {
+		if classes[i] != bidi.ET {
+			i++
+			continue
+		}
+		j := i
+		for j < len(classes) && classes[j] == bidi.ET {
+			j++
+		}
+		if (i > 0 && classes[i-1] == bidi.EN) || (j < len(classes) && classes[j] == bidi.EN) {
+			for k := i; k < j; k++ {
+				classes[k] = bidi.EN
+			}
+		}
+		i = j
+	}
+	// W6, W7: remaining separators are neutral and European numbers follow preceding left-to-right text
+	lastStrong = sos
+	for i, c := range classes {
+		switch c {
+		case bidi.ES, bidi.ET, bidi.CS:
+			classes[i] = bidi.ON
+		case bidi.L, bidi.R:
+			lastStrong = c
+		case bidi.EN:
+			if lastStrong == bidi.L {
+				classes[i] = bidi.L
+			}
+		}
+	}
+
+	// N1, N2: neutrals take the direction of surrounding text if both sides agree, the paragraph direction otherwise
+	strongDirection := func(c bidi.Class) bidi.Class {
+		if c == bidi.L {
+			return bidi.L
+		}
+		return bidi.R
+	}
+	for i := 0; i < len(classes); {
+		if !isBidiNeutral(classes[i]) {
+			i++
+			continue
+		}
+		j := i
+		for j < len(classes) && isBidiNeutral(classes[j]) {
+			j++
+		}
+		leading, trailing := sos, sos
+		if i > 0 {
+			leading = strongDirection(classes[i-1])
+		}
+		if j < len(classes) {
+			trailing = strongDirection(classes[j])
+		}
+		resolved := sos
+		if leading == trailing {
+			resolved = leading
+		}
+		for k := i; k < j; k++ {
+			classes[k] = resolved
+		}
+		i = j
+	}
+
+	// I1, I2: implicit levels
+	levels := make([]uint8, len(runes))
+	for i, c := range classes {
+		levels[i] = base
+		if base%2 == 0 {
+			if c == bidi.R {
+				levels[i]++
+			} else if c == bidi.AN || c == bidi.EN {
+				levels[i] += 2
+			}
+		} else if c == bidi.L || c == bidi.EN || c == bidi.AN {
+			levels[i]++
+		}
+	}
+	return levels, base
+}
+
+func isBidiNeutral(c bidi.Class) bool {
+	return c == bidi.B || c == bidi.S || c == bidi.WS || c == bidi.ON
+}
+
+// reorderLine converts the glyphs of a line from logical to visual order. Marks stay attached to their base glyph and all glyphs are moved to their new pen position.
+func (l *TextLayout) reorderLine(line *TextLine) {
+	glyphs := line.Glyphs
+	// L1: trailing whitespace and whitespace in front of tabs take the paragraph level
+	trailing := true
+	for i := len(glyphs) - 1; i >= 0; i-- {
+		if glyphs[i].Rune == '\t' {
+			glyphs[i].level = line.level
+			trailing = true
+		} else if trailing && unicode.IsSpace(glyphs[i].Rune) {
+			glyphs[i].level = line.level
+		} else if !glyphs[i].mark {
+			trailing = false
+		}
+	}
+
+	maxLevel, minOddLevel := uint8(0), uint8(255)
+	for _, g := range glyphs {
+		if g.level > maxLevel {
+			maxLevel = g.level
+		}
+		if g.level%2 == 1 && g.level < minOddLevel {
+			minOddLevel = g.level
+		}
+	}
+	if maxLevel == 0 {
+		// pure left-to-right lines keep their kerning
+		return
+	}
+
+	// clusters consist of a base glyph followed by its marks
+	type cluster struct {
+		from, to int
+		level    uint8
+	}
+	var clusters []cluster
+	for i, g := range glyphs {
+		if g.mark && len(clusters) > 0 {
+			clusters[len(clusters)-1].to = i + 1
+			continue
+		}
+		clusters = append(clusters, cluster{from: i, to: i + 1, level: g.level})
+	}
+
+	// L2: reverse all sequences at or above each odd level, starting with the highest level
+	for level := maxLevel; level >= minOddLevel && level > 0; level-- {
+		for i := 0; i < len(clusters); {
+			if clusters[i].level < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(clusters) && clusters[j].level >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				clusters[a], clusters[b] = clusters[b], clusters[a]
+			}
+			i = j
+		}
+	}
+
+	reordered := make([]TextGlyph, 0, len(glyphs))
+	x := float32(0)
+	for _, c := range clusters {
+		base := glyphs[c.from]
+		shift := x - base.X
+		for _, g := range glyphs[c.from:c.to] {
+			g.X += shift
+			// L4: paired punctuation is mirrored in right-to-left runs
+			if g.level%2 == 1 {
+				if mirrored, ok := mirroredRunes[g.Rune]; ok && l.styles[g.style].font.HasRune(mirrored) {
+					g.Rune = mirrored
+				}
+			}
+			reordered = append(reordered, g)
+		}
+		x += base.Advance
+	}
+	line.Glyphs = reordered
+	line.Width = x
+}
@@ -0,0 +1,208 @@
+package gl2d
+
+import (
+	"unicode/utf8"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// OffsetAt returns the byte offset of the caret position that is closest to pos. pos is given relative to the layout origin.
+func (l *TextLayout) OffsetAt(pos mgl32.Vec2) int {
+	if len(l.Lines) == 0 {
+		return 0
+	}
+
+	// the gap between two lines belongs to the upper line
+	line := l.Lines[0]
+	for _, candidate := range l.Lines[1:] {
+		if pos[1] < candidate.Pos[1] {
+			break
+		}
+		line = candidate
+	}
+
+	x := pos[0] - line.Pos[0]
+	for _, g := range line.Glyphs {
+		if g.mark || x >= g.X+g.Advance {
+			continue
+		}
+		// the caret in front of right-to-left runes is located at their right edge
+		before := x < g.X+g.Advance/2
+		if g.level%2 == 1 {
+			before = !before
+		}
+		if before {
+			return minInt(g.Offset, line.End)
+		}
+		return minInt(line.nextOffset(g.Offset), line.End)
+	}
+	if line.level%2 == 1 {
+		return line.Start
+	}
+	return line.End
+}
+
+// CaretPos returns the top of the caret in front of the rune at the given byte offset and the height of the caret. The position is given relative to the layout origin.
+func (l *TextLayout) CaretPos(offset int) (mgl32.Vec2, float32) {
+	line, ok := l.caretLine(offset)
+	if !ok {
+		return mgl32.Vec2{}, 0
+	}
+	return line.Pos.Add(mgl32.Vec2{line.caretX(offset), 0}), line.Height
+}
+
+// SelectionRects returns one rectangle per line that covers all runes between the byte offsets start and end. Lines with mixed direction might require multiple rectangles. The rectangles are given relative to the layout origin.
+func (l *TextLayout) SelectionRects(start, end int) []Quad {
+	if start > end {
+		start, end = end, start
+	}
+	var rects []Quad
+	for _, line := range l.Lines {
+		if line.Start >= end || line.End < start {
+			continue
+		}
+		if line.isBidi() {
+			rects = append(rects, line.bidiSelectionRects(start, end)...)
+			continue
+		}
+		left := line.caretX(maxInt(start, line.Start))
+		right := line.Width
+		if end <= line.End {
+			right = line.caretX(end)
+		}
+		if right <= left {
+			continue
+		}
+		rects = append(rects, Quad{
+			Left:   line.Pos[0] + left,
+			Right:  line.Pos[0] + right,
+			Top:    line.Pos[1],
+			Bottom: line.Pos[1] + line.Height,
+		})
+	}
+	return rects
+}
+
+// caretLine returns the line that displays the caret at the given byte offset. Offsets between two wrapped lines belong to the end of the upper line.
+func (l *TextLayout) caretLine(offset int) (TextLine, bool) {
+	if len(l.Lines) == 0 {
+		return TextLine{}, false
+	}
+	line := l.Lines[0]
+	for _, candidate := range l.Lines[1:] {
+		if candidate.Start > offset {
+			break
+		}
+		line = candidate
+	}
+	return line, true
+}
+
+// caretX returns the horizontal caret position in front of the rune at the given byte offset relative to the line start.
+func (line TextLine) caretX(offset int) float32 {
+	if !line.isBidi() {
+		for _, g := range line.Glyphs {
+			if g.Offset >= offset {
+				return g.X
+			}
+		}
+		return line.Width
+	}
+
+	// glyphs of mixed direction lines are not sorted by offset
+	var next *TextGlyph
+	for i, g := range line.Glyphs {
+		if !g.mark && g.Offset >= offset && (next == nil || g.Offset < next.Offset) {
+			next = &line.Glyphs[i]
+		}
+	}
+	if next == nil {
+		if line.level%2 == 1 {
+			return 0
+		}
+		return line.Width
+	}
+	if next.level%2 == 1 {
+		return next.X + next.Advance
+	}
+	return next.X
+}
+
+// nextOffset returns the smallest glyph offset after the given offset or the end of the line.
+func (line TextLine) nextOffset(offset int) int {
+	next := line.End
+	for _, g := range line.Glyphs {
+		if g.Offset > offset && g.Offset < next {
+			next = g.Offset
+		}
+	}
+	return next
+}
+
+// isBidi returns true when the line contains right-to-left runs.
+func (line TextLine) isBidi() bool {
+	for _, g := range line.Glyphs {
+		if g.level%2 == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// bidiSelectionRects returns one rectangle per visually connected run of selected glyphs, as the selection of mixed direction lines might be discontinuous.
+func (line TextLine) bidiSelectionRects(start, end int) []Quad {
+	var rects []Quad
+	selected := false
+	for _, g := range line.Glyphs {
+		if g.mark {
+			continue
+		}
+		if g.Offset < start || g.Offset >= end {
+			selected = false
+			continue
+		}
+		if selected {
+			rects[len(rects)-1].Right = line.Pos[0] + g.X + g.Advance
+		} else {
+			rects = append(rects, Quad{
+				Left:   line.Pos[0] + g.X,
+				Right:  line.Pos[0] + g.X + g.Advance,
+				Top:    line.Pos[1],
+				Bottom: line.Pos[1] + line.Height,
+			})
+		}
+		selected = true
+	}
+	return rects
+}
+
+// RuneIndexAt returns the index of the rune in front of that the caret is placed when clicking at pos. pos is given relative to the position passed to DrawString.
+func RuneIndexAt(str string, pos mgl32.Vec2, font *Font, opts *DrawStringOptions) int {
+	offset := LayoutText(str, font, opts).OffsetAt(pos)
+	return utf8.RuneCountInString(str[:offset])
+}
+
+// CaretPos returns the top of the caret in front of the rune with the given index and the height of the caret. The position is given relative to the position passed to DrawString.
+func CaretPos(str string, index int, font *Font, opts *DrawStringOptions) (mgl32.Vec2, float32) {
+	return LayoutText(str, font, opts).CaretPos(runeIndexToOffset(str, index))
+}
+
+// SelectionRects returns one rectangle per line that covers all runes with index in [start, end). The rectangles are given relative to the position passed to DrawString.
+func SelectionRects(str string, start, end int, font *Font, opts *DrawStringOptions) []Quad {
+	return LayoutText(str, font, opts).SelectionRects(runeIndexToOffset(str, start), runeIndexToOffset(str, end))
+}
+
+// runeIndexToOffset returns the byte offset of the rune with the given index. Indices exceeding the string are mapped to its end.
+func runeIndexToOffset(str string, index int) int {
+	if index <= 0 {
+		return 0
+	}
+	i := 0
+	for offset := range str {
+		if i == index {
+			return offset
+		}
+		i++
+	}
+	return len(str)
+}
@@ -0,0 +1,571 @@
+package gl2d
+
+import (
+	"github.com/sbreitf1/go-gl-lib/glutil"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// TextAlign denotes the horizontal alignment of lines.
+type TextAlign int
+
+const (
+	// TextAlignLeft aligns lines at the left border of the layout box.
+	TextAlignLeft TextAlign = iota
+	// TextAlignCenter centers lines inside the layout box.
+	TextAlignCenter
+	// TextAlignRight aligns lines at the right border of the layout box.
+	TextAlignRight
+	// TextAlignJustify stretches the spaces of wrapped lines to fill MaxWidth. The last line of each paragraph is aligned left.
+	TextAlignJustify
+)
+
+// VerticalAlign denotes the vertical alignment of text inside a layout box of height MaxHeight.
+type VerticalAlign int
+
+const (
+	// VerticalAlignTop aligns the first line at the top of the layout box.
+	VerticalAlignTop VerticalAlign = iota
+	// VerticalAlignMiddle centers all lines inside the layout box.
+	VerticalAlignMiddle
+	// VerticalAlignBottom aligns the last line at the bottom of the layout box.
+	VerticalAlignBottom
+)
+
+// TextLayout denotes a string that has been broken into lines. Use LayoutText to obtain a layout.
+type TextLayout struct {
+	Lines []TextLine
+	// Size denotes the width of the longest line and the height of all lines.
+	Size mgl32.Vec2
+	// Truncated is true when lines or runes have been omitted to satisfy MaxLines, MaxHeight or MaxWidth.
+	Truncated bool
+
+	font   *Font
+	opts   DrawStringOptions
+	styles []textStyle
+}
+
+// textStyle denotes the appearance of a run of runes. The layout of plain strings consists of a single style.
+type textStyle struct {
+	font  *Font
+	scale float32
+	// color replaces the color or paint of DrawTextLayout if set
+	color *Color
+	// fauxBold denotes the width in local units by which glyphs are widened to emulate bold fonts
+	fauxBold float32
+	// image is rendered instead of a rune if set
+	image *glutil.Texture
+}
+
+// ascent returns the distance from the top of the style's line to the baseline.
+func (s textStyle) ascent() float32 {
+	if s.image != nil {
+		return s.imageSize()[1]
+	}
+	return s.scale * s.font.Ascent()
+}
+
+// descent returns the distance from the baseline to the bottom of the style's line.
+func (s textStyle) descent() float32 {
+	if s.image != nil {
+		return 0
+	}
+	return s.scale * (s.font.lineHeight - s.font.Ascent())
+}
+
+// imageSize returns the size of inline images, which are as high as the ascent of the font.
+func (s textStyle) imageSize() mgl32.Vec2 {
+	h := s.scale * s.font.Ascent()
+	if s.image.Height <= 0 {
+		return mgl32.Vec2{}
+	}
+	return mgl32.Vec2{h * float32(s.image.Width) / float32(s.image.Height), h}
+}
+
+// advance returns the horizontal advance of a rune in this style.
+func (s textStyle) advance(r rune) (float32, bool) {
+	if s.image != nil {
+		return s.imageSize()[0], true
+	}
+	w, ok := s.font.advance(r)
+	if !ok {
+		return 0, false
+	}
+	return s.scale*w + s.fauxBold, true
+}
+
+// TextLine denotes a single line of a text layout.
+type TextLine struct {
+	// Pos denotes the top-left corner of the line relative to the layout origin.
+	Pos    mgl32.Vec2
+	Width  float32
+	Height float32
+	// Baseline denotes the distance from the top of the line to the baseline.
+	Baseline float32
+	// Start and End denote the byte offsets of the line in the source string. The line break or the spaces at a wrapped line break are excluded.
+	Start, End int
+	Glyphs     []TextGlyph
+
+	// wrapped is true when the line has been broken to fit MaxWidth
+	wrapped bool
+	// ellipsized is true when the ellipsis has already been appended to the line
+	ellipsized bool
+	// level denotes the bidi embedding level of the paragraph, which is odd for right-to-left paragraphs
+	level uint8
+}
+
+// TextGlyph denotes the location of a rune inside a line. Glyphs are stored in visual order from left to right.
+type TextGlyph struct {
+	// Rune denotes the rendered rune, which might be a contextual form or ligature of the source runes.
+	Rune rune
+	// Offset denotes the byte offset of the rune in the source string. Runes of an ellipsis refer to the end of the line.
+	Offset int
+	// X denotes the pen position relative to the line start.
+	X       float32
+	Advance float32
+
+	style int
+	// level denotes the bidi embedding level, which is odd for right-to-left runs
+	level uint8
+	// mark is true for combining marks, which are placed on top of the preceding glyph without advance
+	mark bool
+}
+
+// paragraph denotes a part of the source string between two line breaks.
+type paragraph struct {
+	runes   []rune
+	offsets []int
+	styles  []int
+	// levels denote the bidi embedding level of each rune and level the paragraph level
+	levels     []uint8
+	level      uint8
+	start, end int
+}
+
+// LayoutText breaks a string into lines as configured by the wrapping, alignment and truncation options. The layout can be rendered with DrawTextLayout.
+func LayoutText(str string, font *Font, opts *DrawStringOptions) *TextLayout {
+	l := newTextLayout(font, opts)
+	paragraphs := appendParagraphs([]paragraph{{}}, str, 0, 0)
+	paragraphs[len(paragraphs)-1].end = len(str)
+	l.layout(paragraphs)
+	return l
+}
+
+func newTextLayout(font *Font, opts *DrawStringOptions) *TextLayout {
+	actualOpts := getActualDrawStringOptions(opts)
+	return &TextLayout{
+		font:   font,
+		opts:   actualOpts,
+		styles: []textStyle{{font: font, scale: actualOpts.Scale}},
+	}
+}
+
+// layout breaks all paragraphs into lines and positions the lines inside the layout box.
+func (l *TextLayout) layout(paragraphs []paragraph) {
+	actualOpts := l.opts
+	// only omitted lines end the text with an ellipsis, lines that are too wide have already been ellipsized on their own
+	linesOmitted := false
+	for _, p := range paragraphs {
+		if actualOpts.MaxLines > 0 && len(l.Lines) >= actualOpts.MaxLines {
+			linesOmitted = true
+			break
+		}
+		l.layoutParagraph(p)
+	}
+	if actualOpts.MaxLines > 0 && len(l.Lines) > actualOpts.MaxLines {
+		l.Lines = l.Lines[:actualOpts.MaxLines]
+		linesOmitted = true
+	}
+	if actualOpts.MaxHeight > 0 {
+		// the first line is always visible
+		bottom := l.Lines[0].Height
+		for i := 1; i < len(l.Lines); i++ {
+			bottom += actualOpts.LineSpacing*l.Lines[i-1].Height + l.Lines[i].Height - l.Lines[i-1].Height
+			if bottom > actualOpts.MaxHeight+0.001 {
+				l.Lines = l.Lines[:i]
+				linesOmitted = true
+				break
+			}
+		}
+	}
+	if linesOmitted {
+		l.Truncated = true
+		if last := &l.Lines[len(l.Lines)-1]; len(actualOpts.Ellipsis) > 0 && !last.ellipsized {
+			l.appendEllipsis(last)
+		}
+	}
+	for i := range l.Lines {
+		l.reorderLine(&l.Lines[i])
+	}
+
+	if actualOpts.Align == TextAlignJustify && actualOpts.MaxWidth > 0 {
+		for i := range l.Lines {
+			if l.Lines[i].wrapped {
+				l.justify(&l.Lines[i])
+			}
+		}
+	}
+
+	lineTops := make([]float32, len(l.Lines))
+	for i, line := range l.Lines {
+		l.Size[0] = max32(l.Size[0], line.Width)
+		if i > 0 {
+			lineTops[i] = lineTops[i-1] + actualOpts.LineSpacing*l.Lines[i-1].Height
+		}
+		l.Size[1] = lineTops[i] + line.Height
+	}
+
+	boxWidth := actualOpts.MaxWidth
+	if boxWidth <= 0 {
+		boxWidth = l.Size[0]
+	}
+	var offsetY float32
+	if actualOpts.MaxHeight > 0 {
+		switch actualOpts.VerticalAlign {
+		case VerticalAlignMiddle:
+			offsetY = (actualOpts.MaxHeight - l.Size[1]) / 2
+		case VerticalAlignBottom:
+			offsetY = actualOpts.MaxHeight - l.Size[1]
+		}
+	}
+	if actualOpts.BaselineOrigin && len(l.Lines) > 0 {
+		offsetY -= l.Lines[0].Baseline
+	}
+	for i := range l.Lines {
+		line := &l.Lines[i]
+		line.Pos[1] = offsetY + lineTops[i]
+		switch actualOpts.Align {
+		case TextAlignCenter:
+			line.Pos[0] = (boxWidth - line.Width) / 2
+		case TextAlignRight:
+			line.Pos[0] = boxWidth - line.Width
+		}
+		if actualOpts.RoundPos {
+			line.Pos[0] = round32(line.Pos[0])
+			line.Pos[1] = round32(line.Pos[1])
+		}
+	}
+}
+
+// appendParagraphs appends the runes of a string with the given style to the last paragraph and starts a new paragraph at each line break. offset denotes the byte offset of the string in the source. The end of the last paragraph needs to be set by the caller.
+func appendParagraphs(paragraphs []paragraph, str string, offset, style int) []paragraph {
+	current := &paragraphs[len(paragraphs)-1]
+	skipNewline := false
+	for j, r := range str {
+		i := offset + j
+		if skipNewline && r == '\n' {
+			// second part of \r\n
+			current.start = i + 1
+			skipNewline = false
+			continue
+		}
+		skipNewline = false
+		if r == '\r' || r == '\n' {
+			current.end = i
+			skipNewline = r == '\r'
+			paragraphs = append(paragraphs, paragraph{start: i + 1})
+			current = &paragraphs[len(paragraphs)-1]
+			continue
+		}
+		current.runes = append(current.runes, r)
+		current.offsets = append(current.offsets, i)
+		current.styles = append(current.styles, style)
+	}
+	return paragraphs
+}
+
+func isTextWhitespace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// tabStop returns the location of the next tab stop after x.
+func (l *TextLayout) tabStop(x float32) float32 {
+	spaceWidth, _ := l.font.RuneSize(' ')
+	//TODO fallback if space does not exist
+	tabWidth := l.opts.Scale * (spaceWidth + 1) * float32(l.opts.TabSpaces)
+	if tabWidth <= 0 {
+		return x
+	}
+	return (floor32(x/tabWidth) + 1) * tabWidth
+}
+
+// layoutParagraph appends the lines of a paragraph. Lines are broken at the last space that fits into MaxWidth or before the first rune that exceeds MaxWidth if a word does not fit into a line.
+func (l *TextLayout) layoutParagraph(p paragraph) {
+	p.levels, p.level = bidiLevels(p.runes, l.opts.Direction)
+	p = l.shapeParagraph(p)
+
+	wrap := l.opts.Wrap && l.opts.MaxWidth > 0
+	start := 0
+	for {
+		var glyphs []TextGlyph
+		x := float32(0)
+		previousRune := rune(0)
+		previousStyle := -1
+		// breakAt denotes the first space of the last sequence of spaces and breakGlyphs the number of glyphs in front of it
+		breakAt, breakGlyphs := -1, 0
+		end, next := len(p.runes), len(p.runes)
+		wrapped := false
+
+		for i := start; i < len(p.runes); i++ {
+			r := p.runes[i]
+			if isTextWhitespace(r) && i > start && !isTextWhitespace(p.runes[i-1]) {
+				breakAt, breakGlyphs = i, len(glyphs)
+			}
+
+			g := TextGlyph{Rune: r, Offset: p.offsets[i], X: x, style: p.styles[i], level: p.levels[i]}
+			style := l.styles[g.style]
+			if isMarkRune(r) && style.image == nil {
+				if _, ok := style.advance(r); !ok {
+					continue
+				}
+				g.mark = true
+				for j := len(glyphs) - 1; j >= 0; j-- {
+					if !glyphs[j].mark {
+						l.placeMark(&g, glyphs[j])
+						break
+					}
+				}
+				// marks neither advance the pen nor interrupt kerning of their base glyphs
+				glyphs = append(glyphs, g)
+				continue
+			}
+			if r == '\t' && style.image == nil {
+				// jump to next tab anchor instead of drawing
+				g.Advance = l.tabStop(x) - x
+				previousRune = rune(0)
+			} else {
+				w, ok := style.advance(r)
+				if !ok {
+					continue
+				}
+				// kerning is only defined between runes of the same font
+				if previousRune != rune(0) && style.image == nil && l.styles[previousStyle].font == style.font && l.styles[previousStyle].image == nil {
+					g.X += style.scale * style.font.Kern(previousRune, r)
+				}
+				g.Advance = w
+				previousRune, previousStyle = r, g.style
+			}
+
+			// trailing spaces may exceed the line
+			if wrap && !isTextWhitespace(r) && len(glyphs) > 0 && g.X+g.Advance > l.opts.MaxWidth {
+				wrapped = true
+				if breakAt > start {
+					end, next = breakAt, breakAt
+					for next < len(p.runes) && isTextWhitespace(p.runes[next]) {
+						next++
+					}
+					glyphs = glyphs[:breakGlyphs]
+				} else {
+					end, next = i, i
+				}
+				break
+			}
+			glyphs = append(glyphs, g)
+			x = g.X + g.Advance
+		}
+
+		line := TextLine{Start: p.start, End: p.end, Glyphs: glyphs, wrapped: wrapped, level: p.level}
+		if start > 0 {
+			line.Start = p.offsets[start]
+		}
+		if end < len(p.runes) {
+			line.End = p.offsets[end]
+		}
+		line.Width = lineWidth(glyphs)
+		l.measureLineHeight(&line, p)
+		if !wrap && l.opts.MaxWidth > 0 && line.Width > l.opts.MaxWidth && len(l.opts.Ellipsis) > 0 {
+			l.Truncated = true
+			l.appendEllipsis(&line)
+		}
+		l.Lines = append(l.Lines, line)
+
+		if next >= len(p.runes) {
+			return
+		}
+		start = next
+	}
+}
+
+// measureLineHeight computes the height and baseline of a line from the highest styles above and below the baseline.
+func (l *TextLayout) measureLineHeight(line *TextLine, p paragraph) {
+	styles := make([]int, 0, len(line.Glyphs)+1)
+	for _, g := range line.Glyphs {
+		styles = append(styles, g.style)
+	}
+	if len(styles) == 0 {
+		// empty lines take the height of the style at the line break
+		style := 0
+		if len(p.styles) > 0 {
+			style = p.styles[len(p.styles)-1]
+		}
+		styles = append(styles, style)
+	}
+
+	var ascent, descent float32
+	for _, s := range styles {
+		ascent = max32(ascent, l.styles[s].ascent())
+		descent = max32(descent, l.styles[s].descent())
+	}
+	line.Baseline = ascent
+	line.Height = ascent + descent
+}
+
+func lineWidth(glyphs []TextGlyph) float32 {
+	for i := len(glyphs) - 1; i >= 0; i-- {
+		if !glyphs[i].mark {
+			return glyphs[i].X + glyphs[i].Advance
+		}
+	}
+	return 0
+}
+
+// appendEllipsis removes trailing runes of a line until the ellipsis fits into MaxWidth and appends the ellipsis.
+func (l *TextLayout) appendEllipsis(line *TextLine) {
+	// the ellipsis continues the style of the last rune
+	styleIndex := 0
+	for i := len(line.Glyphs) - 1; i >= 0; i-- {
+		if l.styles[line.Glyphs[i].style].image == nil {
+			styleIndex = line.Glyphs[i].style
+			break
+		}
+	}
+	style := l.styles[styleIndex]
+
+	var ellipsis []TextGlyph
+	x := float32(0)
+	previousRune := rune(0)
+	for _, r := range l.opts.Ellipsis {
+		w, ok := style.advance(r)
+		if !ok {
+			continue
+		}
+		if previousRune != rune(0) {
+			x += style.scale * style.font.Kern(previousRune, r)
+		}
+		ellipsis = append(ellipsis, TextGlyph{Rune: r, X: x, Advance: w, style: styleIndex, level: line.level})
+		x += w
+		previousRune = r
+	}
+	ellipsisWidth := x
+
+	for len(line.Glyphs) > 0 {
+		last := line.Glyphs[len(line.Glyphs)-1]
+		if !last.mark && !isTextWhitespace(last.Rune) && (l.opts.MaxWidth <= 0 || last.X+last.Advance+ellipsisWidth <= l.opts.MaxWidth) {
+			break
+		}
+		line.End = last.Offset
+		line.Glyphs = line.Glyphs[:len(line.Glyphs)-1]
+	}
+
+	x = lineWidth(line.Glyphs)
+	if len(line.Glyphs) > 0 && len(ellipsis) > 0 {
+		if last := line.Glyphs[len(line.Glyphs)-1]; last.style == styleIndex && !isTextWhitespace(last.Rune) {
+			x += style.scale * style.font.Kern(last.Rune, ellipsis[0].Rune)
+		}
+	}
+	for _, g := range ellipsis {
+		g.Offset = line.End
+		g.X += x
+		line.Glyphs = append(line.Glyphs, g)
+	}
+	line.Width = lineWidth(line.Glyphs)
+	line.ellipsized = true
+	// a truncated line is never stretched
+	line.wrapped = false
+}
+
+// justify distributes the remaining space of a line to all spaces between words.
+func (l *TextLayout) justify(line *TextLine) {
+	// leading spaces keep their width to preserve indentation
+	isGap := func(i int) bool {
+		if line.Glyphs[i].Rune != ' ' || l.styles[line.Glyphs[i].style].image != nil {
+			return false
+		}
+		for _, g := range line.Glyphs[:i] {
+			if !isTextWhitespace(g.Rune) {
+				return true
+			}
+		}
+		return false
+	}
+	spaces := 0
+	for i := range line.Glyphs {
+		if isGap(i) {
+			spaces++
+		}
+	}
+	extra := l.opts.MaxWidth - line.Width
+	if spaces == 0 || extra <= 0 {
+		return
+	}
+	extra /= float32(spaces)
+
+	shift := float32(0)
+	for i := range line.Glyphs {
+		line.Glyphs[i].X += shift
+		if isGap(i) {
+			line.Glyphs[i].Advance += extra
+			shift += extra
+		}
+	}
+	line.Width = lineWidth(line.Glyphs)
+}
+
+// quads computes the location of all visible runes and inline images of the layout.
+func (l *TextLayout) quads(pos mgl32.Vec2) ([]glyphQuad, []imageQuad) {
+	var glyphs []glyphQuad
+	var images []imageQuad
+	pinned := make(map[*Font]bool)
+	for _, s := range l.styles {
+		if s.font.cache != nil && !pinned[s.font] {
+			s.font.cache.pin()
+			pinned[s.font] = true
+		}
+	}
+	if l.opts.RoundPos {
+		pos[0] = round32(pos[0])
+		pos[1] = round32(pos[1])
+	}
+
+	for _, line := range l.Lines {
+		baseline := pos[1] + line.Pos[1] + line.Baseline
+		for _, tg := range line.Glyphs {
+			style := l.styles[tg.style]
+			left := pos[0] + line.Pos[0] + tg.X
+			if style.image != nil {
+				size := style.imageSize()
+				images = append(images, imageQuad{tex: style.image, quad: Quad{Left: left, Right: left + size[0], Top: baseline - size[1], Bottom: baseline}})
+				continue
+			}
+			if tg.Rune == '\t' {
+				continue
+			}
+			g, ok := style.font.glyph(tg.Rune)
+			if !ok || g.size[0] <= 0 || g.size[1] <= 0 {
+				continue
+			}
+			left += style.scale * g.offset[0]
+			top := baseline - style.ascent() + style.scale*g.offset[1]
+			glyphs = append(glyphs, glyphQuad{
+				glyph: g,
+				font:  style.font,
+				r:     tg.Rune,
+				quad: Quad{
+					Left:   left,
+					Right:  left + style.scale*g.size[0],
+					Top:    top,
+					Bottom: top + style.scale*g.size[1],
+				},
+				color:    style.color,
+				fauxBold: style.fauxBold,
+			})
+		}
+	}
+	return glyphs, images
+}
+
+// imageQuad denotes the location of an inline image of a laid out string.
+type imageQuad struct {
+	tex  *glutil.Texture
+	quad Quad
+}
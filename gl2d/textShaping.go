@@ -0,0 +1,177 @@
+package gl2d
+
+import (
+	"unicode"
+)
+
+// arabicJoining denotes how an Arabic letter connects to its neighbours.
+type arabicJoining int
+
+const (
+	arabicNonJoining arabicJoining = iota
+	// arabicRightJoining letters only connect to the preceding letter
+	arabicRightJoining
+	// arabicDualJoining letters connect to both neighbours
+	arabicDualJoining
+	// arabicJoinCausing characters connect to both neighbours without having contextual forms, e.g. the tatweel
+	arabicJoinCausing
+)
+
+// arabicForm denotes the contextual forms of an Arabic letter in the Arabic Presentation Forms-B block. The isolated, final, initial and medial forms are stored in consecutive order starting at isolated.
+type arabicForm struct {
+	isolated rune
+	joining  arabicJoining
+}
+
+var (
+	arabicForms = map[rune]arabicForm{
+		'ء': {0xFE80, arabicNonJoining},
+		'آ': {0xFE81, arabicRightJoining},
+		'أ': {0xFE83, arabicRightJoining},
+		'ؤ': {0xFE85, arabicRightJoining},
+		'إ': {0xFE87, arabicRightJoining},
+		'ئ': {0xFE89, arabicDualJoining},
+		'ا': {0xFE8D, arabicRightJoining},
+		'ب': {0xFE8F, arabicDualJoining},
+		'ة': {0xFE93, arabicRightJoining},
+		'ت': {0xFE95, arabicDualJoining},
+		'ث': {0xFE99, arabicDualJoining},
+		'ج': {0xFE9D, arabicDualJoining},
+		'ح': {0xFEA1, arabicDualJoining},
+		'خ': {0xFEA5, arabicDualJoining},
+		'د': {0xFEA9, arabicRightJoining},
+		'ذ': {0xFEAB, arabicRightJoining},
+		'ر': {0xFEAD, arabicRightJoining},
+		'ز': {0xFEAF, arabicRightJoining},
+		'س': {0xFEB1, arabicDualJoining},
+		'ش': {0xFEB5, arabicDualJoining},
+		'ص': {0xFEB9, arabicDualJoining},
+		'ض': {0xFEBD, arabicDualJoining},
+		'ط': {0xFEC1, arabicDualJoining},
+		'ظ': {0xFEC5, arabicDualJoining},
+		'ع': {0xFEC9, arabicDualJoining},
+		'غ': {0xFECD, arabicDualJoining},
+		'ـ': {0, arabicJoinCausing},
+		'ف': {0xFED1, arabicDualJoining},
+		'ق': {0xFED5, arabicDualJoining},
+		'ك': {0xFED9, arabicDualJoining},
+		'ل': {0xFEDD, arabicDualJoining},
+		'م': {0xFEE1, arabicDualJoining},
+		'ن': {0xFEE5, arabicDualJoining},
+		'ه': {0xFEE9, arabicDualJoining},
+		'و': {0xFEED, arabicRightJoining},
+		'ى': {0xFEEF, arabicRightJoining},
+		'ي': {0xFEF1, arabicDualJoining},
+	}
+	// lamAlefLigatures maps the alef following a lam to the isolated form of the mandatory ligature. The final form follows the isolated form.
+	lamAlefLigatures = map[rune]rune{
+		'آ': 0xFEF5,
+		'أ': 0xFEF7,
+		'إ': 0xFEF9,
+		'ا': 0xFEFB,
+	}
+)
+
+const (
+	arabicLam = 'ل'
+)
+
+// isMarkRune returns true for combining marks that are placed on top of the preceding base rune.
+func isMarkRune(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
+
+// joinsForward returns true when the rune at index i connects to the following letter.
+func joinsForward(runes []rune, i int) bool {
+	form, ok := arabicForms[runes[i]]
+	return ok && (form.joining == arabicDualJoining || form.joining == arabicJoinCausing)
+}
+
+// joinsBackward returns true when the rune at index i connects to the preceding letter.
+func joinsBackward(runes []rune, i int) bool {
+	form, ok := arabicForms[runes[i]]
+	return ok && form.joining != arabicNonJoining
+}
+
+// shapeParagraph replaces Arabic letters by their contextual presentation forms and merges lam-alef pairs to their mandatory ligature. Forms are only used when supported by the font of the rune's style. Bidi levels are resolved before shaping, so they are passed along with the paragraph.
+func (l *TextLayout) shapeParagraph(p paragraph) paragraph {
+	// marks are transparent and do not interrupt the connection of letters
+	previousLetter := func(i int) int {
+		for i--; i >= 0 && isMarkRune(p.runes[i]); i-- {
+		}
+		return i
+	}
+	nextLetter := func(i int) int {
+		for i++; i < len(p.runes) && isMarkRune(p.runes[i]); i++ {
+		}
+		return i
+	}
+	supported := func(i int, r rune) bool {
+		style := l.styles[p.styles[i]]
+		return style.image == nil && style.font.HasRune(r)
+	}
+
+	shaped := paragraph{start: p.start, end: p.end, level: p.level}
+	for i := 0; i < len(p.runes); i++ {
+		r := p.runes[i]
+		form, ok := arabicForms[r]
+		if ok && form.isolated != 0 {
+			prev, next := previousLetter(i), nextLetter(i)
+			joinsPrevious := prev >= 0 && joinsForward(p.runes, prev) && joinsBackward(p.runes, i)
+			joinsNext := next < len(p.runes) && joinsForward(p.runes, i) && joinsBackward(p.runes, next)
+
+			if r == arabicLam && next == i+1 && next < len(p.runes) {
+				if ligature, ok := lamAlefLigatures[p.runes[next]]; ok {
+					if joinsPrevious {
+						ligature++
+					}
+					if supported(i, ligature) {
+						// the ligature refers to the offset of the lam and replaces the alef
+						shaped.appendRune(ligature, p, i)
+						i++
+						continue
+					}
+				}
+			}
+
+			contextual := form.isolated
+			switch {
+			case joinsPrevious && joinsNext:
+				contextual += 3
+			case joinsNext:
+				contextual += 2
+			case joinsPrevious:
+				contextual++
+			}
+			if supported(i, contextual) {
+				r = contextual
+			}
+		}
+		shaped.appendRune(r, p, i)
+	}
+	return shaped
+}
+
+// appendRune appends a rune that takes the offset, style and bidi level of the rune at index i of the source paragraph.
+func (p *paragraph) appendRune(r rune, src paragraph, i int) {
+	p.runes = append(p.runes, r)
+	p.offsets = append(p.offsets, src.offsets[i])
+	p.styles = append(p.styles, src.styles[i])
+	p.levels = append(p.levels, src.levels[i])
+}
+
+// placeMark positions a combining mark horizontally centered above or below the ink of its base glyph.
+func (l *TextLayout) placeMark(mark *TextGlyph, base TextGlyph) {
+	markStyle, baseStyle := l.styles[mark.style], l.styles[base.style]
+	center := base.X + base.Advance/2
+	if baseStyle.image == nil {
+		if m, ok := baseStyle.font.GlyphMetrics(base.Rune); ok && m.Bounds.Right > m.Bounds.Left {
+			center = base.X + baseStyle.scale*(m.Bounds.Left+m.Bounds.Right)/2
+		}
+	}
+	mark.X = center
+	if m, ok := markStyle.font.GlyphMetrics(mark.Rune); ok {
+		mark.X -= markStyle.scale * (m.Bounds.Left + m.Bounds.Right) / 2
+	}
+	mark.Advance = 0
+}
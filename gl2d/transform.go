@@ -0,0 +1,85 @@
+package gl2d
+
+import (
+	"math"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+var (
+	transform      = mgl32.Ident3()
+	transformStack []mgl32.Mat3
+)
+
+// PushTransform saves the current transformation so it can be restored later using PopTransform.
+func PushTransform() {
+	transformStack = append(transformStack, transform)
+}
+
+// PopTransform restores the transformation saved by the last call to PushTransform.
+func PopTransform() {
+	if len(transformStack) == 0 {
+		panic("PopTransform called without matching PushTransform")
+	}
+	transform = transformStack[len(transformStack)-1]
+	transformStack = transformStack[:len(transformStack)-1]
+}
+
+// ResetTransform sets the current transformation to identity. The transform stack remains unchanged.
+func ResetTransform() {
+	transform = mgl32.Ident3()
+}
+
+// CurrentTransform returns the current transformation from local to screen coordinates.
+func CurrentTransform() mgl32.Mat3 {
+	return transform
+}
+
+// SetTransform replaces the current transformation.
+func SetTransform(m mgl32.Mat3) {
+	transform = m
+}
+
+// Translate moves the origin of all subsequent primitives.
+func Translate(offset mgl32.Vec2) {
+	transform = transform.Mul3(mgl32.Translate2D(offset[0], offset[1]))
+}
+
+// Rotate rotates all subsequent primitives around the current origin. The angle is given in radians and positive values rotate clockwise on screen.
+func Rotate(angle float32) {
+	transform = transform.Mul3(mgl32.HomogRotate2D(angle))
+}
+
+// RotateAround rotates all subsequent primitives around the given pivot point.
+func RotateAround(pivot mgl32.Vec2, angle float32) {
+	Translate(pivot)
+	Rotate(angle)
+	Translate(pivot.Mul(-1))
+}
+
+// Scale scales all subsequent primitives relative to the current origin.
+func Scale(factor mgl32.Vec2) {
+	transform = transform.Mul3(mgl32.Scale2D(factor[0], factor[1]))
+}
+
+func resetTransformStack() {
+	transform = mgl32.Ident3()
+	transformStack = transformStack[:0]
+}
+
+func transformPoint(m mgl32.Mat3, p mgl32.Vec2) mgl32.Vec2 {
+	return m.Mul3x1(p.Vec3(1)).Vec2()
+}
+
+// transformScale returns the average scale factor of a transformation, i.e. the length of a local unit in screen pixels.
+func transformScale(m mgl32.Mat3) float32 {
+	return float32(math.Sqrt(math.Abs(float64(m[0]*m[4] - m[1]*m[3]))))
+}
+
+// localRBlend returns the blending radius in local units of the current transformation.
+func localRBlend() float32 {
+	if s := transformScale(transform); s > 0 {
+		return rBlend / s
+	}
+	return rBlend
+}
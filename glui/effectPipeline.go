@@ -0,0 +1,273 @@
+package glui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/sbreitf1/go-gl-lib/glutil"
+	"github.com/sirupsen/logrus"
+)
+
+type effectKind int
+
+const (
+	effectCopy effectKind = iota
+	effectBlur
+	effectThreshold
+	effectBloomCombine
+	effectVignette
+	effectColorMatrix
+)
+
+var (
+	effectFragmentShaders = map[effectKind]string{
+		effectCopy:         copyEffectFragmentShader,
+		effectBlur:         blurEffectFragmentShader,
+		effectThreshold:    thresholdEffectFragmentShader,
+		effectBloomCombine: bloomCombineEffectFragmentShader,
+		effectVignette:     vignetteEffectFragmentShader,
+		effectColorMatrix:  colorMatrixEffectFragmentShader,
+	}
+)
+
+// effectProgram holds a post-processing shader program and caches its uniform locations.
+type effectProgram struct {
+	prog     uint32
+	uniforms map[string]int32
+}
+
+func newEffectProgram(fragmentSource string) (*effectProgram, error) {
+	prog, err := glutil.AssembleShaderFromSource(glutil.ShaderSource{
+		Vertex:   effectVertexShader,
+		Fragment: fragmentSource,
+		// keep the vertex position at generic attribute 0 like gl2d does
+		AttribLocations: map[string]uint32{"vertexPos": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &effectProgram{prog: prog, uniforms: make(map[string]int32)}, nil
+}
+
+func (p *effectProgram) uniform(name string) int32 {
+	loc, ok := p.uniforms[name]
+	if !ok {
+		loc = gl.GetUniformLocation(p.prog, gl.Str(name+"\x00"))
+		p.uniforms[name] = loc
+	}
+	return loc
+}
+
+// effectPipeline renders the layers of the main window to intermediate render targets and applies effects in between.
+type effectPipeline struct {
+	vbo           uint32
+	progs         map[effectKind]*effectProgram
+	width, height int
+	failedWidth   int
+	failedHeight  int
+	targets       [2]*glutil.RenderTarget
+	scratches     []*glutil.RenderTarget
+	current       int
+	time          float32
+}
+
+func newEffectPipeline() *effectPipeline {
+	p := &effectPipeline{progs: make(map[effectKind]*effectProgram)}
+
+	// full-screen quad as triangle strip
+	vertices := []float32{-1, -1, 1, -1, -1, 1, 1, 1}
+	gl.GenBuffers(1, &p.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, 4*len(vertices), gl.Ptr(vertices), gl.STATIC_DRAW)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+	return p
+}
+
+func (p *effectPipeline) destroy() {
+	for _, prog := range p.progs {
+		if prog != nil {
+			gl.DeleteProgram(prog.prog)
+		}
+	}
+	p.progs = nil
+	p.releaseTargets()
+	gl.DeleteBuffers(1, &p.vbo)
+}
+
+func (p *effectPipeline) releaseTargets() {
+	for _, t := range p.targets {
+		if t != nil {
+			t.Destroy()
+		}
+	}
+	p.targets = [2]*glutil.RenderTarget{}
+	for _, t := range p.scratches {
+		t.Destroy()
+	}
+	p.scratches = nil
+	p.width, p.height = 0, 0
+}
+
+// begin redirects all rendering to an intermediate render target of the given size. Returns false if no render target is available.
+func (p *effectPipeline) begin(width, height int, time float32) bool {
+	if width <= 0 || height <= 0 {
+		return false
+	}
+	if width != p.width || height != p.height {
+		if width == p.failedWidth && height == p.failedHeight {
+			return false
+		}
+		if err := p.resize(width, height); err != nil {
+			// only report once per size to not flood the log
+			logrus.Errorf("post-processing disabled: %s", err.Error())
+			p.failedWidth, p.failedHeight = width, height
+			return false
+		}
+	}
+
+	p.time = time
+	p.current = 0
+	p.bind(p.targets[0])
+	gl.ClearColor(0, 0, 0, 0)
+	gl.ClearStencil(0)
+	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT | gl.STENCIL_BUFFER_BIT)
+	return true
+}
+
+func (p *effectPipeline) resize(width, height int) error {
+	p.releaseTargets()
+	for i := range p.targets {
+		t, err := glutil.NewRenderTarget(width, height, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+		if err != nil {
+			p.releaseTargets()
+			return fmt.Errorf("create render target: %s", err.Error())
+		}
+		p.targets[i] = t
+	}
+	p.width, p.height = width, height
+	return nil
+}
+
+// scratch returns an additional render target of the screen size for intermediate results of effects.
+func (p *effectPipeline) scratch(index int) *glutil.RenderTarget {
+	for len(p.scratches) <= index {
+		t, err := glutil.NewRenderTarget(p.width, p.height, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+		if err != nil {
+			logrus.Errorf("create scratch render target: %s", err.Error())
+			return nil
+		}
+		p.scratches = append(p.scratches, t)
+	}
+	return p.scratches[index]
+}
+
+// apply runs all effects on the current screen content. Rendering continues on the result afterwards.
+func (p *effectPipeline) apply(effects []Effect) {
+	if len(effects) == 0 {
+		return
+	}
+
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.DEPTH_TEST)
+	gl.Disable(gl.STENCIL_TEST)
+	gl.Disable(gl.SCISSOR_TEST)
+	gl.ColorMask(true, true, true, true)
+
+	for _, e := range effects {
+		src, dst := p.targets[p.current], p.targets[1-p.current]
+		e.apply(p, src, dst)
+		p.current = 1 - p.current
+	}
+
+	gl.UseProgram(0)
+	p.bind(p.targets[p.current])
+	// layers rendered afterwards expect a fresh stencil buffer for clipping
+	gl.ClearStencil(0)
+	gl.Clear(gl.STENCIL_BUFFER_BIT)
+}
+
+// present copies the final screen content to the back buffer.
+func (p *effectPipeline) present() {
+	gl.Disable(gl.BLEND)
+	gl.Disable(gl.STENCIL_TEST)
+	p.copy(p.targets[p.current], nil)
+	gl.UseProgram(0)
+}
+
+// bind activates the render target or the back buffer if target is nil.
+func (p *effectPipeline) bind(target *glutil.RenderTarget) {
+	if target == nil {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	} else {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, target.Framebuffer())
+	}
+	gl.Viewport(0, 0, int32(p.width), int32(p.height))
+}
+
+func (p *effectPipeline) copy(src, dst *glutil.RenderTarget) {
+	p.pass(effectCopy, dst, src, nil, nil)
+}
+
+// blur applies a separable gaussian blur from src to dst using tmp for the intermediate result.
+func (p *effectPipeline) blur(src, tmp, dst *glutil.RenderTarget, radius float32) {
+	if radius > maxBlurRadius {
+		radius = maxBlurRadius
+	}
+	for i, pass := range []struct{ src, dst *glutil.RenderTarget }{{src, tmp}, {tmp, dst}} {
+		p.pass(effectBlur, pass.dst, pass.src, nil, func(prog *effectProgram) {
+			gl.Uniform2f(prog.uniform("direction"), float32(1-i), float32(i))
+			gl.Uniform1f(prog.uniform("radius"), radius)
+		})
+	}
+}
+
+// pass renders a built-in effect program.
+func (p *effectPipeline) pass(kind effectKind, dst, src, src2 *glutil.RenderTarget, setup func(prog *effectProgram)) {
+	prog, ok := p.progs[kind]
+	if !ok {
+		var err error
+		prog, err = newEffectProgram(effectFragmentShaders[kind])
+		if err != nil {
+			// failed programs are not compiled again
+			logrus.Errorf("assemble effect shader %d: %s", kind, err.Error())
+		}
+		p.progs[kind] = prog
+	}
+	if prog == nil {
+		if kind != effectCopy {
+			p.copy(src, dst)
+		}
+		return
+	}
+	p.passProgram(prog, dst, src, src2, setup)
+}
+
+// passProgram renders a full-screen quad to dst using the given program. src is bound to "tex" and src2 to "tex2".
+func (p *effectPipeline) passProgram(prog *effectProgram, dst, src, src2 *glutil.RenderTarget, setup func(prog *effectProgram)) {
+	if src == nil {
+		return
+	}
+	p.bind(dst)
+
+	gl.UseProgram(prog.prog)
+	gl.Uniform2f(prog.uniform("texelSize"), 1/float32(p.width), 1/float32(p.height))
+	gl.Uniform1f(prog.uniform("time"), p.time)
+	gl.Uniform1i(prog.uniform("tex"), 0)
+	if src2 != nil {
+		gl.Uniform1i(prog.uniform("tex2"), 1)
+		gl.ActiveTexture(gl.TEXTURE1)
+		gl.BindTexture(gl.TEXTURE_2D, src2.Texture.Tex)
+		gl.ActiveTexture(gl.TEXTURE0)
+	}
+	gl.BindTexture(gl.TEXTURE_2D, src.Texture.Tex)
+	if setup != nil {
+		setup(prog)
+	}
+
+	gl.BindBuffer(gl.ARRAY_BUFFER, p.vbo)
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 0, gl.PtrOffset(0))
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	gl.DisableVertexAttribArray(0)
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
+}
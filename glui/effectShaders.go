@@ -0,0 +1,108 @@
+package glui
+
+const (
+	effectVertexShader = `#version 120
+
+attribute vec2 vertexPos;
+varying vec2 uv;
+
+void main() {
+	uv = 0.5*vertexPos + 0.5;
+	gl_Position = vec4(vertexPos, 0, 1);
+}
+`
+
+	copyEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+varying vec2 uv;
+
+void main() {
+	gl_FragColor = texture2D(tex, uv);
+}
+`
+
+	// blurEffectFragmentShader computes one direction of a separable gaussian blur. The loop limit must match maxBlurRadius
+	blurEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+uniform vec2 texelSize;
+uniform vec2 direction;
+uniform float radius;
+varying vec2 uv;
+
+void main() {
+	// the radius covers three standard deviations
+	float sigma = radius/3.0;
+	vec4 sum = texture2D(tex, uv);
+	float weightSum = 1.0;
+	for (int i = 1; i <= 64; i++) {
+		if (float(i) > radius) {
+			break;
+		}
+		float weight = exp(-float(i*i)/(2.0*sigma*sigma));
+		vec2 offset = float(i)*direction*texelSize;
+		sum += weight*(texture2D(tex, uv+offset) + texture2D(tex, uv-offset));
+		weightSum += 2.0*weight;
+	}
+	gl_FragColor = sum/weightSum;
+}
+`
+
+	thresholdEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+uniform float threshold;
+varying vec2 uv;
+
+void main() {
+	vec4 color = texture2D(tex, uv);
+	float brightness = max(color.r, max(color.g, color.b));
+	float factor = max(brightness-threshold, 0.0)/max(brightness, 0.0001);
+	gl_FragColor = vec4(factor*color.rgb, 1.0);
+}
+`
+
+	bloomCombineEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+uniform sampler2D tex2;
+uniform float intensity;
+varying vec2 uv;
+
+void main() {
+	vec4 color = texture2D(tex, uv);
+	gl_FragColor = vec4(color.rgb + intensity*texture2D(tex2, uv).rgb, color.a);
+}
+`
+
+	vignetteEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+uniform float radius;
+uniform float softness;
+uniform float strength;
+uniform vec3 color;
+varying vec2 uv;
+
+void main() {
+	vec4 screenColor = texture2D(tex, uv);
+	// distance is 1 in the corners
+	float dist = length(uv-0.5)*sqrt(2.0);
+	float factor = strength*smoothstep(radius, radius+max(softness, 0.0001), dist);
+	gl_FragColor = vec4(mix(screenColor.rgb, color, factor), screenColor.a);
+}
+`
+
+	colorMatrixEffectFragmentShader = `#version 120
+
+uniform sampler2D tex;
+uniform mat4 matrix;
+uniform vec4 offset;
+varying vec2 uv;
+
+void main() {
+	gl_FragColor = clamp(matrix*texture2D(tex, uv) + offset, 0.0, 1.0);
+}
+`
+)
@@ -0,0 +1,154 @@
+package glui
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sbreitf1/go-gl-lib/glutil"
+)
+
+const (
+	// maxBlurRadius denotes the largest supported blur radius in pixels. Must match the loop limit of the blur shader.
+	maxBlurRadius = 64
+)
+
+// Effect is a full-screen post-processing pass. GaussianBlur, Bloom, Vignette, ColorMatrix and ShaderEffect implement Effect.
+type Effect interface {
+	apply(p *effectPipeline, src, dst *glutil.RenderTarget)
+}
+
+// GaussianBlur blurs the whole screen.
+type GaussianBlur struct {
+	// Radius denotes the distance in pixels that contributes to each blurred pixel.
+	Radius float32
+}
+
+func (e GaussianBlur) apply(p *effectPipeline, src, dst *glutil.RenderTarget) {
+	if e.Radius <= 0 {
+		p.copy(src, dst)
+		return
+	}
+	tmp := p.scratch(0)
+	if tmp == nil {
+		p.copy(src, dst)
+		return
+	}
+	p.blur(src, tmp, dst, e.Radius)
+}
+
+// Bloom lets bright areas bleed into their surroundings.
+type Bloom struct {
+	// Threshold denotes the brightness between 0 and 1 above that colors start to glow.
+	Threshold float32
+	// Intensity scales the brightness of the glow.
+	Intensity float32
+	// Radius denotes the distance in pixels that is covered by the glow.
+	Radius float32
+}
+
+func (e Bloom) apply(p *effectPipeline, src, dst *glutil.RenderTarget) {
+	bright, tmp := p.scratch(0), p.scratch(1)
+	if bright == nil || tmp == nil {
+		p.copy(src, dst)
+		return
+	}
+	p.pass(effectThreshold, bright, src, nil, func(prog *effectProgram) {
+		gl.Uniform1f(prog.uniform("threshold"), e.Threshold)
+	})
+	if e.Radius > 0 {
+		p.blur(bright, tmp, bright, e.Radius)
+	}
+	p.pass(effectBloomCombine, dst, src, bright, func(prog *effectProgram) {
+		gl.Uniform1f(prog.uniform("intensity"), e.Intensity)
+	})
+}
+
+// Vignette darkens the screen towards the corners.
+type Vignette struct {
+	// Radius denotes the distance to the screen center where darkening starts. 0 denotes the center and 1 the corners of the screen.
+	Radius float32
+	// Softness denotes the distance over that the vignette fades in.
+	Softness float32
+	// Strength denotes the opacity of the vignette between 0 and 1.
+	Strength float32
+	// Color is blended into the corners. Defaults to black.
+	Color mgl32.Vec3
+}
+
+func (e Vignette) apply(p *effectPipeline, src, dst *glutil.RenderTarget) {
+	p.pass(effectVignette, dst, src, nil, func(prog *effectProgram) {
+		gl.Uniform1f(prog.uniform("radius"), e.Radius)
+		gl.Uniform1f(prog.uniform("softness"), e.Softness)
+		gl.Uniform1f(prog.uniform("strength"), e.Strength)
+		gl.Uniform3f(prog.uniform("color"), e.Color[0], e.Color[1], e.Color[2])
+	})
+}
+
+// ColorMatrix transforms each color by Matrix*rgba+Offset.
+type ColorMatrix struct {
+	Matrix mgl32.Mat4
+	Offset mgl32.Vec4
+}
+
+// ColorGrading returns a color matrix that adjusts brightness, contrast and saturation. Brightness 0, contrast 1 and saturation 1 leave colors unchanged.
+func ColorGrading(brightness, contrast, saturation float32) ColorMatrix {
+	// luminance weights of ITU-R BT.709
+	luma := mgl32.Vec3{0.2126, 0.7152, 0.0722}
+
+	m := mgl32.Ident4()
+	for col := 0; col < 3; col++ {
+		for row := 0; row < 3; row++ {
+			v := (1 - saturation) * luma[col]
+			if row == col {
+				v += saturation
+			}
+			m.Set(row, col, contrast*v)
+		}
+	}
+	offset := 0.5*(1-contrast) + brightness
+	return ColorMatrix{Matrix: m, Offset: mgl32.Vec4{offset, offset, offset, 0}}
+}
+
+func (e ColorMatrix) apply(p *effectPipeline, src, dst *glutil.RenderTarget) {
+	p.pass(effectColorMatrix, dst, src, nil, func(prog *effectProgram) {
+		gl.UniformMatrix4fv(prog.uniform("matrix"), 1, false, &e.Matrix[0])
+		gl.Uniform4f(prog.uniform("offset"), e.Offset[0], e.Offset[1], e.Offset[2], e.Offset[3])
+	})
+}
+
+// ShaderEffect applies a user-defined fragment shader to the screen.
+type ShaderEffect struct {
+	prog *effectProgram
+	// SetUniforms is called before each pass to set custom uniforms of the program.
+	SetUniforms func(prog uint32)
+}
+
+// NewShaderEffect compiles a fragment shader for post-processing. The shader receives the screen texture coordinates as "varying vec2 uv" and can use the uniforms "sampler2D tex" containing the screen content, "vec2 texelSize" denoting the size of a pixel in texture coordinates and "float time" containing the total simulation time in seconds.
+func NewShaderEffect(fragmentSource string) (*ShaderEffect, error) {
+	prog, err := newEffectProgram(fragmentSource)
+	if err != nil {
+		return nil, fmt.Errorf("assemble effect shader: %s", err.Error())
+	}
+	return &ShaderEffect{prog: prog}, nil
+}
+
+// Destroy releases the shader program of this effect.
+func (e *ShaderEffect) Destroy() {
+	if e.prog != nil {
+		gl.DeleteProgram(e.prog.prog)
+		e.prog = nil
+	}
+}
+
+func (e *ShaderEffect) apply(p *effectPipeline, src, dst *glutil.RenderTarget) {
+	if e.prog == nil {
+		p.copy(src, dst)
+		return
+	}
+	p.passProgram(e.prog, dst, src, nil, func(prog *effectProgram) {
+		if e.SetUniforms != nil {
+			e.SetUniforms(prog.prog)
+		}
+	})
+}
@@ -24,6 +24,10 @@ type MainWindow struct {
 
 	layers []ContextLayer
 
+	effects       *effectPipeline
+	layerEffects  map[ContextLayer][]Effect
+	windowEffects []Effect
+
 	maxSimStep   time.Duration
 	totalSimTime time.Duration
 
@@ -34,6 +38,9 @@ type MainWindow struct {
 
 	FixedPreFrameSleep     time.Duration
 	FixedPollEventsTimeout time.Duration
+
+	// PresentHandler is called after all layers have been rendered and all effects have been applied, right before the frame is presented. The back buffer contains the final frame at this point.
+	PresentHandler func(w *MainWindow)
 }
 
 // Init initializes GLFW and OpenGL with the given main window properties.
@@ -57,6 +64,7 @@ func Init(windowWidth, windowHeight int, windowTitle string) (*MainWindow, error
 	glfw.WindowHint(glfw.ContextVersionMinor, glVersionMinor)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLAnyProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.False)
+	glfw.WindowHint(glfw.StencilBits, 8)
 	glfwWindow, err := glfw.CreateWindow(windowWidth, windowHeight, windowTitle, nil, nil)
 	if err != nil {
 		return nil, err
@@ -86,6 +94,7 @@ func Init(windowWidth, windowHeight int, windowTitle string) (*MainWindow, error
 		glVersionMinor:    glVersionMinor,
 		glfwWindow:        glfwWindow,
 		layers:            make([]ContextLayer, 0),
+		layerEffects:      make(map[ContextLayer][]Effect),
 		keyStates:         keyStates,
 		mouseButtonStates: mouseButtonStates,
 	}
@@ -104,6 +113,10 @@ func Terminate() {
 		panic("cannot terminate uninitialized ui")
 	}
 
+	if mainWindow.effects != nil {
+		mainWindow.effects.destroy()
+		mainWindow.effects = nil
+	}
 	mainWindow.glfwWindow.Destroy()
 	glfw.Terminate()
 	mainWindow = nil
@@ -133,14 +146,13 @@ func (w *MainWindow) Run() {
 
 		glfw.WaitEventsTimeout(w.FixedPollEventsTimeout.Seconds())
 
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
 		for _, c := range w.layers {
 			c.Update(w, dt.Seconds())
 		}
 
-		for _, c := range w.layers {
-			c.Render(w)
+		w.render()
+		if w.PresentHandler != nil {
+			w.PresentHandler(w)
 		}
 
 		w.glfwWindow.SwapBuffers()
@@ -152,6 +164,69 @@ func (w *MainWindow) Run() {
 	}
 }
 
+// render draws all layers and applies post-processing effects. Layers are rendered straight to the back buffer when no effects are active.
+func (w *MainWindow) render() {
+	usePostProcessing := false
+	if w.hasEffects() {
+		if w.effects == nil {
+			w.effects = newEffectPipeline()
+		}
+		width, height := w.GetSize()
+		usePostProcessing = w.effects.begin(width, height, float32(w.totalSimTime.Seconds()))
+	}
+	if !usePostProcessing {
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
+	}
+
+	for _, c := range w.layers {
+		c.Render(w)
+		if usePostProcessing {
+			w.effects.apply(w.layerEffects[c])
+		}
+	}
+
+	if usePostProcessing {
+		w.effects.apply(w.windowEffects)
+		w.effects.present()
+	}
+}
+
+func (w *MainWindow) hasEffects() bool {
+	if len(w.windowEffects) > 0 {
+		return true
+	}
+	for _, c := range w.layers {
+		if len(w.layerEffects[c]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SetLayerEffects defines the post-processing effects of a layer. After the layer has been rendered, the effects are applied to the content of this layer and all layers beneath it, so a pause menu can blur the game layer beneath it. Call without effects to disable post-processing for the layer. The effects are removed when the layer is left.
+func (w *MainWindow) SetLayerEffects(c ContextLayer, effects ...Effect) {
+	if len(effects) == 0 {
+		delete(w.layerEffects, c)
+	} else {
+		w.layerEffects[c] = effects
+	}
+}
+
+// LayerEffects returns the post-processing effects of a layer.
+func (w *MainWindow) LayerEffects(c ContextLayer) []Effect {
+	return w.layerEffects[c]
+}
+
+// SetEffects defines the post-processing effects that are applied after all layers have been rendered.
+func (w *MainWindow) SetEffects(effects ...Effect) {
+	w.windowEffects = effects
+}
+
+// Effects returns the post-processing effects that are applied after all layers have been rendered.
+func (w *MainWindow) Effects() []Effect {
+	return w.windowEffects
+}
+
 // Close will close the window on next update.
 func (w *MainWindow) Close() {
 	w.glfwWindow.SetShouldClose(true)
@@ -172,7 +247,9 @@ func (w *MainWindow) IsUppermostLayer(c ContextLayer) bool {
 // LeaveUppermostLayer leaves the current uppermost layer.
 func (w *MainWindow) LeaveUppermostLayer() {
 	if len(w.layers) > 0 {
-		w.layers[len(w.layers)-1].Leave(w)
+		c := w.layers[len(w.layers)-1]
+		c.Leave(w)
+		delete(w.layerEffects, c)
 		w.layers = w.layers[:len(w.layers)-1]
 	}
 }
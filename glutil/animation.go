@@ -6,11 +6,26 @@ import (
 	"image/draw"
 	"math"
 	"path/filepath"
+	"sort"
 
 	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sirupsen/logrus"
 )
 
+// AnimationDirection denotes the order in which the frames of an animation are played.
+type AnimationDirection int
+
+const (
+	// AnimationForward plays the frames in ascending order.
+	AnimationForward AnimationDirection = iota
+	// AnimationReverse plays the frames in descending order.
+	AnimationReverse
+	// AnimationPingPong plays the frames in ascending and then in descending order without repeating the first and last frame.
+	AnimationPingPong
+	// AnimationPingPongReverse plays the frames in descending and then in ascending order without repeating the first and last frame.
+	AnimationPingPongReverse
+)
+
 // Animation wraps a Texture and manages moving uv-coordinate frames to display an animation.
 type Animation struct {
 	Image         *Texture
@@ -21,7 +36,39 @@ type Animation struct {
 	currentTime   float64
 	Loop          bool
 	FlipX         bool
-	uvSize        mgl32.Vec2
+	FlipY         bool
+	// Direction denotes the playback order of the frames. Changing the direction keeps the current time, use SetCurrentFrame to continue at a given frame.
+	Direction AnimationDirection
+	// Speed scales the time steps passed to Update. Defaults to 1, 0 pauses the animation.
+	Speed float64
+	// OnFrameChange is called by Update when the visible frame changes.
+	OnFrameChange func(anim *Animation, frame int)
+	// OnLoop is called by Update each time a looping animation starts over.
+	OnLoop func(anim *Animation)
+	// OnFinished is called by Update when a non-looping animation reaches its end.
+	OnFinished func(anim *Animation)
+	uvSize     mgl32.Vec2
+	// frameUVs denote the top-left and bottom-right uv-coordinates of each frame for animations that are not arranged in a grid
+	frameUVs [][2]mgl32.Vec2
+	// frameRects denote the visible part of trimmed frames relative to the frame size
+	frameRects [][2]mgl32.Vec2
+	// frameEnds denote the accumulated end time of each frame for animations with individual frame durations
+	frameEnds []float64
+	tags      []AnimationTag
+	// currentTag denotes the index of the tag that restricts playback or -1 to play all frames
+	currentTag int
+	// sequence denotes the frame indices of a single playback for the cached direction and tag
+	sequence          []int
+	sequenceEnds      []float64
+	sequenceDirection AnimationDirection
+}
+
+// AnimationTag denotes a named range of frames, e.g. "walk" or "idle".
+type AnimationTag struct {
+	Name string
+	// From and To denote the indices of the first and last frame of the tag.
+	From, To  int
+	Direction AnimationDirection
 }
 
 // AnimationFromFileSequence reads an animation from multiple files like 00.png, 01.png, ...
@@ -76,7 +123,9 @@ func AnimationFromFileSequence(dir, format string, first, last int, duration flo
 		gridH:      gridH,
 		frameCount: frameCount,
 		duration:   duration,
+		Speed:      1,
 		uvSize:     [2]float32{1 / float32(gridW), 1 / float32(gridH)},
+		currentTag: -1,
 	}, nil
 }
 
@@ -103,25 +152,259 @@ func AnimationFromLinewiseGridFile(file string, frameCount int, duration float64
 		gridH:      gridH,
 		frameCount: frameCount,
 		duration:   duration,
+		Speed:      1,
 		uvSize:     [2]float32{1 / float32(gridW), 1 / float32(gridH)},
+		currentTag: -1,
 	}, nil
 }
 
+// AnimationFromAtlasRegions creates an animation from regions of an uploaded texture atlas, e.g. as returned by TextureAtlas.Sequence. The duration is divided evenly across all frames. All regions must reside on the same page.
+func AnimationFromAtlasRegions(regions []*AtlasRegion, duration float64) (*Animation, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("invalid frame count 0")
+	}
+	if duration <= 0 {
+		return nil, fmt.Errorf("invalid duration %f", duration)
+	}
+	return animationFromAtlasRegions(regions, nil, duration)
+}
+
+// AnimationFromAtlas creates an animation from all regions of an uploaded texture atlas with the frame durations and tags of the import. Frames without duration are displayed for defaultFrameDuration seconds.
+func AnimationFromAtlas(atlas *TextureAtlas, defaultFrameDuration float64) (*Animation, error) {
+	anim, err := animationFromAtlasFrames(atlas.Regions(), defaultFrameDuration)
+	if err != nil {
+		return nil, err
+	}
+	anim.tags = append([]AnimationTag(nil), atlas.Tags...)
+	return anim, nil
+}
+
+// AnimationFromAtlasTag creates an animation from the frames of a tag of an uploaded texture atlas. Frames without duration are displayed for defaultFrameDuration seconds.
+func AnimationFromAtlasTag(atlas *TextureAtlas, tag string, defaultFrameDuration float64) (*Animation, error) {
+	for _, t := range atlas.Tags {
+		if t.Name == tag {
+			regions := atlas.Regions()
+			if t.From < 0 || t.To < t.From || t.To >= len(regions) {
+				return nil, fmt.Errorf("tag %q has invalid frame range %d-%d", t.Name, t.From, t.To)
+			}
+			anim, err := animationFromAtlasFrames(regions[t.From:t.To+1], defaultFrameDuration)
+			if err != nil {
+				return nil, err
+			}
+			anim.tags = []AnimationTag{{Name: t.Name, From: 0, To: t.To - t.From, Direction: t.Direction}}
+			anim.Direction = t.Direction
+			return anim, nil
+		}
+	}
+	return nil, fmt.Errorf("texture atlas does not contain tag %q", tag)
+}
+
+func animationFromAtlasFrames(regions []*AtlasRegion, defaultFrameDuration float64) (*Animation, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("invalid frame count 0")
+	}
+	durations := make([]float64, len(regions))
+	for i, r := range regions {
+		durations[i] = r.Duration
+		if durations[i] <= 0 {
+			durations[i] = defaultFrameDuration
+		}
+		if durations[i] <= 0 {
+			return nil, fmt.Errorf("invalid duration %f of frame %q", durations[i], r.Name)
+		}
+	}
+	return animationFromAtlasRegions(regions, durations, 0)
+}
+
+// animationFromAtlasRegions creates an animation with individual frame durations or evenly divides duration if frameDurations is nil.
+func animationFromAtlasRegions(regions []*AtlasRegion, frameDurations []float64, duration float64) (*Animation, error) {
+	tex := regions[0].Texture()
+	if tex == nil {
+		return nil, fmt.Errorf("texture atlas has not been uploaded")
+	}
+
+	anim := &Animation{
+		Image:      tex,
+		Width:      regions[0].SourceSize.X,
+		Height:     regions[0].SourceSize.Y,
+		frameCount: len(regions),
+		duration:   duration,
+		Speed:      1,
+		frameUVs:   make([][2]mgl32.Vec2, len(regions)),
+		currentTag: -1,
+	}
+	for i, r := range regions {
+		if r.Texture() != tex {
+			return nil, fmt.Errorf("frame %q resides on another atlas page", r.Name)
+		}
+		anim.frameUVs[i][0], anim.frameUVs[i][1] = r.UV()
+		if r.IsTrimmed() {
+			if anim.frameRects == nil {
+				anim.frameRects = make([][2]mgl32.Vec2, len(regions))
+				for j := range anim.frameRects {
+					anim.frameRects[j][1] = mgl32.Vec2{1, 1}
+				}
+			}
+			anim.frameRects[i][0], anim.frameRects[i][1] = r.TrimRect()
+		}
+	}
+	if frameDurations != nil {
+		anim.frameEnds = make([]float64, len(frameDurations))
+		anim.duration = 0
+		for i, d := range frameDurations {
+			anim.duration += d
+			anim.frameEnds[i] = anim.duration
+		}
+	}
+	return anim, nil
+}
+
 // CurrentTime returns the current animation time in seconds.
 func (a *Animation) CurrentTime() float64 {
 	return a.currentTime
 }
 
+// Duration returns the total animation duration in seconds.
+func (a *Animation) Duration() float64 {
+	return a.duration
+}
+
+// FrameCount returns the number of frames.
+func (a *Animation) FrameCount() int {
+	return a.frameCount
+}
+
+// FrameDuration returns the display duration of a given frame index in seconds.
+func (a *Animation) FrameDuration(f int) float64 {
+	if a.frameEnds == nil {
+		return a.duration / float64(a.frameCount)
+	}
+	if f == 0 {
+		return a.frameEnds[0]
+	}
+	return a.frameEnds[f] - a.frameEnds[f-1]
+}
+
+// Tags returns all named frame ranges of the animation.
+func (a *Animation) Tags() []AnimationTag {
+	return a.tags
+}
+
+// Tag returns the named frame range with the given name.
+func (a *Animation) Tag(name string) (AnimationTag, bool) {
+	for _, t := range a.tags {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return AnimationTag{}, false
+}
+
+// SetTag restricts playback to the frames of a tag, applies the direction of the tag and resets the animation time.
+func (a *Animation) SetTag(name string) error {
+	for i, t := range a.tags {
+		if t.Name == name {
+			a.currentTag = i
+			a.Direction = t.Direction
+			a.sequence = nil
+			a.Reset()
+			return nil
+		}
+	}
+	return fmt.Errorf("animation does not contain tag %q", name)
+}
+
+// ClearTag plays all frames again and resets the animation time.
+func (a *Animation) ClearTag() {
+	a.currentTag = -1
+	a.sequence = nil
+	a.Reset()
+}
+
+// CurrentTag returns the name of the tag that restricts playback or an empty string if all frames are played.
+func (a *Animation) CurrentTag() string {
+	if a.currentTag < 0 {
+		return ""
+	}
+	return a.tags[a.currentTag].Name
+}
+
+// PlaybackDuration returns the duration of a single playback of the current tag and direction in seconds. Ping-pong playback does not repeat the first and last frame.
+func (a *Animation) PlaybackDuration() float64 {
+	sequence, ends := a.playback()
+	if a.frameEnds == nil {
+		// keep the exact duration for the full animation
+		return a.duration * (float64(len(sequence)) / float64(a.frameCount))
+	}
+	return ends[len(ends)-1]
+}
+
+// playback returns the frame indices of a single playback and their accumulated end times, which are only computed for individual frame durations.
+func (a *Animation) playback() ([]int, []float64) {
+	if a.sequence != nil && a.sequenceDirection == a.Direction {
+		return a.sequence, a.sequenceEnds
+	}
+
+	from, to := 0, a.frameCount-1
+	if a.currentTag >= 0 {
+		from, to = a.tags[a.currentTag].From, a.tags[a.currentTag].To
+	}
+	var ascending, descending []int
+	for f := from; f <= to; f++ {
+		ascending = append(ascending, f)
+	}
+	for f := to; f >= from; f-- {
+		descending = append(descending, f)
+	}
+	// ping-pong playback omits the turning frames on the way back
+	inner := func(frames []int) []int {
+		if len(frames) <= 2 {
+			return nil
+		}
+		return frames[1 : len(frames)-1]
+	}
+
+	switch a.Direction {
+	case AnimationReverse:
+		a.sequence = descending
+	case AnimationPingPong:
+		a.sequence = append(ascending, inner(descending)...)
+	case AnimationPingPongReverse:
+		a.sequence = append(descending, inner(ascending)...)
+	default:
+		a.sequence = ascending
+	}
+	a.sequenceDirection = a.Direction
+
+	a.sequenceEnds = nil
+	if a.frameEnds != nil {
+		a.sequenceEnds = make([]float64, len(a.sequence))
+		t := 0.0
+		for i, f := range a.sequence {
+			t += a.FrameDuration(f)
+			a.sequenceEnds[i] = t
+		}
+	}
+	return a.sequence, a.sequenceEnds
+}
+
 // CurrentFrame returns the currently visible frame index.
 func (a *Animation) CurrentFrame() int {
-	currentFrame := int(math.Floor(float64(a.frameCount) * a.currentTime / a.duration))
-	if currentFrame < 0 {
-		currentFrame = 0
+	sequence, ends := a.playback()
+	var pos int
+	if ends != nil {
+		// the first frame that ends after the current time is visible
+		pos = sort.Search(len(ends), func(i int) bool { return ends[i] > a.currentTime })
+	} else {
+		pos = int(math.Floor(float64(len(sequence)) * a.currentTime / a.PlaybackDuration()))
 	}
-	if currentFrame >= a.frameCount {
-		currentFrame = a.frameCount - 1
+	if pos < 0 {
+		pos = 0
 	}
-	return currentFrame
+	if pos >= len(sequence) {
+		pos = len(sequence) - 1
+	}
+	return sequence[pos]
 }
 
 // Reset sets animation time to 0.
@@ -131,52 +414,111 @@ func (a *Animation) Reset() {
 
 // ToEnd sets animation time to end.
 func (a *Animation) ToEnd() {
-	a.currentTime = a.duration
+	a.currentTime = a.PlaybackDuration()
 }
 
 // SetCurrentTime sets the current animation time.
 func (a *Animation) SetCurrentTime(t float64) {
 	a.currentTime = t
 	if a.Loop {
-		a.currentTime -= a.duration * math.Floor(t/a.duration)
+		duration := a.PlaybackDuration()
+		a.currentTime -= duration * math.Floor(t/duration)
 	}
 }
 
-// SetCurrentFrame sets the current time to the beginning of a given frame index.
+// SetCurrentFrame sets the current time to the first occurrence of a given frame index in the playback order. Frames outside the current tag are clamped to the tag.
 func (a *Animation) SetCurrentFrame(f int) {
-	a.currentTime = float64(f) * a.duration / float64(a.frameCount)
+	sequence, ends := a.playback()
+	first, last := sequence[0], sequence[0]
+	for _, frame := range sequence {
+		first, last = minInt(first, frame), maxInt(last, frame)
+	}
+	f = minInt(maxInt(f, first), last)
+
+	for pos, frame := range sequence {
+		if frame == f {
+			switch {
+			case ends == nil:
+				a.currentTime = float64(pos) * a.PlaybackDuration() / float64(len(sequence))
+			case pos == 0:
+				a.currentTime = 0
+			default:
+				a.currentTime = ends[pos-1]
+			}
+			return
+		}
+	}
 }
 
-// Update simulation a time step and sets animation parameters accordingly.
+// Update simulation a time step scaled by Speed, sets animation parameters accordingly and invokes the callbacks.
 func (a *Animation) Update(dt float64) {
-	a.currentTime += dt
-	if a.Loop && a.currentTime >= a.duration {
+	previousFrame := a.CurrentFrame()
+	wasFinished := a.IsFinished()
+
+	a.currentTime += dt * a.Speed
+	if a.Loop && a.currentTime >= a.PlaybackDuration() {
 		a.Repeat()
+		if a.OnLoop != nil {
+			a.OnLoop(a)
+		}
+	}
+
+	if frame := a.CurrentFrame(); frame != previousFrame && a.OnFrameChange != nil {
+		a.OnFrameChange(a, frame)
+	}
+	if !wasFinished && a.IsFinished() && a.OnFinished != nil {
+		a.OnFinished(a)
 	}
 }
 
 // Repeat loops the animation once if it has reached it's end.
 func (a *Animation) Repeat() {
 	// reset animation, but keep time overflow
-	for a.currentTime >= a.duration {
-		a.currentTime -= a.duration
+	duration := a.PlaybackDuration()
+	for a.currentTime >= duration {
+		a.currentTime -= duration
 	}
 }
 
 // IsFinished returns whether the animation has reached it's end.
 func (a *Animation) IsFinished() bool {
-	return !a.Loop && a.currentTime >= a.duration
+	return !a.Loop && a.currentTime >= a.PlaybackDuration()
 }
 
 // GetSrcUV returns the uv-coordinates of the current frame.
 func (a *Animation) GetSrcUV() (topLeft, bottomRight mgl32.Vec2) {
 	currentFrame := a.CurrentFrame()
-	topLeft = [2]float32{float32(currentFrame%a.gridW) / float32(a.gridW), float32(currentFrame/a.gridW) / float32(a.gridH)}
-	bottomRight = [2]float32{topLeft[0] + a.uvSize[0], topLeft[1] + a.uvSize[1]}
+	if a.frameUVs != nil {
+		topLeft, bottomRight = a.frameUVs[currentFrame][0], a.frameUVs[currentFrame][1]
+	} else {
+		topLeft = [2]float32{float32(currentFrame%a.gridW) / float32(a.gridW), float32(currentFrame/a.gridW) / float32(a.gridH)}
+		bottomRight = [2]float32{topLeft[0] + a.uvSize[0], topLeft[1] + a.uvSize[1]}
+	}
 	if a.FlipX {
 		tmp := topLeft[0]
 		topLeft[0] = bottomRight[0]
 		bottomRight[0] = tmp
 	}
+	if a.FlipY {
+		tmp := topLeft[1]
+		topLeft[1] = bottomRight[1]
+		bottomRight[1] = tmp
+	}
+	return
+}
+
+// GetDstRect returns the visible part of the current frame relative to the frame size, which is 0,0 to 1,1 unless the frame has been trimmed.
+func (a *Animation) GetDstRect() (topLeft, bottomRight mgl32.Vec2) {
+	if a.frameRects == nil {
+		return mgl32.Vec2{0, 0}, mgl32.Vec2{1, 1}
+	}
+	currentFrame := a.CurrentFrame()
+	topLeft, bottomRight = a.frameRects[currentFrame][0], a.frameRects[currentFrame][1]
+	if a.FlipX {
+		topLeft[0], bottomRight[0] = 1-bottomRight[0], 1-topLeft[0]
+	}
+	if a.FlipY {
+		topLeft[1], bottomRight[1] = 1-bottomRight[1], 1-topLeft[1]
+	}
 	return
 }
@@ -0,0 +1,174 @@
+package glutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// asepriteDirections maps the tag directions of Aseprite to playback directions. TexturePacker does not export tags.
+	asepriteDirections = map[string]AnimationDirection{
+		"":                 AnimationForward,
+		"forward":          AnimationForward,
+		"reverse":          AnimationReverse,
+		"pingpong":         AnimationPingPong,
+		"pingpong_reverse": AnimationPingPongReverse,
+	}
+)
+
+// atlasJSONRect denotes a rectangle in the JSON files of TexturePacker and Aseprite.
+type atlasJSONRect struct {
+	X, Y, W, H int
+}
+
+// atlasJSONFrame denotes a single frame in the JSON files of TexturePacker and Aseprite. Filename is only set for the array format.
+type atlasJSONFrame struct {
+	Filename         string
+	Frame            atlasJSONRect
+	Rotated          bool
+	Trimmed          bool
+	SpriteSourceSize atlasJSONRect
+	SourceSize       atlasJSONRect
+	// Duration is given in milliseconds by Aseprite.
+	Duration int
+}
+
+type atlasJSONMeta struct {
+	Image     string
+	FrameTags []struct {
+		Name      string
+		From, To  int
+		Direction string
+	}
+}
+
+// LoadAtlasJSON reads a sprite sheet in the JSON hash or JSON array format of TexturePacker or Aseprite. The sheet image is loaded relative to the JSON file.
+func LoadAtlasJSON(file string) (*TextureAtlas, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(file)
+	return ReadAtlasJSON(f, func(imageFile string) (image.Image, error) {
+		return imageFromFile(filepath.Join(dir, imageFile))
+	})
+}
+
+// ReadAtlasJSON reads a sprite sheet in the JSON hash or JSON array format of TexturePacker or Aseprite. loadImage is called for the sheet image referenced by the meta data. Regions are named by the frame names and keep the order of the file, frame durations and tags including their direction are taken from Aseprite files. Rotated frames are not supported.
+func ReadAtlasJSON(r io.Reader, loadImage func(file string) (image.Image, error)) (*TextureAtlas, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Frames json.RawMessage
+		Meta   atlasJSONMeta
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decode atlas JSON: %s", err.Error())
+	}
+	frames, err := decodeAtlasJSONFrames(file.Frames)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Meta.Image) == 0 {
+		return nil, fmt.Errorf("atlas JSON does not reference an image")
+	}
+
+	img, err := loadImage(file.Meta.Image)
+	if err != nil {
+		return nil, fmt.Errorf("load atlas image %q: %s", file.Meta.Image, err.Error())
+	}
+	bounds := img.Bounds()
+	page := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(page, page.Bounds(), img, bounds.Min, draw.Src)
+
+	atlas := &TextureAtlas{Pages: []*image.RGBA{page}, regions: make(map[string]*AtlasRegion)}
+	for _, frame := range frames {
+		if _, ok := atlas.regions[frame.Filename]; ok {
+			return nil, fmt.Errorf("duplicate atlas frame %q", frame.Filename)
+		}
+		if frame.Rotated {
+			return nil, fmt.Errorf("atlas frame %q is rotated, which is not supported", frame.Filename)
+		}
+		rect := image.Rect(frame.Frame.X, frame.Frame.Y, frame.Frame.X+frame.Frame.W, frame.Frame.Y+frame.Frame.H)
+		if rect.Empty() || !rect.In(page.Bounds()) {
+			return nil, fmt.Errorf("atlas frame %q exceeds the image bounds", frame.Filename)
+		}
+
+		region := &AtlasRegion{
+			Name:       frame.Filename,
+			Rect:       rect,
+			SourceSize: rect.Size(),
+			Duration:   float64(frame.Duration) / 1000,
+			atlas:      atlas,
+		}
+		if frame.Trimmed {
+			region.Offset = image.Pt(frame.SpriteSourceSize.X, frame.SpriteSourceSize.Y)
+			region.SourceSize = image.Pt(frame.SourceSize.W, frame.SourceSize.H)
+		}
+		atlas.regions[region.Name] = region
+		atlas.names = append(atlas.names, region.Name)
+	}
+
+	for _, tag := range file.Meta.FrameTags {
+		if tag.From < 0 || tag.To < tag.From || tag.To >= len(frames) {
+			return nil, fmt.Errorf("tag %q has invalid frame range %d-%d", tag.Name, tag.From, tag.To)
+		}
+		direction, ok := asepriteDirections[tag.Direction]
+		if !ok {
+			return nil, fmt.Errorf("tag %q has unknown direction %q", tag.Name, tag.Direction)
+		}
+		atlas.Tags = append(atlas.Tags, AnimationTag{Name: tag.Name, From: tag.From, To: tag.To, Direction: direction})
+	}
+	return atlas, nil
+}
+
+// decodeAtlasJSONFrames decodes the frames of the array format or of the hash format, where the order of keys is preserved.
+func decodeAtlasJSONFrames(data json.RawMessage) ([]atlasJSONFrame, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("atlas JSON does not contain any frame")
+	}
+
+	var frames []atlasJSONFrame
+	switch trimmed[0] {
+	case '[':
+		if err := json.Unmarshal(trimmed, &frames); err != nil {
+			return nil, fmt.Errorf("decode atlas frames: %s", err.Error())
+		}
+
+	case '{':
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		// skip the opening brace
+		if _, err := decoder.Token(); err != nil {
+			return nil, fmt.Errorf("decode atlas frames: %s", err.Error())
+		}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, fmt.Errorf("decode atlas frames: %s", err.Error())
+			}
+			var frame atlasJSONFrame
+			if err := decoder.Decode(&frame); err != nil {
+				return nil, fmt.Errorf("decode atlas frame %q: %s", key, err.Error())
+			}
+			frame.Filename = key.(string)
+			frames = append(frames, frame)
+		}
+
+	default:
+		return nil, fmt.Errorf("atlas frames must be an array or an object")
+	}
+	return frames, nil
+}
@@ -0,0 +1,87 @@
+package glutil
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v2.1/gl"
+)
+
+// RenderTarget combines a texture with a framebuffer object to render into the texture.
+type RenderTarget struct {
+	Texture      *Texture
+	fbo          uint32
+	depthStencil uint32
+}
+
+// NewRenderTarget generates a new OpenGL texture of the given size that can be used as render target. The texture contains a depth and stencil buffer.
+func NewRenderTarget(width, height int, params TextureParameters) (*RenderTarget, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid render target size %dx%d", width, height)
+	}
+
+	var prevFBO int32
+	gl.GetIntegerv(gl.FRAMEBUFFER_BINDING, &prevFBO)
+
+	// the texture is initialized with transparent pixels
+	tex, err := TextureFromRGBA(image.NewRGBA(image.Rect(0, 0, width, height)), params)
+	if err != nil {
+		return nil, fmt.Errorf("create texture: %s", err.Error())
+	}
+	t := &RenderTarget{Texture: tex}
+
+	gl.GenFramebuffers(1, &t.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.Tex, 0)
+
+	gl.GenRenderbuffers(1, &t.depthStencil)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, t.depthStencil)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, int32(width), int32(height))
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, t.depthStencil)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.STENCIL_ATTACHMENT, gl.RENDERBUFFER, t.depthStencil)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+
+	status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, uint32(prevFBO))
+	if status != gl.FRAMEBUFFER_COMPLETE {
+		t.Destroy()
+		return nil, fmt.Errorf("framebuffer incomplete: %d", status)
+	}
+
+	return t, nil
+}
+
+// NewMemoryRenderTarget returns a render target whose texture resides in main memory only. Such render targets do not require an OpenGL context and can be used by software renderers.
+func NewMemoryRenderTarget(width, height int, params TextureParameters) *RenderTarget {
+	return &RenderTarget{Texture: MemoryTextureFromRGBA(image.NewRGBA(image.Rect(0, 0, width, height)), params)}
+}
+
+// Width returns the width of the render target in pixels.
+func (t *RenderTarget) Width() int {
+	return t.Texture.Width
+}
+
+// Height returns the height of the render target in pixels.
+func (t *RenderTarget) Height() int {
+	return t.Texture.Height
+}
+
+// Framebuffer returns the OpenGL framebuffer object of this render target. Returns 0 for render targets in main memory.
+func (t *RenderTarget) Framebuffer() uint32 {
+	return t.fbo
+}
+
+// Destroy releases the framebuffer and texture of this render target.
+func (t *RenderTarget) Destroy() {
+	if t.fbo != 0 {
+		gl.DeleteFramebuffers(1, &t.fbo)
+		t.fbo = 0
+	}
+	if t.depthStencil != 0 {
+		gl.DeleteRenderbuffers(1, &t.depthStencil)
+		t.depthStencil = 0
+	}
+	if t.Texture != nil {
+		t.Texture.Destroy()
+	}
+}
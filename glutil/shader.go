@@ -13,6 +13,8 @@ import (
 type ShaderSource struct {
 	Vertex   string
 	Fragment string
+	// AttribLocations binds vertex attributes to fixed locations before the program is linked.
+	AttribLocations map[string]uint32
 }
 
 // AssembleShaderFromFiles compiles and links a shader from input files.
@@ -35,7 +37,7 @@ func AssembleShaderFromFiles(src ShaderSource) (uint32, error) {
 		fragCode = string(fragData)
 	}
 
-	return AssembleShaderFromSource(ShaderSource{Vertex: vertCode, Fragment: fragCode})
+	return AssembleShaderFromSource(ShaderSource{Vertex: vertCode, Fragment: fragCode, AttribLocations: src.AttribLocations})
 }
 
 // AssembleShaderFromSource compiles and links a shader from input sources.
@@ -54,6 +56,9 @@ func AssembleShaderFromSource(src ShaderSource) (uint32, error) {
 	prog := gl.CreateProgram()
 	gl.AttachShader(prog, vertexShader)
 	gl.AttachShader(prog, fragmentShader)
+	for name, loc := range src.AttribLocations {
+		gl.BindAttribLocation(prog, loc, gl.Str(name+"\x00"))
+	}
 	gl.LinkProgram(prog)
 
 	var linkStatus int32
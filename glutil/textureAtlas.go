@@ -0,0 +1,357 @@
+package glutil
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultAtlasPageSize denotes the default maximum width and height of atlas pages in pixels.
+	DefaultAtlasPageSize = 2048
+)
+
+// AtlasImage denotes a named image that is packed into a texture atlas.
+type AtlasImage struct {
+	Name  string
+	Image image.Image
+}
+
+// AtlasOptions configures the packing of texture atlases.
+type AtlasOptions struct {
+	// MaxPageSize denotes the maximum width and height of a page in pixels. Defaults to DefaultAtlasPageSize.
+	MaxPageSize int
+	// Padding denotes the number of transparent pixels between two images. Defaults to 1, negative values disable padding.
+	Padding int
+	// Extrude repeats the border pixels of each image into the padding to prevent bleeding of transparent pixels on interpolation.
+	Extrude bool
+	// PowerOfTwo rounds the page size up to powers of two.
+	PowerOfTwo bool
+}
+
+// TextureAtlas denotes many images that have been packed into few page images. Upload the pages to graphics memory before rendering.
+type TextureAtlas struct {
+	Pages []*image.RGBA
+	// Textures holds one texture per page after Upload has been called.
+	Textures []*Texture
+	// Tags denote named frame ranges referring to the indices of Regions, e.g. as exported by Aseprite.
+	Tags    []AnimationTag
+	regions map[string]*AtlasRegion
+	names   []string
+}
+
+// AtlasRegion denotes the location of a single image inside a texture atlas.
+type AtlasRegion struct {
+	Name string
+	Page int
+	// Rect denotes the pixel location inside the page image.
+	Rect image.Rectangle
+	// Offset denotes the location of the trimmed image inside the original image of size SourceSize. Images packed by NewTextureAtlas are not trimmed.
+	Offset     image.Point
+	SourceSize image.Point
+	// Duration denotes the frame duration in seconds if known from the import, otherwise 0.
+	Duration float64
+	atlas    *TextureAtlas
+}
+
+// Texture returns the texture of the region's page or nil if the atlas has not been uploaded.
+func (r *AtlasRegion) Texture() *Texture {
+	if r.Page >= len(r.atlas.Textures) {
+		return nil
+	}
+	return r.atlas.Textures[r.Page]
+}
+
+// Size returns the size of the region in pixels.
+func (r *AtlasRegion) Size() mgl32.Vec2 {
+	return mgl32.Vec2{float32(r.Rect.Dx()), float32(r.Rect.Dy())}
+}
+
+// IsTrimmed returns true if transparent borders of the original image have been removed.
+func (r *AtlasRegion) IsTrimmed() bool {
+	return r.Offset != (image.Point{}) || r.SourceSize != r.Rect.Size()
+}
+
+// TrimRect returns the location of the visible image relative to the original image, which is 0,0 to 1,1 for images that are not trimmed.
+func (r *AtlasRegion) TrimRect() (topLeft, bottomRight mgl32.Vec2) {
+	w, h := float32(r.SourceSize.X), float32(r.SourceSize.Y)
+	topLeft = mgl32.Vec2{float32(r.Offset.X) / w, float32(r.Offset.Y) / h}
+	bottomRight = mgl32.Vec2{float32(r.Offset.X+r.Rect.Dx()) / w, float32(r.Offset.Y+r.Rect.Dy()) / h}
+	return
+}
+
+// UV returns the normalized texture coordinates of the region inside its page.
+func (r *AtlasRegion) UV() (topLeft, bottomRight mgl32.Vec2) {
+	size := r.atlas.Pages[r.Page].Bounds().Size()
+	w, h := float32(size.X), float32(size.Y)
+	topLeft = mgl32.Vec2{float32(r.Rect.Min.X) / w, float32(r.Rect.Min.Y) / h}
+	bottomRight = mgl32.Vec2{float32(r.Rect.Max.X) / w, float32(r.Rect.Max.Y) / h}
+	return
+}
+
+// NewTextureAtlas packs all images into as few pages as possible using the MaxRects algorithm. Image names must be unique.
+func NewTextureAtlas(images []AtlasImage, opts *AtlasOptions) (*TextureAtlas, error) {
+	var actualOpts AtlasOptions
+	if opts != nil {
+		actualOpts = *opts
+	}
+	if actualOpts.MaxPageSize <= 0 {
+		actualOpts.MaxPageSize = DefaultAtlasPageSize
+	}
+	if actualOpts.Padding == 0 {
+		actualOpts.Padding = 1
+	} else if actualOpts.Padding < 0 {
+		actualOpts.Padding = 0
+	}
+	padding := actualOpts.Padding
+
+	atlas := &TextureAtlas{regions: make(map[string]*AtlasRegion)}
+	for _, img := range images {
+		if _, ok := atlas.regions[img.Name]; ok {
+			return nil, fmt.Errorf("duplicate atlas image %q", img.Name)
+		}
+		size := img.Image.Bounds().Size()
+		if size.X+2*padding > actualOpts.MaxPageSize || size.Y+2*padding > actualOpts.MaxPageSize {
+			return nil, fmt.Errorf("image %q of size %dx%d does not fit into atlas page", img.Name, size.X, size.Y)
+		}
+		atlas.regions[img.Name] = &AtlasRegion{Name: img.Name, atlas: atlas}
+		atlas.names = append(atlas.names, img.Name)
+	}
+
+	// large images are packed first for denser pages
+	order := make([]int, len(images))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := images[order[i]].Image.Bounds().Size(), images[order[j]].Image.Bounds().Size()
+		return maxInt(a.X, a.Y) > maxInt(b.X, b.Y)
+	})
+
+	var bins []*maxRectsBin
+	for _, i := range order {
+		size := images[i].Image.Bounds().Size()
+		// each image occupies its padding on all sides, so adjacent images are separated by twice the padding
+		w, h := size.X+2*padding, size.Y+2*padding
+		page, pos := -1, image.Point{}
+		for p, bin := range bins {
+			if pt, ok := bin.insert(w, h); ok {
+				page, pos = p, pt
+				break
+			}
+		}
+		if page < 0 {
+			bin := newMaxRectsBin(actualOpts.MaxPageSize, actualOpts.MaxPageSize)
+			pt, _ := bin.insert(w, h)
+			bins = append(bins, bin)
+			page, pos = len(bins)-1, pt
+		}
+		region := atlas.regions[images[i].Name]
+		region.Page = page
+		region.Rect = image.Rect(pos.X+padding, pos.Y+padding, pos.X+padding+size.X, pos.Y+padding+size.Y)
+		region.SourceSize = size
+	}
+
+	// pages are cropped to their used area
+	for p, bin := range bins {
+		w, h := bin.usedWidth, bin.usedHeight
+		if actualOpts.PowerOfTwo {
+			w, h = roundUpToPowerOfTwo(w), roundUpToPowerOfTwo(h)
+		}
+		atlas.Pages = append(atlas.Pages, image.NewRGBA(image.Rect(0, 0, w, h)))
+		logrus.Debugf("texture atlas page %d of size %dx%d", p, w, h)
+	}
+	for _, img := range images {
+		region := atlas.regions[img.Name]
+		page := atlas.Pages[region.Page]
+		draw.Draw(page, region.Rect, img.Image, img.Image.Bounds().Min, draw.Src)
+		if actualOpts.Extrude && padding > 0 {
+			extrude(page, region.Rect, padding)
+		}
+	}
+	return atlas, nil
+}
+
+// extrude copies the border pixels of rect outwards by the given number of pixels.
+func extrude(img *image.RGBA, rect image.Rectangle, pixels int) {
+	bounds := rect.Inset(-pixels).Intersect(img.Bounds())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if (image.Point{X: x, Y: y}).In(rect) {
+				continue
+			}
+			srcX := minInt(maxInt(x, rect.Min.X), rect.Max.X-1)
+			srcY := minInt(maxInt(y, rect.Min.Y), rect.Max.Y-1)
+			img.SetRGBA(x, y, img.RGBAAt(srcX, srcY))
+		}
+	}
+}
+
+// Region returns the region of the image with the given name.
+func (a *TextureAtlas) Region(name string) (*AtlasRegion, bool) {
+	r, ok := a.regions[name]
+	return r, ok
+}
+
+// Regions returns all regions in the order the images have been passed to NewTextureAtlas or have been read from file.
+func (a *TextureAtlas) Regions() []*AtlasRegion {
+	regions := make([]*AtlasRegion, len(a.names))
+	for i, name := range a.names {
+		regions[i] = a.regions[name]
+	}
+	return regions
+}
+
+// Sequence returns the regions named name/0, name/1, ... as added by SliceSpriteSheet in frame order.
+func (a *TextureAtlas) Sequence(name string) []*AtlasRegion {
+	var regions []*AtlasRegion
+	for i := 0; ; i++ {
+		r, ok := a.regions[fmt.Sprintf("%s/%d", name, i)]
+		if !ok {
+			return regions
+		}
+		regions = append(regions, r)
+	}
+}
+
+// Upload transfers all pages to graphics memory using newTexture, which defaults to TextureFromRGBA. Renderers that do not use OpenGL pass their own texture constructor.
+func (a *TextureAtlas) Upload(newTexture func(rgba *image.RGBA, params TextureParameters) (*Texture, error), params TextureParameters) error {
+	if newTexture == nil {
+		newTexture = TextureFromRGBA
+	}
+	a.Destroy()
+	for i, page := range a.Pages {
+		tex, err := newTexture(page, params)
+		if err != nil {
+			a.Destroy()
+			return fmt.Errorf("upload atlas page %d: %s", i, err.Error())
+		}
+		a.Textures = append(a.Textures, tex)
+	}
+	return nil
+}
+
+// Destroy releases the textures of all pages. The page images remain valid.
+func (a *TextureAtlas) Destroy() {
+	for _, tex := range a.Textures {
+		tex.Destroy()
+	}
+	a.Textures = nil
+}
+
+// SliceSpriteSheet splits a sprite sheet whose frames are arranged line by line into frameCount images named name/0, name/1, ... Use TextureAtlas.Sequence to obtain the frames after packing.
+func SliceSpriteSheet(name string, sheet image.Image, frameWidth, frameHeight, frameCount int) ([]AtlasImage, error) {
+	if frameWidth <= 0 || frameHeight <= 0 {
+		return nil, fmt.Errorf("invalid frame size %dx%d", frameWidth, frameHeight)
+	}
+	bounds := sheet.Bounds()
+	gridW, gridH := bounds.Dx()/frameWidth, bounds.Dy()/frameHeight
+	if gridW*gridH < frameCount {
+		return nil, fmt.Errorf("sprite sheet of size %dx%d is too small to hold %d frames", bounds.Dx(), bounds.Dy(), frameCount)
+	}
+
+	images := make([]AtlasImage, frameCount)
+	for i := range images {
+		min := bounds.Min.Add(image.Pt((i%gridW)*frameWidth, (i/gridW)*frameHeight))
+		frame := image.NewRGBA(image.Rect(0, 0, frameWidth, frameHeight))
+		draw.Draw(frame, frame.Bounds(), sheet, min, draw.Src)
+		images[i] = AtlasImage{Name: fmt.Sprintf("%s/%d", name, i), Image: frame}
+	}
+	return images, nil
+}
+
+// maxRectsBin keeps track of the free space of an atlas page as list of maximal free rectangles.
+type maxRectsBin struct {
+	free                  []image.Rectangle
+	usedWidth, usedHeight int
+}
+
+func newMaxRectsBin(width, height int) *maxRectsBin {
+	return &maxRectsBin{free: []image.Rectangle{image.Rect(0, 0, width, height)}}
+}
+
+// insert places a rectangle at the free location that leaves the shortest remaining side (best short side fit).
+func (b *maxRectsBin) insert(w, h int) (image.Point, bool) {
+	best := -1
+	bestShort, bestLong := 0, 0
+	for i, f := range b.free {
+		if f.Dx() < w || f.Dy() < h {
+			continue
+		}
+		short := minInt(f.Dx()-w, f.Dy()-h)
+		long := maxInt(f.Dx()-w, f.Dy()-h)
+		if best < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			best, bestShort, bestLong = i, short, long
+		}
+	}
+	if best < 0 {
+		return image.Point{}, false
+	}
+
+	placed := image.Rectangle{Min: b.free[best].Min, Max: b.free[best].Min.Add(image.Pt(w, h))}
+	b.usedWidth = maxInt(b.usedWidth, placed.Max.X)
+	b.usedHeight = maxInt(b.usedHeight, placed.Max.Y)
+
+	// split all free rectangles that overlap the placed rectangle
+	var free []image.Rectangle
+	for _, f := range b.free {
+		if !f.Overlaps(placed) {
+			free = append(free, f)
+			continue
+		}
+		if placed.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, placed.Min.X, f.Max.Y))
+		}
+		if placed.Max.X < f.Max.X {
+			free = append(free, image.Rect(placed.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if placed.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, placed.Min.Y))
+		}
+		if placed.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, placed.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	// remove free rectangles that are contained in others
+	b.free = b.free[:0]
+	for i, f := range free {
+		contained := false
+		for j, other := range free {
+			if i != j && f.In(other) && (f != other || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			b.free = append(b.free, f)
+		}
+	}
+	return placed.Min, true
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func roundUpToPowerOfTwo(val int) int {
+	power := 1
+	for power < val {
+		power *= 2
+	}
+	return power
+}
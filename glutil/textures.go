@@ -48,6 +48,10 @@ func TexParam(wrap TextureWrap, filter TextureFilter) TextureParameters {
 type Texture struct {
 	Tex           uint32
 	Width, Height int
+	// Image holds the texture data in main memory for textures created by MemoryTextureFromRGBA and is nil otherwise.
+	Image *image.RGBA
+	// Params denotes the parameters used to sample this texture.
+	Params TextureParameters
 }
 
 // AspectRatio returns width divided by height.
@@ -107,5 +111,44 @@ func TextureFromRGBA(rgba *image.RGBA, params TextureParameters) (*Texture, erro
 	}
 	gl.BindTexture(gl.TEXTURE_2D, 0)
 
-	return &Texture{tex, rgba.Bounds().Size().X, rgba.Bounds().Size().Y}, nil
+	return &Texture{Tex: tex, Width: rgba.Bounds().Size().X, Height: rgba.Bounds().Size().Y, Params: params}, nil
+}
+
+// MemoryTextureFromRGBA wraps an RGBA image as texture that resides in main memory only. Such textures do not require an OpenGL context and can be used by software renderers.
+func MemoryTextureFromRGBA(rgba *image.RGBA, params TextureParameters) *Texture {
+	return &Texture{Width: rgba.Bounds().Size().X, Height: rgba.Bounds().Size().Y, Image: rgba, Params: params}
+}
+
+// Destroy releases the graphics memory of this texture.
+func (t *Texture) Destroy() {
+	if t.Tex != 0 {
+		gl.DeleteTextures(1, &t.Tex)
+		t.Tex = 0
+	}
+	t.Image = nil
+}
+
+// UpdateRegion replaces the pixels inside rect with the pixels at the same location of rgba. The image must cover rect.
+func (t *Texture) UpdateRegion(rgba *image.RGBA, rect image.Rectangle) error {
+	rect = rect.Intersect(image.Rect(0, 0, t.Width, t.Height)).Intersect(rgba.Bounds())
+	if rect.Empty() {
+		return nil
+	}
+
+	if t.Image != nil && t.Image != rgba {
+		draw.Draw(t.Image, rect, rgba, rect.Min, draw.Src)
+	}
+
+	if t.Tex != 0 {
+		gl.BindTexture(gl.TEXTURE_2D, t.Tex)
+		// the region is read directly from the pixel buffer of the whole image
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, int32(rgba.Stride/4))
+		gl.TexSubImage2D(gl.TEXTURE_2D, 0, int32(rect.Min.X), int32(rect.Min.Y), int32(rect.Dx()), int32(rect.Dy()), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(rgba.Pix[rgba.PixOffset(rect.Min.X, rect.Min.Y):]))
+		gl.PixelStorei(gl.UNPACK_ROW_LENGTH, 0)
+		gl.BindTexture(gl.TEXTURE_2D, 0)
+		if errNum := gl.GetError(); errNum != 0 {
+			return fmt.Errorf("write image data to graphics memory: %d", errNum)
+		}
+	}
+	return nil
 }
@@ -10,4 +10,5 @@ require (
 	github.com/sirupsen/logrus v1.8.0
 	golang.org/x/image v0.0.0-20210220032944-ac19c3e999fb
 	golang.org/x/sys v0.0.0-20210301091718-77cc2087c03b // indirect
+	golang.org/x/text v0.3.0
 )
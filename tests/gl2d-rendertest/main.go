@@ -1,20 +1,33 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/png"
+	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/sbreitf1/go-gl-lib/gl2d"
 	"github.com/sbreitf1/go-gl-lib/glui"
+	"github.com/sbreitf1/go-gl-lib/glutil"
 
 	"github.com/go-gl/gl/v2.1/gl"
+	"github.com/go-gl/mathgl/mgl32"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
 )
 
 const (
@@ -27,12 +40,41 @@ const (
 	createMissingImages = false
 )
 
+var (
+	useSoftwareBackend = flag.Bool("software", false, "render headless using the software backend of gl2d")
+)
+
 type testDefinition struct {
 	Name       string
+	RenderFunc func() error
+}
+
+// effectTestDefinition describes a test of the post-processing effects of glui, which require OpenGL. The result is validated by Check instead of a reference image.
+type effectTestDefinition struct {
+	Name string
+	// Layers are entered from bottom to top
+	Layers  []effectTestLayer
+	Effects []glui.Effect
+	Check   func(img *image.RGBA) error
+}
+
+type effectTestLayer struct {
 	RenderFunc func()
+	Effects    []glui.Effect
 }
 
 func main() {
+	flag.Parse()
+
+	if err := checkColorGrading(); err != nil {
+		logrus.Fatalf("color grading: %s", err.Error())
+	}
+
+	if *useSoftwareBackend {
+		runSoftwareTests()
+		return
+	}
+
 	runtime.LockOSThread()
 
 	mainWindow, err := glui.Init(windowWidth, windowHeight, "gl2d-rendertest")
@@ -46,6 +88,122 @@ func main() {
 	}
 	defer gl2d.Terminate()
 
+	tests := getTestDefinitions()
+	effectTests := getEffectTestDefinitions()
+
+	gl.ClearColor(0, 0, 0, 1)
+
+	currentTest := 0
+	currentEffectTest := -1
+	var effectLayers []glui.ContextLayer
+	errorCount := 0
+	mainWindow.EnterLayer(&glui.ContextLayerWrapper{
+		UpdateHandler: func(w *glui.MainWindow, _ float64) {
+			if currentTest < len(tests) {
+				return
+			}
+			if currentEffectTest >= 0 {
+				// the previous test has been checked by the present handler
+				for range effectLayers {
+					w.LeaveUppermostLayer()
+				}
+				effectLayers = effectLayers[:0]
+				w.SetEffects()
+			}
+
+			currentEffectTest++
+			if currentEffectTest >= len(effectTests) {
+				// no tests left
+				w.Close()
+				return
+			}
+			test := effectTests[currentEffectTest]
+			for _, l := range test.Layers {
+				renderFunc := l.RenderFunc
+				layer := &glui.ContextLayerWrapper{
+					RenderHandler: func(w *glui.MainWindow) {
+						gl2d.Begin(w.GetSize())
+						defer gl2d.End()
+						renderFunc()
+					},
+				}
+				w.EnterLayer(layer)
+				w.SetLayerEffects(layer, l.Effects...)
+				effectLayers = append(effectLayers, layer)
+			}
+			w.SetEffects(test.Effects...)
+		},
+		RenderHandler: func(w *glui.MainWindow) {
+			if currentTest >= len(tests) {
+				// effect tests are rendered by their own layers
+				return
+			}
+
+			gl2d.Begin(w.GetSize())
+			defer gl2d.End()
+
+			if err := gl2dTest(func() (*image.RGBA, error) { return getCurrentImageFromOpenGL(w) }, tests[currentTest]); err != nil {
+				logrus.Errorf("test %q failed: %s", tests[currentTest].Name, err.Error())
+				errorCount++
+			}
+			currentTest++
+		},
+	})
+	// effects are applied after all layers have been rendered, so the result is checked right before the frame is presented
+	mainWindow.PresentHandler = func(w *glui.MainWindow) {
+		if currentEffectTest < 0 || currentEffectTest >= len(effectTests) {
+			return
+		}
+		test := effectTests[currentEffectTest]
+		img, err := getCurrentImageFromOpenGL(w)
+		if err == nil {
+			err = test.Check(img)
+		}
+		if err != nil {
+			logrus.Errorf("effect test %q failed: %s", test.Name, err.Error())
+			errorCount++
+		}
+	}
+	mainWindow.Run()
+
+	if errorCount > 0 {
+		gl2d.Terminate()
+		glui.Terminate()
+		logrus.Fatalf("%d of %d tests have failed", errorCount, len(tests)+len(effectTests))
+	} else {
+		logrus.Infof("tests successful, exiting test application")
+	}
+}
+
+func runSoftwareTests() {
+	target := image.NewRGBA(image.Rect(0, 0, windowWidth, windowHeight))
+	if err := gl2d.InitWithBackend(gl2d.NewSoftwareBackend(target)); err != nil {
+		logrus.Fatalf("failed to init gl2d: %s", err.Error())
+	}
+	defer gl2d.Terminate()
+
+	tests := getTestDefinitions()
+
+	errorCount := 0
+	for _, test := range tests {
+		gl2d.Begin(windowWidth, windowHeight)
+		gl2d.Clear(gl2d.Black)
+		if err := gl2dTest(func() (*image.RGBA, error) { return getCurrentImageFromSoftware(target), nil }, test); err != nil {
+			logrus.Errorf("test %q failed: %s", test.Name, err.Error())
+			errorCount++
+		}
+		gl2d.End()
+	}
+
+	if errorCount > 0 {
+		gl2d.Terminate()
+		logrus.Fatalf("%d of %d tests have failed", errorCount, len(tests))
+	} else {
+		logrus.Infof("tests successful, exiting test application")
+	}
+}
+
+func getTestDefinitions() []testDefinition {
 	//TODO test image
 	//TODO test image clipping
 	//TODO test custom fonts
@@ -53,8 +211,8 @@ func main() {
 	//TODO test insane lineWidth parameters
 
 	tests := []testDefinition{
-		{"empty", func() {}},
-		{"colors", func() {
+		{"empty", func() error { return nil }},
+		{"colors", func() error {
 			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{10, 10}, gl2d.Red)
 			gl2d.FillRectangle([2]float32{10, 0}, [2]float32{10, 10}, gl2d.Green)
 			gl2d.FillRectangle([2]float32{20, 0}, [2]float32{10, 10}, gl2d.Blue)
@@ -64,8 +222,9 @@ func main() {
 			gl2d.FillRectangle([2]float32{0, 20}, [2]float32{10, 10}, gl2d.Cyan)
 			gl2d.FillRectangle([2]float32{10, 20}, [2]float32{10, 10}, gl2d.Magenta)
 			gl2d.FillRectangle([2]float32{20, 20}, [2]float32{10, 10}, [4]float32{0.17, 0.89, 0.54, 1})
+			return nil
 		}},
-		{"all-shapes", func() {
+		{"all-shapes", func() error {
 			gl2d.FillCircle([2]float32{50, 50}, 40, gl2d.White)
 			gl2d.DrawCircle([2]float32{150, 50}, 40, 1, gl2d.White)
 			gl2d.FillCircle([2]float32{150, 50}, 0, gl2d.White)
@@ -81,8 +240,9 @@ func main() {
 			gl2d.FillRectangle([2]float32{420.75, 150.5}, [2]float32{80.5, 80.25}, gl2d.White)
 			gl2d.DrawRectangle([2]float32{520.25, 150.75}, [2]float32{80.5, 80.5}, 1.75, gl2d.White)
 			gl2d.FillRectangle([2]float32{650.5, 150.5}, [2]float32{80, 80}, gl2d.White)
+			return nil
 		}},
-		{"text", func() {
+		{"text", func() error {
 			gl2d.DrawString("foo bar", [2]float32{10, 10}, gl2d.DefaultFont(), gl2d.White, nil)
 			gl2d.DrawString("bigger text", [2]float32{10, 40}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 2})
 			gl2d.DrawString("float pos", [2]float32{200.5, 10}, gl2d.DefaultFont(), gl2d.White, nil)
@@ -95,16 +255,18 @@ func main() {
 			gl2d.DrawString("measure this!", [2]float32{150, 100}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 2})
 			//TODO tabs
 			//TODO center
+			return nil
 		}},
-		{"complex", func() {
+		{"complex", func() error {
 			gl2d.DrawCircle([2]float32{100, 100}, 50, 1, gl2d.Green)
 			gl2d.FillCircle([2]float32{500, 500}, 50, gl2d.Blue)
 			gl2d.FillRectangle([2]float32{100, 250}, [2]float32{300, 200}, gl2d.Red)
 			gl2d.DrawLine([2]float32{44, 399}, [2]float32{690, 549}, 13, gl2d.Yellow)
 			gl2d.FillRectangle([2]float32{377, 232}, [2]float32{142, 346}, gl2d.White.Alpha(0.5))
 			gl2d.DrawLine([2]float32{451, 218}, [2]float32{536, 367}, 2, gl2d.Green.MulColor(0.5))
+			return nil
 		}},
-		{"clip-shapes", func() {
+		{"clip-shapes", func() error {
 			gl2d.SetClipRect(gl2d.Quad{Left: 100, Right: 500, Top: 100, Bottom: 400})
 			gl2d.FillRectangle([2]float32{50, 50}, [2]float32{100, 100}, gl2d.White)
 			gl2d.DrawRectangle([2]float32{450, 350}, [2]float32{100, 100}, 3, gl2d.White)
@@ -117,52 +279,1356 @@ func main() {
 			gl2d.FillCircle([2]float32{600, 400}, 40, gl2d.Blue)
 			gl2d.SetClipRect(gl2d.Quad{Left: 50, Right: 100, Top: 450, Bottom: 500})
 			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{800, 600}, gl2d.Red)
+			return nil
 		}},
-		{"clip-text", func() {
+		{"clip-text", func() error {
 			gl2d.SetClipRect(gl2d.Quad{Left: 98, Right: 515, Top: 101, Bottom: 126})
 			gl2d.DrawString("out of bounds", [2]float32{80, 80}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 5})
 			gl2d.SetClipRect(gl2d.Quad{Left: 100, Right: 130, Top: 180, Bottom: 200})
 			gl2d.DrawString("#", [2]float32{80, 130}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 10})
+			return nil
 		}},
-	}
+		{"transform", func() error {
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{150, 150})
+			gl2d.Rotate(math.Pi / 6)
+			gl2d.FillRectangle([2]float32{-50, -30}, [2]float32{100, 60}, gl2d.Red)
+			gl2d.DrawRectangle([2]float32{-70, -50}, [2]float32{140, 100}, 2, gl2d.White)
+			gl2d.DrawLine([2]float32{-80, 0}, [2]float32{80, 0}, 3, gl2d.Yellow)
+			gl2d.PopTransform()
 
-	gl.ClearColor(0, 0, 0, 1)
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{400, 150})
+			gl2d.Scale([2]float32{2, 0.5})
+			gl2d.FillCircle([2]float32{0, 0}, 50, gl2d.Blue)
+			gl2d.DrawCircle([2]float32{0, 0}, 60, 4, gl2d.Green)
+			gl2d.PopTransform()
 
-	currentTest := 0
-	errorCount := 0
-	mainWindow.EnterLayer(&glui.ContextLayerWrapper{
-		RenderHandler: func(w *glui.MainWindow) {
-			if currentTest >= len(tests) {
-				// no tests left
-				w.Close()
-				return
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{100, 350})
+			gl2d.Scale([2]float32{3, 3})
+			gl2d.DrawString("scaled", [2]float32{0, 0}, gl2d.DefaultFont(), gl2d.White, nil)
+			gl2d.PopTransform()
+
+			gl2d.SetClipRect(gl2d.Quad{Left: 450, Right: 650, Top: 300, Bottom: 500})
+			gl2d.RotateAround([2]float32{550, 400}, math.Pi/4)
+			gl2d.FillRectangle([2]float32{450, 300}, [2]float32{200, 200}, gl2d.Magenta.Alpha(0.5))
+			gl2d.ResetTransform()
+			gl2d.ResetClipRect()
+			return nil
+		}},
+		{"clip-stack", func() error {
+			gl2d.PushClipRect(gl2d.Quad{Left: 100, Right: 500, Top: 100, Bottom: 400})
+			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{800, 600}, gl2d.DarkGray)
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{400, 300})
+			gl2d.PushClipRect(gl2d.Quad{Left: 0, Right: 200, Top: 0, Bottom: 200})
+			gl2d.FillCircle([2]float32{50, 50}, 80, gl2d.Red)
+			gl2d.DrawString("clipped", [2]float32{40, 80}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 3})
+			gl2d.PopClipRect()
+			gl2d.PopTransform()
+			gl2d.FillCircle([2]float32{150, 150}, 80, gl2d.Green)
+			gl2d.PushClipRect(gl2d.Quad{Left: 600, Right: 700, Top: 0, Bottom: 600})
+			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{800, 600}, gl2d.Blue)
+			gl2d.PopClipRect()
+			gl2d.PopClipRect()
+			gl2d.FillCircle([2]float32{650, 450}, 50, gl2d.Yellow)
+			return nil
+		}},
+		{"clip-mask", func() error {
+			gl2d.PushClipMask(func() {
+				gl2d.FillCircle([2]float32{200, 200}, 150, gl2d.White)
+			})
+			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{800, 600}, gl2d.Red)
+			gl2d.DrawString("masked text", [2]float32{60, 180}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 4})
+			gl2d.PushClipMask(func() {
+				gl2d.FillRectangle([2]float32{200, 0}, [2]float32{400, 600}, gl2d.White)
+				gl2d.FillCircle([2]float32{100, 100}, 60, gl2d.White)
+			})
+			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{800, 600}, gl2d.Blue.Alpha(0.5))
+			gl2d.PopClipMask()
+			gl2d.DrawLine([2]float32{0, 0}, [2]float32{400, 400}, 9, gl2d.Green)
+			gl2d.PopClipMask()
+			gl2d.DrawLine([2]float32{400, 0}, [2]float32{0, 400}, 5, gl2d.Yellow)
+			return nil
+		}},
+		{"paths", func() error {
+			star := gl2d.NewPath()
+			for i := 0; i < 5; i++ {
+				angle := float32(i)*4*math.Pi/5 - math.Pi/2
+				star.LineTo([2]float32{120 + 100*float32(math.Cos(float64(angle))), 130 + 100*float32(math.Sin(float64(angle)))})
 			}
+			star.Close()
+			gl2d.FillPath(star, gl2d.FillRuleNonZero, gl2d.Red)
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{230, 0})
+			gl2d.FillPath(star, gl2d.FillRuleEvenOdd, gl2d.Green)
+			gl2d.PopTransform()
 
-			gl2d.Begin(w.GetSize())
-			defer gl2d.End()
+			curves := gl2d.NewPath()
+			curves.MoveTo([2]float32{480, 40})
+			curves.QuadTo([2]float32{600, 0}, [2]float32{700, 100})
+			curves.CubicTo([2]float32{800, 200}, [2]float32{500, 250}, [2]float32{600, 150})
+			curves.ArcTo([2]float32{450, 150}, [2]float32{450, 40}, 40)
+			curves.Close()
+			curves.Circle([2]float32{560, 110}, 25)
+			gl2d.FillPath(curves, gl2d.FillRuleEvenOdd, gl2d.Blue)
+			gl2d.StrokePath(curves, gl2d.StrokeStyle{Width: 2}, gl2d.White)
 
-			if err := gl2dTest(w, tests[currentTest]); err != nil {
-				logrus.Errorf("test %q failed: %s", tests[currentTest].Name, err.Error())
-				errorCount++
+			zigzag := gl2d.NewPath()
+			zigzag.MoveTo([2]float32{40, 300})
+			zigzag.LineTo([2]float32{120, 260})
+			zigzag.LineTo([2]float32{160, 340})
+			zigzag.LineTo([2]float32{220, 270})
+			for i, join := range []gl2d.LineJoin{gl2d.LineJoinMiter, gl2d.LineJoinRound, gl2d.LineJoinBevel} {
+				gl2d.PushTransform()
+				gl2d.Translate([2]float32{float32(i) * 240, 0})
+				gl2d.StrokePath(zigzag, gl2d.StrokeStyle{Width: 20, Join: join, Cap: gl2d.LineCap(i)}, gl2d.Yellow)
+				gl2d.StrokePath(zigzag, gl2d.StrokeStyle{Width: 1}, gl2d.Black)
+				gl2d.PopTransform()
 			}
-			currentTest++
+
+			dashed := gl2d.NewPath()
+			dashed.Rectangle([2]float32{40, 400}, [2]float32{300, 150})
+			gl2d.StrokePath(dashed, gl2d.StrokeStyle{Width: 4, Dashes: []float32{20, 10, 5, 10}}, gl2d.White)
+			arc := gl2d.NewPath()
+			arc.Arc([2]float32{560, 480}, 90, 0, 1.5*math.Pi)
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{560, 480}, 0.3)
+			gl2d.StrokePath(arc, gl2d.StrokeStyle{Width: 12, Cap: gl2d.LineCapRound, Dashes: []float32{0, 24}}, gl2d.Green.Alpha(0.7))
+			gl2d.StrokePath(arc, gl2d.StrokeStyle{Width: 2, Dashes: []float32{30}, DashOffset: 15}, gl2d.Red)
+			gl2d.PopTransform()
+			return nil
+		}},
+		{"polygons", func() error {
+			graph := make([]mgl32.Vec2, 0)
+			for x := 0; x <= 700; x += 35 {
+				graph = append(graph, mgl32.Vec2{50 + float32(x), 150 - 100*float32(math.Sin(float64(x)/80))})
+			}
+			gl2d.DrawPolyline(graph, 12, gl2d.Green.Alpha(0.5))
+			gl2d.DrawPolyline(graph, 1, gl2d.White)
+
+			hexagon := make([]mgl32.Vec2, 6)
+			for i := range hexagon {
+				angle := float64(i) * math.Pi / 3
+				hexagon[i] = mgl32.Vec2{200 + 120*float32(math.Cos(angle)), 420 + 120*float32(math.Sin(angle))}
+			}
+			gl2d.FillPolygon(hexagon, gl2d.Blue)
+			gl2d.DrawPolygon(hexagon, 8, gl2d.Red.Alpha(0.5))
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{560, 420}, 0.4)
+			gl2d.FillPolygon([]mgl32.Vec2{{460, 340}, {660, 340}, {460, 500}, {660, 500}}, gl2d.Yellow)
+			gl2d.DrawPolygon([]mgl32.Vec2{{460, 340}, {660, 340}, {460, 500}, {660, 500}}, 3, gl2d.White)
+			gl2d.PopTransform()
+
+			// polygons are tessellated into the batch, so consecutive polygons only require a single draw call
+			gl2d.Flush()
+			drawCalls := gl2d.DrawCalls()
+			for i := 0; i < 10; i++ {
+				x := 380 + 40*float32(i)
+				gl2d.FillPolygon([]mgl32.Vec2{{x, 590}, {x + 15, 560}, {x + 30, 590}}, gl2d.Red)
+				gl2d.DrawPolyline([]mgl32.Vec2{{x, 575}, {x + 10, 565}, {x + 20, 585}, {x + 30, 575}}, 2, gl2d.White.Alpha(0.7))
+			}
+			gl2d.Flush()
+			if n := gl2d.DrawCalls() - drawCalls; n != 1 {
+				return fmt.Errorf("polygons required %d draw calls instead of 1", n)
+			}
+			return nil
+		}},
+		{"rounded-shapes", func() error {
+			gl2d.FillRoundedRectangle([2]float32{20, 20}, [2]float32{200, 120}, gl2d.UniformCornerRadii(20), gl2d.Red)
+			gl2d.FillRoundedRectangle([2]float32{240, 20}, [2]float32{200, 120}, gl2d.CornerRadii{TopLeft: 60, BottomRight: 30}, gl2d.Green)
+			gl2d.DrawRoundedRectangle([2]float32{460, 20}, [2]float32{200, 120}, gl2d.CornerRadii{TopLeft: 10, TopRight: 30, BottomRight: 50, BottomLeft: 500}, 4, gl2d.White)
+			gl2d.DrawRoundedRectangle([2]float32{680, 20}, [2]float32{100, 100}, gl2d.UniformCornerRadii(50), 1, gl2d.Yellow)
+
+			gl2d.FillEllipse([2]float32{120, 260}, [2]float32{100, 50}, gl2d.Blue)
+			gl2d.DrawEllipse([2]float32{120, 260}, [2]float32{100, 50}, 3, gl2d.White)
+			gl2d.FillEllipse([2]float32{340, 260}, [2]float32{50, 50}, gl2d.Green)
+			gl2d.FillCircle([2]float32{340, 260}, 50, gl2d.Red.Alpha(0.5))
+			gl2d.DrawEllipse([2]float32{550, 260}, [2]float32{30, 90}, 8, gl2d.Yellow.Alpha(0.7))
+
+			gl2d.DrawArc([2]float32{120, 460}, 80, 0, 1.5*math.Pi, 10, gl2d.Red)
+			gl2d.DrawArc([2]float32{120, 460}, 50, -math.Pi/4, math.Pi/4, 1, gl2d.White)
+			gl2d.FillPie([2]float32{340, 460}, 90, -math.Pi/2, 0.3, gl2d.Green)
+			gl2d.FillPie([2]float32{340, 460}, 90, 0.3, 1.2, gl2d.Blue)
+			gl2d.FillPie([2]float32{340, 460}, 90, 1.2, 1.5*math.Pi, gl2d.Yellow.Alpha(0.8))
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{620, 460}, 0.5)
+			gl2d.FillRoundedRectangle([2]float32{540, 400}, [2]float32{160, 120}, gl2d.UniformCornerRadii(30), gl2d.Blue)
+			gl2d.FillEllipse([2]float32{620, 460}, [2]float32{70, 30}, gl2d.White)
+			gl2d.FillPie([2]float32{620, 460}, 50, 0, 4, gl2d.Red)
+			gl2d.PopTransform()
+			return nil
+		}},
+		{"gradients", func() error {
+			rainbow := []gl2d.GradientStop{{Offset: 0, Color: gl2d.Red}, {Offset: 0.5, Color: gl2d.Green}, {Offset: 1, Color: gl2d.Blue}}
+			gl2d.FillRectanglePaint([2]float32{20, 20}, [2]float32{360, 80}, gl2d.LinearGradient{Start: [2]float32{40, 0}, End: [2]float32{360, 0}, Stops: rainbow})
+			gl2d.FillRoundedRectanglePaint([2]float32{420, 20}, [2]float32{360, 80}, gl2d.UniformCornerRadii(20), gl2d.LinearGradient{
+				Start: [2]float32{0, 20},
+				End:   [2]float32{0, 100},
+				Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.White}, {Offset: 1, Color: gl2d.White.Alpha(0)}},
+			})
+
+			gl2d.FillCirclePaint([2]float32{100, 220}, 80, gl2d.RadialGradient{Center: [2]float32{70, 190}, Radius: 110, Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.White}, {Offset: 1, Color: gl2d.Blue}}})
+			gl2d.FillPiePaint([2]float32{300, 220}, 80, 0, 5, gl2d.ConicGradient{Center: [2]float32{300.5, 220.5}, Stops: rainbow})
+			gl2d.FillEllipsePaint([2]float32{500, 220}, [2]float32{80, 50}, gl2d.ConicGradient{Center: [2]float32{500.5, 220.5}, StartAngle: -math.Pi / 2, Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.Yellow}, {Offset: 0.5, Color: gl2d.Red}, {Offset: 1, Color: gl2d.Yellow}}})
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{690, 220}, 0.6)
+			gl2d.FillPolygonPaint([]mgl32.Vec2{{630, 160}, {750, 160}, {750, 280}, {630, 280}}, gl2d.LinearGradient{Start: [2]float32{630, 0}, End: [2]float32{750, 0}, Stops: rainbow})
+			gl2d.PopTransform()
+
+			gl2d.DrawString("gradient text", [2]float32{20, 320}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{
+				Scale: 5,
+				Paint: gl2d.LinearGradient{Start: [2]float32{0, 330}, End: [2]float32{0, 400}, Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.Yellow}, {Offset: 1, Color: gl2d.Red}}},
+			})
+
+			gl2d.PushClipMask(func() {
+				gl2d.FillRectanglePaint([2]float32{20, 450}, [2]float32{760, 120}, gl2d.LinearGradient{Start: [2]float32{20, 0}, End: [2]float32{780, 0}, Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.Black.Alpha(0)}, {Offset: 1, Color: gl2d.Black.Alpha(0)}}})
+			})
+			gl2d.FillRectanglePaint([2]float32{0, 400}, [2]float32{800, 200}, gl2d.LinearGradient{Start: [2]float32{0, 0}, End: [2]float32{800, 0}, Stops: rainbow})
+			gl2d.PopClipMask()
+			return nil
+		}},
+		{"patterns", func() error {
+			checker := image.NewRGBA(image.Rect(0, 0, 8, 8))
+			hatch := image.NewRGBA(image.Rect(0, 0, 8, 8))
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					if (x < 4) != (y < 4) {
+						checker.Set(x, y, color.RGBA{255, 255, 255, 255})
+					} else {
+						checker.Set(x, y, color.RGBA{255, 0, 0, 255})
+					}
+					if (x+y)%8 < 2 {
+						hatch.Set(x, y, color.RGBA{255, 255, 0, 255})
+					}
+					// gradient in lower right quadrant to show mirroring
+					if x >= 4 && y >= 4 {
+						checker.Set(x, y, color.RGBA{0, uint8(60 * (x - 3)), uint8(60 * (y - 3)), 255})
+					}
+				}
+			}
+			checkerTex, err := gl2d.NewTexture(checker, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterNearest))
+			if err != nil {
+				return err
+			}
+			defer checkerTex.Destroy()
+			hatchTex, err := gl2d.NewTexture(hatch, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterNearest))
+			if err != nil {
+				return err
+			}
+			defer hatchTex.Destroy()
+
+			gl2d.FillRectanglePaint([2]float32{20, 20}, [2]float32{240, 160}, gl2d.Pattern{Texture: checkerTex, Scale: [2]float32{4, 4}})
+			gl2d.FillRectanglePaint([2]float32{280, 20}, [2]float32{240, 160}, gl2d.Pattern{Texture: checkerTex, Scale: [2]float32{4, 4}, Offset: [2]float32{280, 20}, Wrap: glutil.TextureWrapMirroredRepeat})
+			gl2d.FillRectanglePaint([2]float32{540, 20}, [2]float32{240, 160}, gl2d.Pattern{Texture: checkerTex, Scale: [2]float32{10, 10}, Offset: [2]float32{620, 60}, Wrap: glutil.TextureWrapClampToBorder})
+			gl2d.DrawRectangle([2]float32{540, 20}, [2]float32{240, 160}, 1, gl2d.White)
+
+			gl2d.FillCirclePaint([2]float32{140, 320}, 100, gl2d.Pattern{Texture: hatchTex, Scale: [2]float32{2, 2}})
+			gl2d.DrawCircle([2]float32{140, 320}, 100, 2, gl2d.Yellow)
+			gl2d.FillCirclePaint([2]float32{400, 320}, 100, gl2d.Pattern{Texture: checkerTex, Scale: [2]float32{3, 3}, Rotation: math.Pi / 6, Offset: [2]float32{400, 320}, Wrap: glutil.TextureWrapClampToEdge})
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{650, 400}, 0.3)
+			gl2d.FillPolygonPaint([]mgl32.Vec2{{650, 230}, {770, 450}, {530, 450}}, gl2d.Pattern{Texture: checkerTex, Scale: [2]float32{5, 5}, Rotation: 0.2})
+			gl2d.PopTransform()
+
+			gl2d.FillRoundedRectanglePaint([2]float32{20, 460}, [2]float32{480, 120}, gl2d.UniformCornerRadii(30), gl2d.Pattern{Texture: hatchTex, Scale: [2]float32{3, 1}, Wrap: glutil.TextureWrapRepeat})
+			return nil
+		}},
+		{"blend-modes", func() error {
+			gl2d.FillRectanglePaint([2]float32{0, 0}, [2]float32{800, 600}, gl2d.LinearGradient{Start: [2]float32{0, 0}, End: [2]float32{800, 0}, Stops: []gl2d.GradientStop{{Offset: 0, Color: gl2d.Black}, {Offset: 1, Color: gl2d.White}}})
+			gl2d.FillRectangle([2]float32{0, 300}, [2]float32{800, 300}, gl2d.Color{0.2, 0.4, 0.8, 1})
+
+			modes := []gl2d.BlendMode{gl2d.BlendNormal, gl2d.BlendAdditive, gl2d.BlendMultiply, gl2d.BlendScreen, gl2d.BlendPremultiplied, gl2d.BlendReplace}
+			for i, mode := range modes {
+				x := 70 + float32(i)*130
+				gl2d.PushBlendMode(mode)
+				for j, y := range []float32{150, 450} {
+					c := gl2d.Color{1, 0.5, 0, 0.6}
+					if mode == gl2d.BlendPremultiplied {
+						c = gl2d.Color{0.6, 0.3, 0, 0.6}
+					}
+					gl2d.FillCircle([2]float32{x, y - 60}, 50, c)
+					gl2d.FillRectangle([2]float32{x - 50, y + 10}, [2]float32{100, 60}, gl2d.Color{0.2, 0.8, 1, 1})
+					gl2d.DrawLine([2]float32{x - 50, y + 100}, [2]float32{x + 50, y + 120 - float32(j)*40}, 6, gl2d.White.Alpha(0.5))
+				}
+				gl2d.PopBlendMode()
+			}
+			if gl2d.CurrentBlendMode() != gl2d.BlendNormal {
+				return fmt.Errorf("blend mode not restored")
+			}
+			return nil
+		}},
+		{"render-target", func() error {
+			panel, err := gl2d.NewRenderTarget(200, 150, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+			if err != nil {
+				return err
+			}
+			defer panel.Destroy()
+			badge, err := gl2d.NewRenderTarget(60, 60, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+			if err != nil {
+				return err
+			}
+			defer badge.Destroy()
+
+			gl2d.Clear(gl2d.Color{0.1, 0.1, 0.15, 1})
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{400, 0})
+			gl2d.PushClipRect(gl2d.Quad{Left: -400, Right: 400, Top: 0, Bottom: 580})
+			outerClipRect := gl2d.ClipRect()
+
+			gl2d.BeginTarget(panel)
+			gl2d.Clear(gl2d.Color{})
+			gl2d.FillRoundedRectangle([2]float32{0, 0}, [2]float32{200, 150}, gl2d.UniformCornerRadii(16), gl2d.Color{0.9, 0.9, 0.95, 0.9})
+			gl2d.FillRectangle([2]float32{10, 10}, [2]float32{180, 30}, gl2d.Color{0.2, 0.4, 0.8, 1})
+			gl2d.DrawLine([2]float32{20, 60}, [2]float32{180, 130}, 4, gl2d.Red)
+			gl2d.FillCircle([2]float32{150, 100}, 30, gl2d.Green.Alpha(0.7))
+
+			// nested target inside another target
+			gl2d.BeginTarget(badge)
+			gl2d.Clear(gl2d.Color{})
+			gl2d.FillCircle([2]float32{30, 30}, 29, gl2d.Color{1, 0.8, 0, 1})
+			gl2d.FillPie([2]float32{30, 30}, 20, 0, math.Pi/2, gl2d.Black)
+			gl2d.EndTarget()
+			gl2d.DrawImage(badge.Texture, gl2d.Quad{Left: 20, Right: 80, Top: 70, Bottom: 130})
+			gl2d.EndTarget()
+
+			if gl2d.ClipRect() != outerClipRect {
+				return fmt.Errorf("clip rect not restored")
+			}
+			gl2d.FillRectangle([2]float32{-400, 0}, [2]float32{20, 20}, gl2d.White)
+			gl2d.PopClipRect()
+			gl2d.PopTransform()
+
+			gl2d.DrawImage(panel.Texture, gl2d.Quad{Left: 20, Right: 220, Top: 40, Bottom: 190})
+			gl2d.DrawImage(panel.Texture, gl2d.Quad{Left: 260, Right: 560, Top: 40, Bottom: 265})
+			gl2d.DrawColorizedImage(panel.Texture, gl2d.Quad{Left: 600, Right: 700, Top: 40, Bottom: 115}, gl2d.Color{1, 1, 1, 0.5})
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{400, 430}, math.Pi/8)
+			gl2d.DrawImage(panel.Texture, gl2d.Quad{Left: 300, Right: 500, Top: 355, Bottom: 505})
+			gl2d.PopTransform()
+			gl2d.DrawImage(badge.Texture, gl2d.Quad{Left: 40, Right: 160, Top: 400, Bottom: 520})
+			// make sure the render targets are used before they are destroyed
+			gl2d.Flush()
+			return nil
+		}},
+		{"shadows", func() error {
+			gl2d.Clear(gl2d.Color{0.85, 0.85, 0.9, 1})
+
+			gl2d.DrawRectangleShadow([2]float32{40, 40}, [2]float32{200, 120}, gl2d.Shadow{Offset: [2]float32{6, 8}, Blur: 16, Color: gl2d.Black.Alpha(0.5)})
+			gl2d.FillRectangle([2]float32{40, 40}, [2]float32{200, 120}, gl2d.White)
+
+			gl2d.DrawRoundedRectangleShadow([2]float32{300, 40}, [2]float32{200, 120}, gl2d.UniformCornerRadii(20), gl2d.Shadow{Offset: [2]float32{0, 10}, Blur: 30, Spread: -4, Color: gl2d.Black.Alpha(0.6)})
+			gl2d.FillRoundedRectangle([2]float32{300, 40}, [2]float32{200, 120}, gl2d.UniformCornerRadii(20), gl2d.White)
+
+			radii := gl2d.CornerRadii{TopLeft: 40, TopRight: 0, BottomRight: 40, BottomLeft: 8}
+			gl2d.DrawRoundedRectangleShadow([2]float32{560, 40}, [2]float32{200, 120}, radii, gl2d.Glow(20, 4, gl2d.Color{0, 0.6, 1, 1}))
+			gl2d.FillRoundedRectangle([2]float32{560, 40}, [2]float32{200, 120}, radii, gl2d.Color{0.1, 0.1, 0.2, 1})
+
+			// hard shadow without blur
+			gl2d.DrawRectangleShadow([2]float32{40, 220}, [2]float32{120, 80}, gl2d.Shadow{Offset: [2]float32{10, 10}, Color: gl2d.DarkGray})
+			gl2d.FillRectangle([2]float32{40, 220}, [2]float32{120, 80}, gl2d.Color{1, 0.6, 0.2, 1})
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{300, 260}, math.Pi/12)
+			gl2d.DrawRoundedRectangleShadow([2]float32{220, 220}, [2]float32{160, 80}, gl2d.UniformCornerRadii(12), gl2d.Shadow{Offset: [2]float32{4, 12}, Blur: 12, Color: gl2d.Black.Alpha(0.4)})
+			gl2d.FillRoundedRectangle([2]float32{220, 220}, [2]float32{160, 80}, gl2d.UniformCornerRadii(12), gl2d.Color{0.3, 0.8, 0.4, 1})
+			gl2d.PopTransform()
+
+			gl2d.DrawString("drop shadow", [2]float32{440, 230}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 2, Shadows: []gl2d.Shadow{{Offset: [2]float32{2, 3}, Blur: 4, Color: gl2d.Black.Alpha(0.8)}}})
+			gl2d.DrawString("hard shadow", [2]float32{440, 280}, gl2d.DefaultFont(), gl2d.Yellow, &gl2d.DrawStringOptions{Scale: 2, Shadows: []gl2d.Shadow{{Offset: [2]float32{2, 2}, Color: gl2d.Black}}})
+
+			gl2d.FillRectangle([2]float32{0, 340}, [2]float32{800, 260}, gl2d.Color{0.05, 0.05, 0.1, 1})
+			gl2d.DrawString("glowing text", [2]float32{40, 380}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 4, Shadows: []gl2d.Shadow{gl2d.Glow(12, 2, gl2d.Color{1, 0.2, 0.8, 1}), gl2d.Glow(4, 0, gl2d.Color{1, 0.6, 0.9, 1})}})
+			gl2d.PushTransform()
+			gl2d.Translate([2]float32{460, 480})
+			gl2d.Scale([2]float32{3, 3})
+			gl2d.DrawString("zoomed", [2]float32{0, 0}, gl2d.DefaultFont(), gl2d.Color{0.6, 1, 0.6, 1}, &gl2d.DrawStringOptions{Shadows: []gl2d.Shadow{gl2d.Glow(3, 1, gl2d.Green)}})
+			gl2d.PopTransform()
+
+			// the blurred mask of the first drop shadow is reused for another color and location
+			gl2d.DrawString("drop shadow", [2]float32{40, 520}, gl2d.DefaultFont(), gl2d.White, &gl2d.DrawStringOptions{Scale: 2, Shadows: []gl2d.Shadow{{Offset: [2]float32{2, 3}, Blur: 4, Color: gl2d.Red}}})
+			// a spread without blur outlines the text with round corners
+			gl2d.DrawString("outline", [2]float32{480, 400}, gl2d.DefaultFont(), gl2d.Black, &gl2d.DrawStringOptions{Scale: 3, Shadows: []gl2d.Shadow{{Spread: 4, Color: gl2d.Color{1, 0.7, 0.2, 1}}}})
+			return nil
+		}},
+		{"ttf-fonts", func() error {
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 20})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+			// a single small page forces the eviction of glyphs between strings
+			tiny, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 16, PageSize: 128, MaxPages: 1})
+			if err != nil {
+				return err
+			}
+			defer tiny.Destroy()
+
+			gl2d.DrawString("Hello TrueType! AVAWAY Tj", [2]float32{20, 20}, regular, gl2d.White, nil)
+			gl2d.DrawString("Ελληνικά: Καλημέρα κόσμε", [2]float32{20, 60}, regular, gl2d.Color{0.6, 0.8, 1, 1}, nil)
+			gl2d.DrawString("Кириллица: Привет, мир", [2]float32{20, 100}, regular, gl2d.Color{1, 0.8, 0.6, 1}, nil)
+			gl2d.DrawString("Ünïcödé ßøñ ŁĳŒ", [2]float32{20, 140}, regular, gl2d.Yellow, &gl2d.DrawStringOptions{Scale: 1.5})
+			size := gl2d.MeasureString("measured width", regular, nil)
+			gl2d.FillRectangle([2]float32{20, 200}, size, gl2d.Red.Alpha(0.5))
+			gl2d.DrawString("measured width", [2]float32{20, 200}, regular, gl2d.White, nil)
+
+			lines := []string{"abcdefghijklm", "nopqrstuvwxyz", "ABCDEFGHIJKLM", "NOPQRSTUVWXYZ", "0123456789+-*", "абвгдежзийклм", "αβγδεζηθικλμν"}
+			for i, line := range lines {
+				gl2d.DrawString(line, [2]float32{420, 20 + float32(i)*26}, tiny, gl2d.White, nil)
+			}
+			// glyphs that have been evicted before are rasterized again
+			gl2d.DrawString(lines[0], [2]float32{420, 220}, tiny, gl2d.Green, nil)
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{400, 420}, -math.Pi/16)
+			gl2d.DrawString("Rotated Ωμέγα Шрифт", [2]float32{200, 400}, regular, gl2d.Cyan, &gl2d.DrawStringOptions{Scale: 2, Shadows: []gl2d.Shadow{{Offset: [2]float32{3, 3}, Blur: 6, Color: gl2d.Blue}}})
+			gl2d.PopTransform()
+			gl2d.Flush()
+			return nil
+		}},
+		{"sdf-text", func() error {
+			basic, err := gl2d.NewSDFFontFromFace(basicfont.Face7x13, 3)
+			if err != nil {
+				return err
+			}
+			defer basic.Destroy()
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 16, SDF: true})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+
+			// compare to the blurry scaled bitmap text of the "text" test
+			gl2d.DrawString("bigger text", [2]float32{10, 10}, basic, gl2d.White, &gl2d.DrawStringOptions{Scale: 4})
+			gl2d.DrawString("normal size", [2]float32{10, 70}, basic, gl2d.White, nil)
+			gl2d.DrawString("SDF TrueType", [2]float32{10, 100}, regular, gl2d.White, nil)
+			gl2d.DrawString("Crisp Ωμέγα", [2]float32{10, 130}, regular, gl2d.Yellow, &gl2d.DrawStringOptions{Scale: 5})
+			gl2d.DrawString("Outline", [2]float32{10, 240}, regular, gl2d.White, &gl2d.DrawStringOptions{Scale: 4, OutlineWidth: 2, OutlineColor: gl2d.Red})
+			gl2d.DrawString("Shadow", [2]float32{400, 240}, regular, gl2d.Cyan, &gl2d.DrawStringOptions{Scale: 4, Shadows: []gl2d.Shadow{{Offset: [2]float32{4, 4}, Blur: 6, Color: gl2d.Blue}}})
+			size := gl2d.MeasureString("measured", regular, &gl2d.DrawStringOptions{Scale: 3})
+			gl2d.FillRectangle([2]float32{10, 340}, size, gl2d.Red.Alpha(0.5))
+			gl2d.DrawString("measured", [2]float32{10, 340}, regular, gl2d.White, &gl2d.DrawStringOptions{Scale: 3})
+
+			gl2d.PushTransform()
+			gl2d.RotateAround([2]float32{550, 450}, math.Pi/12)
+			gl2d.Scale([2]float32{3, 3})
+			gl2d.DrawString("zoomed", [2]float32{130, 140}, basic, gl2d.Green, &gl2d.DrawStringOptions{OutlineWidth: 1, OutlineColor: gl2d.White.Alpha(0.5)})
+			gl2d.PopTransform()
+			return nil
+		}},
+		{"text-layout", func() error {
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 14})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+
+			text := "The quick brown fox jumps over the lazy dog. Pack my box with five dozen liquor jugs.\nSupercalifragilisticexpialidocious words break anywhere."
+			boxSize := mgl32.Vec2{240, 150}
+			box := func(pos mgl32.Vec2, opts *gl2d.DrawStringOptions) error {
+				gl2d.DrawRectangle(pos, boxSize, 1, gl2d.Gray)
+				// the measured size must match the rendered lines
+				layout := gl2d.LayoutText(text, regular, opts)
+				size := gl2d.MeasureString(text, regular, opts)
+				if size != layout.Size {
+					return fmt.Errorf("MeasureString returned %v instead of %v", size, layout.Size)
+				}
+				for _, line := range layout.Lines {
+					gl2d.FillRectangle(pos.Add(line.Pos), mgl32.Vec2{line.Width, regular.LineHeight()}, gl2d.Blue.Alpha(0.3))
+				}
+				gl2d.DrawTextLayout(layout, pos, gl2d.White)
+				return nil
+			}
+
+			boxes := []struct {
+				pos  mgl32.Vec2
+				opts *gl2d.DrawStringOptions
+			}{
+				{mgl32.Vec2{10, 10}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Wrap: true}},
+				{mgl32.Vec2{275, 10}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Wrap: true, Align: gl2d.TextAlignCenter, VerticalAlign: gl2d.VerticalAlignMiddle}},
+				{mgl32.Vec2{540, 10}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Wrap: true, Align: gl2d.TextAlignRight, VerticalAlign: gl2d.VerticalAlignBottom}},
+				{mgl32.Vec2{10, 200}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Wrap: true, Align: gl2d.TextAlignJustify}},
+				{mgl32.Vec2{275, 200}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Wrap: true, LineSpacing: 1.5, Ellipsis: "..."}},
+				{mgl32.Vec2{540, 200}, &gl2d.DrawStringOptions{MaxWidth: boxSize[0], MaxHeight: boxSize[1], Ellipsis: "...", Align: gl2d.TextAlignCenter}},
+			}
+			for _, b := range boxes {
+				if err := box(b.pos, b.opts); err != nil {
+					return err
+				}
+			}
+
+			gl2d.DrawString("Tabs:\tone\ttwo\nline\twith\ttabs", [2]float32{10, 400}, gl2d.DefaultFont(), gl2d.White, nil)
+			gl2d.DrawString("Two lines limited\nto one line", [2]float32{10, 450}, gl2d.DefaultFont(), gl2d.Yellow, &gl2d.DrawStringOptions{MaxLines: 1, Ellipsis: "..."})
+			gl2d.DrawString("Scaled and wrapped text", [2]float32{300, 400}, gl2d.DefaultFont(), gl2d.Green, &gl2d.DrawStringOptions{Scale: 2, MaxWidth: 200, Wrap: true, Align: gl2d.TextAlignCenter, RoundPos: true})
+			gl2d.DrawRectangle([2]float32{10, 500}, [2]float32{100, 60}, 1, gl2d.Gray)
+			gl2d.DrawString("Averyveryverylongword breaks between runes", [2]float32{10, 500}, regular, gl2d.White, &gl2d.DrawStringOptions{MaxWidth: 100, Wrap: true})
+
+			// an ellipsized line must not ellipsize the last line when no lines are omitted
+			layout := gl2d.LayoutText("a very long first line here\nshort", regular, &gl2d.DrawStringOptions{MaxWidth: 80, Ellipsis: "..."})
+			lines := make([]string, len(layout.Lines))
+			for i, line := range layout.Lines {
+				for _, g := range line.Glyphs {
+					lines[i] += string(g.Rune)
+				}
+			}
+			if len(lines) != 2 || !strings.HasSuffix(lines[0], "...") || lines[1] != "short" {
+				return fmt.Errorf("ellipsized lines are %q", lines)
+			}
+			return nil
+		}},
+		{"rich-text", func() error {
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 16})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+			bold, err := gl2d.NewFontFromData(gobold.TTF, &gl2d.FontOptions{Size: 16})
+			if err != nil {
+				return err
+			}
+			defer bold.Destroy()
+
+			icon := image.NewRGBA(image.Rect(0, 0, 16, 16))
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					if dx, dy := x-8, y-8; dx*dx+dy*dy < 56 {
+						icon.Set(x, y, color.RGBA{255, 200, 0, 255})
+					}
+				}
+			}
+			iconTex, err := gl2d.NewTexture(icon, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear))
+			if err != nil {
+				return err
+			}
+			defer iconTex.Destroy()
+
+			res := &gl2d.RichTextResources{
+				Fonts:     map[string]*gl2d.Font{"mono": gl2d.DefaultFont()},
+				BoldFonts: map[*gl2d.Font]*gl2d.Font{regular: bold},
+				Images:    map[string]*glutil.Texture{"coin": iconTex},
+			}
+
+			if err := gl2d.DrawRichText("[b]Player1:[/b] hello [color=#ff4040]red[/color] and [color=#40ff4080]translucent green[/color] text", [2]float32{10, 10}, regular, gl2d.White, res, nil); err != nil {
+				return err
+			}
+			if err := gl2d.DrawRichText("You found 25 [img=coin] coins! [size=2]Big [img=coin][/size] and [font=mono]monospace[/font].", [2]float32{10, 40}, regular, gl2d.White, res, nil); err != nil {
+				return err
+			}
+			if err := gl2d.DrawRichText("Faux [b][font=mono]bold mono[/font][/b], literal [[brackets] and\na [size=1.5][color=#80c0ff]second[/color][/size] line.", [2]float32{10, 100}, regular, gl2d.White, res, nil); err != nil {
+				return err
+			}
+
+			markup := "[b]Tooltip:[/b] rich text is [color=#ffff00]wrapped[/color] like plain text, including [img=coin] icons and [size=1.5]larger[/size] words inside a narrow box."
+			size, err := gl2d.MeasureRichText(markup, regular, res, &gl2d.DrawStringOptions{MaxWidth: 260, Wrap: true})
+			if err != nil {
+				return err
+			}
+			gl2d.FillRectangle([2]float32{10, 200}, size, gl2d.DarkGray)
+			if err := gl2d.DrawRichText(markup, [2]float32{10, 200}, regular, gl2d.White, res, &gl2d.DrawStringOptions{MaxWidth: 260, Wrap: true}); err != nil {
+				return err
+			}
+			if err := gl2d.DrawRichText(markup, [2]float32{400, 200}, regular, gl2d.White, res, &gl2d.DrawStringOptions{MaxWidth: 260, Wrap: true, Align: gl2d.TextAlignCenter, MaxLines: 2, Ellipsis: "..."}); err != nil {
+				return err
+			}
+
+			// faux bold glyphs are widened in a single pass, so translucent colors are not blended twice and shadows include the widening
+			if err := gl2d.DrawRichText("[font=mono][b][color=#40ff4080]Translucent faux bold[/color] with glow[/b][/font]", [2]float32{10, 320}, regular, gl2d.White, res, &gl2d.DrawStringOptions{Scale: 3, Shadows: []gl2d.Shadow{gl2d.Glow(4, 1, gl2d.Blue)}}); err != nil {
+				return err
+			}
+
+			for _, invalid := range []string{"[b]unclosed", "[color=red]x[/color]", "[b]x[/i]", "[unknown]"} {
+				if _, err := gl2d.ParseRichText(invalid); err == nil {
+					return fmt.Errorf("markup %q should be invalid", invalid)
+				}
+			}
+			return nil
+		}},
+		{"text-hit-test", func() error {
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 18})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+
+			check := func(str string, pos mgl32.Vec2, font *gl2d.Font, opts *gl2d.DrawStringOptions, selStart, selEnd int, carets []int) error {
+				for _, rect := range gl2d.SelectionRects(str, selStart, selEnd, font, opts) {
+					gl2d.FillRectangle(pos.Add(mgl32.Vec2{rect.Left, rect.Top}), mgl32.Vec2{rect.Right - rect.Left, rect.Bottom - rect.Top}, gl2d.Blue.Alpha(0.6))
+				}
+				gl2d.DrawString(str, pos, font, gl2d.White, opts)
+				for _, index := range carets {
+					caretPos, height := gl2d.CaretPos(str, index, font, opts)
+					gl2d.FillRectangle(pos.Add(caretPos), mgl32.Vec2{1, height}, gl2d.Red)
+				}
+
+				// clicking slightly right of a caret position must return the same index
+				for index := range []rune(str) {
+					caretPos, height := gl2d.CaretPos(str, index, font, opts)
+					if hit := gl2d.RuneIndexAt(str, caretPos.Add(mgl32.Vec2{0.5, height / 2}), font, opts); hit != index {
+						return fmt.Errorf("hit test of %q returned index %d instead of %d", str, hit, index)
+					}
+				}
+				return nil
+			}
+
+			if err := check("Click into this text\nsecond\tline with\ttabs\nÜmlauts äöü", mgl32.Vec2{20, 20}, regular, nil, 6, 35, []int{0, 5, 21, 28, 53}); err != nil {
+				return err
+			}
+			if err := check("Selection in\ndefault font", mgl32.Vec2{450, 20}, gl2d.DefaultFont(), &gl2d.DrawStringOptions{Scale: 2}, 3, 18, []int{3, 18}); err != nil {
+				return err
+			}
+			if err := check("Centered and wrapped text can be selected across lines", mgl32.Vec2{20, 200}, regular, &gl2d.DrawStringOptions{MaxWidth: 200, Wrap: true, Align: gl2d.TextAlignCenter}, 9, 40, []int{9, 40}); err != nil {
+				return err
+			}
+			if err := check("Justified text keeps the caret between stretched words", mgl32.Vec2{400, 200}, regular, &gl2d.DrawStringOptions{MaxWidth: 250, Wrap: true, Align: gl2d.TextAlignJustify}, 10, 30, []int{10, 30}); err != nil {
+				return err
+			}
+
+			// positions outside of the text are clamped to the nearest line
+			if index := gl2d.RuneIndexAt("abc\ndef", mgl32.Vec2{-10, -10}, regular, nil); index != 0 {
+				return fmt.Errorf("expected index 0 instead of %d", index)
+			}
+			if index := gl2d.RuneIndexAt("abc\ndef", mgl32.Vec2{500, 500}, regular, nil); index != 7 {
+				return fmt.Errorf("expected index 7 instead of %d", index)
+			}
+			return nil
+		}},
+		{"font-import", func() error {
+			tmpDir, err := ioutil.TempDir("", "gl2d-rendertest")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(tmpDir)
+
+			face, err := opentype.NewFace(mustParseFont(goregular.TTF), &opentype.FaceOptions{Size: 20, DPI: 72})
+			if err != nil {
+				return err
+			}
+			generated, err := gl2d.NewBitmapFontFromFace(face)
+			if err != nil {
+				return err
+			}
+
+			load := func(bitmapFont *gl2d.BitmapFont, err error) (*gl2d.Font, error) {
+				if err != nil {
+					return nil, err
+				}
+				return gl2d.NewFontFromBitmapFont(bitmapFont)
+			}
+
+			imageFile, metaFile := filepath.Join(tmpDir, "font.png"), filepath.Join(tmpDir, "font.json")
+			if err := generated.Export(imageFile, metaFile); err != nil {
+				return err
+			}
+			exported, err := load(gl2d.LoadBitmapFont(imageFile, metaFile))
+			if err != nil {
+				return err
+			}
+			defer exported.Destroy()
+			gl2d.DrawString("Exported image and meta data", [2]float32{20, 20}, exported, gl2d.White, nil)
+
+			bundleFile := filepath.Join(tmpDir, "font.zip")
+			if err := generated.ExportBundle(bundleFile); err != nil {
+				return err
+			}
+			bundled, err := gl2d.NewFontFromBundle(bundleFile)
+			if err != nil {
+				return err
+			}
+			defer bundled.Destroy()
+			gl2d.DrawString("Single file font bundle", [2]float32{20, 60}, bundled, gl2d.Yellow, &gl2d.DrawStringOptions{Scale: 1.5})
+
+			bmFont, err := load(gl2d.LoadBMFont("bmfont.fnt"))
+			if err != nil {
+				return err
+			}
+			defer bmFont.Destroy()
+			gl2d.DrawString("AngelCode BMFont: AVA (Kerning)", [2]float32{20, 120}, bmFont, gl2d.White, nil)
+			gl2d.DrawString("Wrapped BMFont text with descenders: gjpqy", [2]float32{20, 160}, bmFont, gl2d.Cyan, &gl2d.DrawStringOptions{MaxWidth: 300, Wrap: true, Align: gl2d.TextAlignCenter})
+
+			xmlFont, err := load(gl2d.ReadBMFont(strings.NewReader(`<?xml version="1.0"?>
+<font>
+  <common lineHeight="28" base="23" scaleW="256" scaleH="256" pages="1" packed="0"/>
+  <pages><page id="0" file="bmfont_0.png"/></pages>
+  <chars count="2">
+    <char id="32" x="1" y="1" width="0" height="0" xoffset="0" yoffset="23" xadvance="7" page="0" chnl="15"/>
+    <char id="97" x="2" y="1" width="12" height="15" xoffset="1" yoffset="9" xadvance="13" page="0" chnl="15"/>
+  </chars>
+</font>`), func(file string) (image.Image, error) {
+				return readPNG(file)
+			}))
+			if err != nil {
+				return err
+			}
+			defer xmlFont.Destroy()
+			gl2d.DrawString("a a aaa", [2]float32{400, 120}, xmlFont, gl2d.Green, &gl2d.DrawStringOptions{Scale: 2})
+
+			if _, err := gl2d.ReadBMFont(strings.NewReader("BMF\x03"), nil); err == nil {
+				return fmt.Errorf("binary BMFont should not be supported")
+			}
+			return nil
+		}},
+		{"font-metrics", func() error {
+			small, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 14})
+			if err != nil {
+				return err
+			}
+			defer small.Destroy()
+			large, err := gl2d.NewFontFromData(gobold.TTF, &gl2d.FontOptions{Size: 40})
+			if err != nil {
+				return err
+			}
+			defer large.Destroy()
+
+			// texts of different fonts share the same baseline
+			baseline := float32(100)
+			gl2d.DrawLine([2]float32{10, baseline}, [2]float32{790, baseline}, 1, gl2d.Red)
+			x := float32(20)
+			for _, part := range []struct {
+				str  string
+				font *gl2d.Font
+				opts gl2d.DrawStringOptions
+			}{
+				{"Small ", small, gl2d.DrawStringOptions{}},
+				{"Large ", large, gl2d.DrawStringOptions{}},
+				{"default ", gl2d.DefaultFont(), gl2d.DrawStringOptions{Scale: 2}},
+				{"scaled", small, gl2d.DrawStringOptions{Scale: 3}},
+			} {
+				part.opts.BaselineOrigin = true
+				gl2d.DrawString(part.str, [2]float32{x, baseline}, part.font, gl2d.White, &part.opts)
+				x += gl2d.MeasureString(part.str, part.font, &part.opts)[0]
+			}
+
+			// ascent and descent of the large font
+			gl2d.DrawLine([2]float32{10, baseline - large.Ascent()}, [2]float32{790, baseline - large.Ascent()}, 1, gl2d.Green.Alpha(0.6))
+			gl2d.DrawLine([2]float32{10, baseline + large.Descent()}, [2]float32{790, baseline + large.Descent()}, 1, gl2d.Blue)
+
+			// ink boxes and advances of single glyphs
+			x = 20
+			for _, r := range "Agjy%." {
+				m, ok := large.GlyphMetrics(r)
+				if !ok {
+					return fmt.Errorf("missing metrics of rune %q", r)
+				}
+				gl2d.DrawString(string(r), [2]float32{x, 250}, large, gl2d.White, &gl2d.DrawStringOptions{BaselineOrigin: true})
+				gl2d.DrawRectangle([2]float32{x + m.Bounds.Left, 250 + m.Bounds.Top}, [2]float32{m.Bounds.Right - m.Bounds.Left, m.Bounds.Bottom - m.Bounds.Top}, 1, gl2d.Yellow)
+				gl2d.DrawLine([2]float32{x, 260}, [2]float32{x + m.Advance, 260}, 1, gl2d.Cyan)
+				x += m.Advance + 20
+			}
+
+			if m, _ := large.GlyphMetrics('g'); m.Bounds.Bottom <= 0 || m.Bounds.Top >= 0 {
+				return fmt.Errorf("unexpected bounds of descender: %v", m.Bounds)
+			}
+			if small.Descent() <= 0 || gl2d.DefaultFont().Descent() <= 0 {
+				return fmt.Errorf("fonts must have a descent")
+			}
+
+			// metrics survive export and import of bitmap fonts
+			face, err := opentype.NewFace(mustParseFont(goregular.TTF), &opentype.FaceOptions{Size: 30, DPI: 72})
+			if err != nil {
+				return err
+			}
+			generated, err := gl2d.NewBitmapFontFromFace(face)
+			if err != nil {
+				return err
+			}
+			var bundle bytes.Buffer
+			if err := generated.WriteBundle(&bundle); err != nil {
+				return err
+			}
+			imported, err := gl2d.ReadBitmapFontBundle(bytes.NewReader(bundle.Bytes()), int64(bundle.Len()))
+			if err != nil {
+				return err
+			}
+			importedFont, err := gl2d.NewFontFromBitmapFont(imported)
+			if err != nil {
+				return err
+			}
+			defer importedFont.Destroy()
+			if importedFont.Descent() != int26ToFloat32(face.Metrics().Descent) {
+				return fmt.Errorf("imported descent %f does not match font face", importedFont.Descent())
+			}
+			gl2d.DrawLine([2]float32{10, 400}, [2]float32{790, 400}, 1, gl2d.Red)
+			gl2d.DrawString("Imported gjpqy", [2]float32{20, 400}, importedFont, gl2d.White, &gl2d.DrawStringOptions{BaselineOrigin: true})
+			gl2d.DrawString("and face", [2]float32{20 + gl2d.MeasureString("Imported gjpqy ", importedFont, nil)[0], 400}, large, gl2d.White, &gl2d.DrawStringOptions{BaselineOrigin: true})
+			return nil
+		}},
+		{"sprite-batch", func() error {
+			// images of various sizes with a one pixel border to reveal bleeding
+			var images []glutil.AtlasImage
+			colors := []color.RGBA{{255, 80, 80, 255}, {80, 255, 80, 255}, {80, 80, 255, 255}, {255, 255, 80, 255}, {255, 80, 255, 255}}
+			for i := 0; i < 15; i++ {
+				w, h := 8+(i*7)%40, 8+(i*13)%32
+				img := image.NewRGBA(image.Rect(0, 0, w, h))
+				draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+				draw.Draw(img, img.Bounds().Inset(1), image.NewUniform(colors[i%len(colors)]), image.Point{}, draw.Src)
+				images = append(images, glutil.AtlasImage{Name: fmt.Sprintf("box%d", i), Image: img})
+			}
+			// a sprite sheet with an arrow pointing right that moves downwards frame by frame
+			sheet := image.NewRGBA(image.Rect(0, 0, 64, 32))
+			for frame := 0; frame < 8; frame++ {
+				ox, oy := (frame%4)*16, (frame/4)*16
+				for y := 0; y < 5; y++ {
+					for x := 0; x <= 5-y; x++ {
+						sheet.Set(ox+4+x, oy+4+frame+y, color.RGBA{255, 160, 0, 255})
+						sheet.Set(ox+4+x, oy+4+frame-y, color.RGBA{255, 160, 0, 255})
+					}
+				}
+			}
+			frames, err := glutil.SliceSpriteSheet("arrow", sheet, 16, 16, 8)
+			if err != nil {
+				return err
+			}
+			images = append(images, frames...)
+
+			atlas, err := glutil.NewTextureAtlas(images, &glutil.AtlasOptions{MaxPageSize: 128, Extrude: true})
+			if err != nil {
+				return err
+			}
+			if err := atlas.Upload(gl2d.NewTexture, glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterLinear)); err != nil {
+				return err
+			}
+			defer atlas.Destroy()
+
+			regions := atlas.Regions()
+			for i, r1 := range regions {
+				for _, r2 := range regions[i+1:] {
+					if r1.Page == r2.Page && r1.Rect.Overlaps(r2.Rect) {
+						return fmt.Errorf("atlas regions %q and %q overlap", r1.Name, r2.Name)
+					}
+				}
+			}
+
+			// atlas pages
+			x := float32(10)
+			for _, tex := range atlas.Textures {
+				gl2d.DrawRectangle([2]float32{x - 1, 9}, [2]float32{float32(tex.Width) + 2, float32(tex.Height) + 2}, 1, gl2d.DarkGray)
+				gl2d.DrawImage(tex, gl2d.Quad{Left: x, Top: 10, Right: x + float32(tex.Width), Bottom: 10 + float32(tex.Height)})
+				x += float32(tex.Width) + 10
+			}
+
+			batch := gl2d.NewSpriteBatch()
+			batch.Begin()
+			for i := 0; i < 400; i++ {
+				region := regions[i%len(regions)]
+				batch.Add(gl2d.Sprite{
+					Region:   region,
+					Pos:      mgl32.Vec2{float32(20 + (i%20)*38), float32(200 + (i/20)*19)},
+					Origin:   mgl32.Vec2{0.5, 0.5},
+					Rotation: float32(i) * 0.3,
+					Scale:    mgl32.Vec2{0.6, 0.6},
+					Depth:    float32(i % 3),
+				})
+			}
+			// depth decides the overlap regardless of the order
+			batch.Add(gl2d.Sprite{Region: regions[1], Pos: mgl32.Vec2{600, 60}, Size: mgl32.Vec2{60, 60}, Depth: 10})
+			batch.Add(gl2d.Sprite{Region: regions[2], Pos: mgl32.Vec2{620, 80}, Size: mgl32.Vec2{60, 60}, Depth: 5, Tint: gl2d.Color{1, 1, 1, 0.8}})
+			arrow, _ := atlas.Region("arrow/3")
+			batch.Add(gl2d.Sprite{Region: arrow, Pos: mgl32.Vec2{450, 40}, Scale: mgl32.Vec2{3, 3}})
+			batch.Add(gl2d.Sprite{Region: arrow, Pos: mgl32.Vec2{450, 100}, Scale: mgl32.Vec2{3, 3}, FlipX: true})
+			batch.Add(gl2d.Sprite{Region: arrow, Pos: mgl32.Vec2{520, 70}, Origin: mgl32.Vec2{0.5, 0.5}, Rotation: math.Pi / 2, Scale: mgl32.Vec2{3, 3}, Tint: gl2d.Cyan})
+			if batch.Len() != 405 {
+				return fmt.Errorf("unexpected sprite count %d", batch.Len())
+			}
+			batch.End()
+
+			// sprites without texture are skipped, also when sorting by texture
+			unloaded, err := glutil.NewTextureAtlas(images[:1], nil)
+			if err != nil {
+				return err
+			}
+			unloadedRegion, _ := unloaded.Region("box0")
+			batch.SortMode = gl2d.SpriteSortDepthTexture
+			batch.Begin()
+			batch.Add(gl2d.Sprite{Region: regions[3], Pos: mgl32.Vec2{700, 40}, Size: mgl32.Vec2{40, 40}})
+			batch.Add(gl2d.Sprite{Pos: mgl32.Vec2{700, 40}, Size: mgl32.Vec2{40, 40}})
+			batch.Add(gl2d.Sprite{Region: unloadedRegion, Pos: mgl32.Vec2{710, 50}})
+			batch.Add(gl2d.Sprite{Region: regions[4], Pos: mgl32.Vec2{720, 60}, Size: mgl32.Vec2{40, 40}})
+			batch.End()
+
+			anim, err := glutil.AnimationFromAtlasRegions(atlas.Sequence("arrow"), 1)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < 8; i++ {
+				anim.SetCurrentFrame(i)
+				gl2d.DrawAnimation(anim, gl2d.Quad{Left: 300 + float32(i)*34, Top: 150, Right: 332 + float32(i)*34, Bottom: 182})
+			}
+			return nil
+		}},
+		{"atlas-import", func() error {
+			params := glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterNearest)
+
+			// TexturePacker hash format with trimmed frames
+			packed, err := glutil.LoadAtlasJSON("atlas-texturepacker.json")
+			if err != nil {
+				return err
+			}
+			if err := packed.Upload(gl2d.NewTexture, params); err != nil {
+				return err
+			}
+			defer packed.Destroy()
+			regions := packed.Regions()
+			if len(regions) != 6 || regions[0].Name != "walk_0.png" || regions[5].Name != "idle_1.png" {
+				return fmt.Errorf("unexpected regions in TexturePacker atlas")
+			}
+			if !regions[0].IsTrimmed() || regions[0].Offset != image.Pt(4, 6) || regions[0].SourceSize != image.Pt(32, 32) {
+				return fmt.Errorf("unexpected trimming of %q", regions[0].Name)
+			}
+
+			batch := gl2d.NewSpriteBatch()
+			batch.Begin()
+			for i, region := range regions {
+				pos := mgl32.Vec2{float32(10 + i*110), 10}
+				gl2d.DrawRectangle([2]float32{pos[0] - 1, pos[1] - 1}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+				batch.Add(gl2d.Sprite{Region: region, Pos: pos, Scale: mgl32.Vec2{3, 3}})
+			}
+			// flipped sprites mirror the trimming offset
+			walk, _ := packed.Region("walk_0.png")
+			gl2d.DrawRectangle([2]float32{9, 119}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+			batch.Add(gl2d.Sprite{Region: walk, Pos: mgl32.Vec2{10, 120}, Scale: mgl32.Vec2{3, 3}, FlipX: true})
+			gl2d.DrawRectangle([2]float32{119, 119}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+			batch.Add(gl2d.Sprite{Region: walk, Pos: mgl32.Vec2{168, 168}, Origin: mgl32.Vec2{0.5, 0.5}, Size: mgl32.Vec2{96, 96}, Rotation: math.Pi / 2})
+			batch.End()
+
+			// Aseprite array format with frame durations and tags
+			sheet, err := glutil.LoadAtlasJSON("atlas-aseprite.json")
+			if err != nil {
+				return err
+			}
+			if err := sheet.Upload(gl2d.NewTexture, params); err != nil {
+				return err
+			}
+			defer sheet.Destroy()
+			anim, err := glutil.AnimationFromAtlas(sheet, 0.1)
+			if err != nil {
+				return err
+			}
+			if anim.FrameCount() != 6 || math.Abs(anim.Duration()-1.6) > 1e-9 || math.Abs(anim.FrameDuration(1)-0.2) > 1e-9 {
+				return fmt.Errorf("unexpected animation timing: %d frames, %f seconds", anim.FrameCount(), anim.Duration())
+			}
+			if tag, ok := anim.Tag("idle"); !ok || tag.From != 4 || tag.To != 5 {
+				return fmt.Errorf("missing idle tag")
+			}
+			for i, t := range []float64{0.05, 0.2, 0.35, 0.5, 0.8, 1.3, 1.59} {
+				anim.SetCurrentTime(t)
+				dst := gl2d.Quad{Left: float32(10 + i*110), Top: 240, Right: float32(106 + i*110), Bottom: 336}
+				gl2d.DrawRectangle([2]float32{dst.Left - 1, dst.Top - 1}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+				gl2d.DrawAnimation(anim, dst)
+			}
+
+			idle, err := glutil.AnimationFromAtlasTag(sheet, "idle", 0.1)
+			if err != nil {
+				return err
+			}
+			if idle.FrameCount() != 2 || idle.Duration() != 1 {
+				return fmt.Errorf("unexpected idle animation")
+			}
+			idle.FlipX = true
+			for i := 0; i < 2; i++ {
+				idle.SetCurrentFrame(i)
+				dst := gl2d.Quad{Left: float32(10 + i*110), Top: 360, Right: float32(106 + i*110), Bottom: 456}
+				gl2d.DrawRectangle([2]float32{dst.Left - 1, dst.Top - 1}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+				gl2d.DrawAnimation(idle, dst)
+			}
+			walkAnim, err := glutil.AnimationFromAtlasTag(sheet, "walk", 0.1)
+			if err != nil {
+				return err
+			}
+			walkAnim.FlipX = true
+			walkAnim.SetCurrentFrame(2)
+			gl2d.DrawRectangle([2]float32{229, 359}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+			gl2d.DrawAnimation(walkAnim, gl2d.Quad{Left: 230, Top: 360, Right: 326, Bottom: 456})
+			return nil
+		}},
+		{"animation-playback", func() error {
+			params := glutil.TexParam(glutil.TextureWrapClampToEdge, glutil.TextureFilterNearest)
+			expectFrames := func(name string, actual, expected []int) error {
+				if fmt.Sprint(actual) != fmt.Sprint(expected) {
+					return fmt.Errorf("%s: expected frames %v but got %v", name, expected, actual)
+				}
+				return nil
+			}
+
+			// bars of increasing height to identify the frames
+			var images []glutil.AtlasImage
+			for i := 0; i < 5; i++ {
+				img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+				draw.Draw(img, image.Rect(2, 13-2*i, 14, 16), image.NewUniform(color.RGBA{uint8(80 + 40*i), 200, uint8(240 - 40*i), 255}), image.Point{}, draw.Src)
+				draw.Draw(img, image.Rect(4, 0, 12, 4), image.NewUniform(color.White), image.Point{}, draw.Src)
+				images = append(images, glutil.AtlasImage{Name: fmt.Sprintf("bar/%d", i), Image: img})
+			}
+			atlas, err := glutil.NewTextureAtlas(images, nil)
+			if err != nil {
+				return err
+			}
+			if err := atlas.Upload(gl2d.NewTexture, params); err != nil {
+				return err
+			}
+			defer atlas.Destroy()
+			anim, err := glutil.AnimationFromAtlasRegions(atlas.Sequence("bar"), 0.5)
+			if err != nil {
+				return err
+			}
+
+			var frames []int
+			loops, finished := 0, 0
+			anim.OnFrameChange = func(anim *glutil.Animation, frame int) { frames = append(frames, frame) }
+			anim.OnLoop = func(anim *glutil.Animation) { loops++ }
+			anim.OnFinished = func(anim *glutil.Animation) { finished++ }
+			drawFrames := func(row int, frames []int, flipX, flipY bool) {
+				anim.FlipX, anim.FlipY = flipX, flipY
+				for i, f := range frames {
+					anim.SetCurrentFrame(f)
+					dst := gl2d.Quad{Left: float32(10 + i*50), Top: float32(10 + row*60), Right: float32(58 + i*50), Bottom: float32(58 + row*60)}
+					gl2d.DrawRectangle([2]float32{dst.Left - 1, dst.Top - 1}, [2]float32{50, 50}, 1, gl2d.DarkGray)
+					gl2d.DrawAnimation(anim, dst)
+				}
+				anim.FlipX, anim.FlipY = false, false
+			}
+
+			// time steps start in the middle of a frame to avoid rounding issues at frame boundaries
+			anim.Loop = true
+			anim.Direction = glutil.AnimationPingPong
+			if anim.PlaybackDuration() != 0.8 {
+				return fmt.Errorf("unexpected ping-pong duration %f", anim.PlaybackDuration())
+			}
+			anim.SetCurrentTime(0.05)
+			for i := 0; i < 10; i++ {
+				anim.Update(0.1)
+			}
+			if err := expectFrames("ping-pong", frames, []int{1, 2, 3, 4, 3, 2, 1, 0, 1, 2}); err != nil {
+				return err
+			}
+			if loops != 1 || finished != 0 {
+				return fmt.Errorf("unexpected ping-pong events: %d loops, %d finished", loops, finished)
+			}
+			drawFrames(0, frames, false, false)
+
+			frames = nil
+			anim.Loop = false
+			anim.Direction = glutil.AnimationReverse
+			anim.SetCurrentTime(0.05)
+			for i := 0; i < 8; i++ {
+				anim.Update(0.1)
+			}
+			if err := expectFrames("reverse", frames, []int{3, 2, 1, 0}); err != nil {
+				return err
+			}
+			if !anim.IsFinished() || finished != 1 {
+				return fmt.Errorf("reverse animation finished %d times", finished)
+			}
+			drawFrames(1, frames, false, false)
+
+			frames = nil
+			anim.Direction = glutil.AnimationForward
+			anim.Speed = 2
+			anim.SetCurrentTime(0.05)
+			for i := 0; i < 3; i++ {
+				anim.Update(0.05)
+			}
+			if err := expectFrames("speed", frames, []int{1, 2, 3}); err != nil {
+				return err
+			}
+			anim.Speed = 0
+			anim.Update(1)
+			if err := expectFrames("paused", frames, []int{1, 2, 3}); err != nil {
+				return err
+			}
+			drawFrames(2, []int{0, 1, 2, 3, 4}, true, true)
+
+			// tags with individual frame durations
+			sheet, err := glutil.LoadAtlasJSON("atlas-aseprite.json")
+			if err != nil {
+				return err
+			}
+			if err := sheet.Upload(gl2d.NewTexture, params); err != nil {
+				return err
+			}
+			defer sheet.Destroy()
+			hero, err := glutil.AnimationFromAtlas(sheet, 0.1)
+			if err != nil {
+				return err
+			}
+			frames = nil
+			hero.OnFrameChange = func(anim *glutil.Animation, frame int) { frames = append(frames, frame) }
+			if err := hero.SetTag("walk"); err != nil {
+				return err
+			}
+			if hero.CurrentTag() != "walk" || math.Abs(hero.PlaybackDuration()-0.6) > 1e-9 {
+				return fmt.Errorf("unexpected walk duration %f", hero.PlaybackDuration())
+			}
+			hero.SetCurrentTime(0.025)
+			for i := 0; i < 14; i++ {
+				hero.Update(0.05)
+			}
+			if err := expectFrames("walk", frames, []int{1, 2, 3}); err != nil {
+				return err
+			}
+			if !hero.IsFinished() {
+				return fmt.Errorf("walk animation has not finished")
+			}
+			if err := hero.SetTag("idle"); err != nil {
+				return err
+			}
+			if hero.Direction != glutil.AnimationPingPong || hero.PlaybackDuration() != 1 {
+				return fmt.Errorf("unexpected idle playback")
+			}
+			if err := hero.SetTag("run"); err == nil {
+				return fmt.Errorf("expected error for unknown tag")
+			}
+			// clearing the tag keeps the direction of the tag
+			hero.ClearTag()
+			hero.Direction = glutil.AnimationForward
+			if hero.CurrentTag() != "" || math.Abs(hero.PlaybackDuration()-1.6) > 1e-9 {
+				return fmt.Errorf("unexpected playback after clearing tag")
+			}
+			for i, flip := range [][2]bool{{false, false}, {true, false}, {false, true}, {true, true}} {
+				hero.FlipX, hero.FlipY = flip[0], flip[1]
+				hero.SetCurrentFrame(0)
+				dst := gl2d.Quad{Left: float32(10 + i*110), Top: 200, Right: float32(106 + i*110), Bottom: 296}
+				gl2d.DrawRectangle([2]float32{dst.Left - 1, dst.Top - 1}, [2]float32{98, 98}, 1, gl2d.DarkGray)
+				gl2d.DrawAnimation(hero, dst)
+			}
+			return nil
+		}},
+		{"bidi-text", func() error {
+			regular, err := gl2d.NewFontFromData(goregular.TTF, &gl2d.FontOptions{Size: 18})
+			if err != nil {
+				return err
+			}
+			defer regular.Destroy()
+
+			rtl := &gl2d.DrawStringOptions{Direction: gl2d.TextDirectionRTL, MaxWidth: 300, Align: gl2d.TextAlignRight}
+			for i, str := range []string{"left to right (123)", "(brackets) are mirrored", "words keep [their] order", "tab\tstops"} {
+				gl2d.DrawString(str, [2]float32{20, 20 + 30*float32(i)}, regular, gl2d.White, nil)
+				gl2d.DrawString(str, [2]float32{400, 20 + 30*float32(i)}, regular, gl2d.Yellow, rtl)
+			}
+
+			str := "selection (across runs)"
+			pos := mgl32.Vec2{400, 200}
+			for _, rect := range gl2d.SelectionRects(str, 5, 15, regular, rtl) {
+				gl2d.FillRectangle(pos.Add(mgl32.Vec2{rect.Left, rect.Top}), mgl32.Vec2{rect.Right - rect.Left, rect.Bottom - rect.Top}, gl2d.Blue.Alpha(0.6))
+			}
+			gl2d.DrawString(str, pos, regular, gl2d.White, rtl)
+			for _, index := range []int{5, 15} {
+				caretPos, height := gl2d.CaretPos(str, index, regular, rtl)
+				gl2d.FillRectangle(pos.Add(caretPos), mgl32.Vec2{1, height}, gl2d.Red)
+			}
+			// the trailing bracket forms a right-to-left run, so the caret positions at both run boundaries coincide
+			for index := 1; index < 22; index++ {
+				caretPos, height := gl2d.CaretPos(str, index, regular, rtl)
+				if hit := gl2d.RuneIndexAt(str, caretPos.Add(mgl32.Vec2{0.5, height / 2}), regular, rtl); hit != index {
+					return fmt.Errorf("hit test of %q returned index %d instead of %d", str, hit, index)
+				}
+			}
+
+			// combining marks without advance are centered on their base rune
+			if size := gl2d.MeasureString("a\u0301", regular, nil); size != gl2d.MeasureString("a", regular, nil) {
+				return fmt.Errorf("combining mark changed the string size to %v", size)
+			}
+
+			// goregular lacks hebrew and arabic letters, so unsupported runes are replaced by boxes to lay out right-to-left scripts
+			face, err := opentype.NewFace(mustParseFont(goregular.TTF), &opentype.FaceOptions{Size: 18, DPI: 72})
+			if err != nil {
+				return err
+			}
+			boxed := gl2d.NewCachedFontFromFace(boxFace{face}, nil)
+			defer boxed.Destroy()
+			for i, c := range []struct {
+				str, visual string
+			}{
+				{"سلام", "\uFEE1\uFEFC\uFEB3"},
+				{"שלום 123 abc", "abc 123 םולש"},
+			} {
+				layout := gl2d.LayoutText(c.str, boxed, nil)
+				visual := ""
+				for _, g := range layout.Lines[0].Glyphs {
+					visual += string(g.Rune)
+				}
+				if visual != c.visual {
+					return fmt.Errorf("%q is laid out as %U instead of %U", c.str, []rune(visual), []rune(c.visual))
+				}
+				gl2d.DrawTextLayout(layout, mgl32.Vec2{20, 300 + 30*float32(i)}, gl2d.White)
+			}
+			return nil
+		}},
+	}
+
+	return tests
+}
+
+// boxFace extends a font face by a box glyph for all runes the face does not support.
+type boxFace struct {
+	font.Face
+}
+
+func (f boxFace) boxBounds() (fixed.Rectangle26_6, fixed.Int26_6) {
+	m := f.Metrics()
+	advance := m.Height / 2
+	return fixed.Rectangle26_6{Min: fixed.Point26_6{X: advance / 8, Y: -m.Ascent * 3 / 4}, Max: fixed.Point26_6{X: advance * 7 / 8}}, advance
+}
+
+func (f boxFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	if dr, mask, maskp, advance, ok := f.Face.Glyph(dot, r); ok {
+		return dr, mask, maskp, advance, true
+	}
+	bounds, advance := f.boxBounds()
+	dr := image.Rect((dot.X + bounds.Min.X).Round(), (dot.Y + bounds.Min.Y).Round(), (dot.X + bounds.Max.X).Round(), (dot.Y + bounds.Max.Y).Round())
+	mask := image.NewAlpha(image.Rect(0, 0, dr.Dx(), dr.Dy()))
+	for y := 0; y < dr.Dy(); y++ {
+		for x := 0; x < dr.Dx(); x++ {
+			if x == 0 || y == 0 || x == dr.Dx()-1 || y == dr.Dy()-1 {
+				mask.SetAlpha(x, y, color.Alpha{255})
+			}
+		}
+	}
+	return dr, mask, image.Point{}, advance, true
+}
+
+func (f boxFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	if bounds, advance, ok := f.Face.GlyphBounds(r); ok {
+		return bounds, advance, true
+	}
+	bounds, advance := f.boxBounds()
+	return bounds, advance, true
+}
+
+func (f boxFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	if advance, ok := f.Face.GlyphAdvance(r); ok {
+		return advance, true
+	}
+	_, advance := f.boxBounds()
+	return advance, true
+}
+
+func mustParseFont(data []byte) *opentype.Font {
+	f, err := opentype.Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+func int26ToFloat32(i fixed.Int26_6) float32 {
+	return float32(float64(i) / 64)
+}
+
+func getEffectTestDefinitions() []effectTestDefinition {
+	fillScreen := func(color gl2d.Color) func() {
+		return func() {
+			gl2d.FillRectangle([2]float32{0, 0}, [2]float32{windowWidth, windowHeight}, color)
+		}
+	}
+
+	return []effectTestDefinition{
+		{
+			Name: "layer-effects",
+			Layers: []effectTestLayer{
+				// the grayscale effect of the bottom layer must not affect the upper layer
+				{RenderFunc: fillScreen(gl2d.Color{0.2, 0.4, 0.6, 1}), Effects: []glui.Effect{glui.ColorGrading(0, 1, 0)}},
+				{RenderFunc: func() {
+					gl2d.FillRectangle([2]float32{windowWidth / 2, 0}, [2]float32{windowWidth / 2, windowHeight}, gl2d.Color{0.9, 0.3, 0.1, 1})
+				}},
+			},
+			Effects: []glui.Effect{glui.ColorGrading(0.1, 1, 1)},
+			Check: func(img *image.RGBA) error {
+				brighter := glui.ColorGrading(0.1, 1, 1)
+				if err := checkPixel(img, 200, 300, applyColorMatrix(brighter, applyColorMatrix(glui.ColorGrading(0, 1, 0), mgl32.Vec4{0.2, 0.4, 0.6, 1}))); err != nil {
+					return err
+				}
+				return checkPixel(img, 600, 300, applyColorMatrix(brighter, mgl32.Vec4{0.9, 0.3, 0.1, 1}))
+			},
 		},
-	})
-	mainWindow.Run()
+		{
+			Name: "blur",
+			Layers: []effectTestLayer{
+				{RenderFunc: func() {
+					gl2d.FillRectangle([2]float32{0, 0}, [2]float32{windowWidth / 2, windowHeight}, gl2d.White)
+				}, Effects: []glui.Effect{glui.GaussianBlur{Radius: 16}}},
+			},
+			Check: func(img *image.RGBA) error {
+				if err := checkPixel(img, 100, 300, mgl32.Vec4{1, 1, 1, 1}); err != nil {
+					return err
+				}
+				if err := checkPixel(img, 700, 300, mgl32.Vec4{0, 0, 0, 1}); err != nil {
+					return err
+				}
+				// the edge between both halves fades over the blur radius
+				left, mid, right := img.RGBAAt(390, 300).R, img.RGBAAt(400, 300).R, img.RGBAAt(410, 300).R
+				if !(left > mid && mid > right && mid > 50 && mid < 205) {
+					return fmt.Errorf("edge is not blurred: %d, %d, %d", left, mid, right)
+				}
+				return nil
+			},
+		},
+		{
+			Name:    "vignette",
+			Layers:  []effectTestLayer{{RenderFunc: fillScreen(gl2d.White)}},
+			Effects: []glui.Effect{glui.Vignette{Radius: 0.4, Softness: 0.4, Strength: 1}},
+			Check: func(img *image.RGBA) error {
+				if err := checkPixel(img, windowWidth/2, windowHeight/2, mgl32.Vec4{1, 1, 1, 1}); err != nil {
+					return err
+				}
+				return checkPixel(img, 0, 0, mgl32.Vec4{0, 0, 0, 1})
+			},
+		},
+	}
+}
 
-	if errorCount > 0 {
-		gl2d.Terminate()
-		glui.Terminate()
-		logrus.Fatalf("%d of %d tests have failed", errorCount, len(tests))
-	} else {
-		logrus.Infof("tests successful, exiting test application")
+// applyColorMatrix transforms a color like the color matrix shader.
+func applyColorMatrix(m glui.ColorMatrix, color mgl32.Vec4) mgl32.Vec4 {
+	result := m.Matrix.Mul4x1(color).Add(m.Offset)
+	for i := range result {
+		result[i] = float32(math.Max(0, math.Min(1, float64(result[i]))))
+	}
+	return result
+}
+
+// checkColorGrading validates the matrices of glui.ColorGrading, which does not require OpenGL.
+func checkColorGrading() error {
+	checks := []struct {
+		name                             string
+		brightness, contrast, saturation float32
+		color, expected                  mgl32.Vec4
+	}{
+		{"neutral", 0, 1, 1, mgl32.Vec4{0.2, 0.5, 0.9, 0.7}, mgl32.Vec4{0.2, 0.5, 0.9, 0.7}},
+		{"brightness", 0.1, 1, 1, mgl32.Vec4{0.2, 0.5, 0.8, 1}, mgl32.Vec4{0.3, 0.6, 0.9, 1}},
+		{"contrast", 0, 2, 1, mgl32.Vec4{0.25, 0.5, 0.75, 1}, mgl32.Vec4{0, 0.5, 1, 1}},
+		{"grayscale", 0, 1, 0, mgl32.Vec4{1, 0, 0, 1}, mgl32.Vec4{0.2126, 0.2126, 0.2126, 1}},
+		{"saturation", 0, 1, 2, mgl32.Vec4{0.5, 0.5, 0.5, 1}, mgl32.Vec4{0.5, 0.5, 0.5, 1}},
+	}
+	for _, check := range checks {
+		m := glui.ColorGrading(check.brightness, check.contrast, check.saturation)
+		if result := m.Matrix.Mul4x1(check.color).Add(m.Offset); !result.ApproxEqualThreshold(check.expected, 1e-5) {
+			return fmt.Errorf("%s maps %v to %v instead of %v", check.name, check.color, result, check.expected)
+		}
+	}
+	return nil
+}
+
+// checkPixel compares the color of a pixel with an expected color, where small deviations are tolerated.
+func checkPixel(img *image.RGBA, x, y int, expected mgl32.Vec4) error {
+	c := img.RGBAAt(x, y)
+	for i, v := range []uint8{c.R, c.G, c.B} {
+		if math.Abs(float64(v)-255*float64(expected[i])) > 3 {
+			return fmt.Errorf("pixel %d,%d is %v instead of %v", x, y, [3]uint8{c.R, c.G, c.B}, expected.Vec3())
+		}
 	}
+	return nil
 }
 
-func gl2dTest(w *glui.MainWindow, test testDefinition) error {
-	test.RenderFunc()
+func gl2dTest(getCurrentImage func() (*image.RGBA, error), test testDefinition) error {
+	if err := test.RenderFunc(); err != nil {
+		return err
+	}
+	gl2d.Flush()
 
-	currentImage, err := getCurrentImageFromOpenGL(w)
+	currentImage, err := getCurrentImage()
 	if err != nil {
 		return fmt.Errorf("could not get current image: %s", err.Error())
 	}
@@ -228,6 +1694,16 @@ func getCurrentImageFromOpenGL(w *glui.MainWindow) (*image.RGBA, error) {
 	return img, nil
 }
 
+func getCurrentImageFromSoftware(target *image.RGBA) *image.RGBA {
+	// ignore the alpha channel like for images read from OpenGL
+	img := image.NewRGBA(target.Bounds())
+	copy(img.Pix, target.Pix)
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
 func writePNG(file string, img image.Image) error {
 	f, err := os.Create(file)
 	if err != nil {